@@ -0,0 +1,380 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+type Config struct {
+	Timezone     string   `toml:"timezone"`
+	Columns      []string `toml:"columns"`
+	FilterStates []string `toml:"states"`
+	FilterName   string   `toml:"name"`
+	VisualBell   bool     `toml:"visual_bell"`
+	Cluster      string   `toml:"cluster"`
+	AllUsers     bool     `toml:"all_users"`
+	User         string   `toml:"user"`
+	Partition    string   `toml:"partition"`
+	Account      string   `toml:"account"`
+	GRES         string   `toml:"gres"`
+	CompactMode  bool     `toml:"compact"`
+
+	CheckpointDir string `toml:"checkpoint_dir"`
+	StorePath     string `toml:"store_path"`
+	RemoteLogHost string `toml:"remote_log_host"`
+	RemoteLogUser string `toml:"remote_log_user"`
+
+	InitialFocus      string `toml:"focus"`
+	InitialFocusArea  int    `toml:"-"` // 0 jobs, 1 stdout, 2 stderr; parsed from InitialFocus
+	InitialMergedMode bool   `toml:"merged_mode"`
+	InitialFollow     bool   `toml:"follow_by_default"`
+
+	RefreshInterval  time.Duration `toml:"refresh_interval"`
+	LogDir           string        `toml:"log_dir"`
+	LogPattern       string        `toml:"log_pattern"`
+	LogOutPattern    string        `toml:"log_out_pattern"` // full stdout path; overrides log_dir/log_pattern
+	LogErrPattern    string        `toml:"log_err_pattern"`
+	Theme            string        `toml:"theme"`
+	TabWidth         int           `toml:"tab_width"`
+	InitialTailBytes int64         `toml:"initial_tail_bytes"`
+	InitialTailLines int           `toml:"initial_tail_lines"` // overrides initial_tail_bytes when > 0
+	MaxJobs          int           `toml:"max_jobs"`
+
+	// KeyBindings remaps keys: each entry makes the key on the left act
+	// like the built-in key on the right, e.g. x = "c".
+	KeyBindings map[string]string `toml:"keybindings"`
+
+	// AutoDismissStates maps a terminal state to how long a job stays
+	// listed after reaching it. Zero means never auto-dismiss; absent
+	// states fall back to TerminalRetentionDuration.
+	AutoDismissStates map[string]time.Duration `toml:"auto_dismiss"`
+
+	// TerminalRetentionDuration dismisses terminal jobs without an
+	// AutoDismissStates rule last seen this long ago. Zero dismisses them
+	// on the next tick; negative never does.
+	TerminalRetentionDuration time.Duration `toml:"terminal_retention"`
+}
+
+func (c Config) hasColumn(name string) bool {
+	for _, col := range c.Columns {
+		if strings.EqualFold(col, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Config) showsOtherUsers() bool {
+	return c.AllUsers || strings.Contains(c.User, ",")
+}
+
+func defaultConfig() Config {
+	return Config{
+		InitialFocus:     "jobs",
+		InitialFollow:    true,
+		RefreshInterval:  jobsRefreshEvery,
+		LogDir:           "slurm_logs",
+		LogPattern:       "%j",
+		Theme:            "default",
+		TabWidth:         8,
+		InitialTailBytes: initialTailBytes,
+
+		TerminalRetentionDuration: time.Hour,
+	}
+}
+
+const minRefreshInterval = 500 * time.Millisecond
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.RefreshInterval < minRefreshInterval {
+		errs = append(errs, fmt.Errorf("refresh_interval %s: must be at least %s", c.RefreshInterval, minRefreshInterval))
+	}
+	if c.LogPattern == "" {
+		errs = append(errs, errors.New("log_pattern: must not be empty"))
+	}
+	if _, ok := themes[c.Theme]; !ok {
+		errs = append(errs, fmt.Errorf("theme %q: want one of %s", c.Theme, strings.Join(themeNames(), ", ")))
+	}
+	if c.TabWidth < 1 || c.TabWidth > 16 {
+		errs = append(errs, fmt.Errorf("tab_width %d: must be between 1 and 16", c.TabWidth))
+	}
+	if c.InitialTailBytes <= 0 {
+		errs = append(errs, fmt.Errorf("initial_tail_bytes %d: must be positive", c.InitialTailBytes))
+	}
+	if c.InitialFocus != "" {
+		if _, err := parseFocusArea(c.InitialFocus); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.InitialTailLines < 0 {
+		errs = append(errs, fmt.Errorf("initial_tail_lines %d: must not be negative (0 reads initial_tail_bytes)", c.InitialTailLines))
+	}
+	if c.MaxJobs < 0 {
+		errs = append(errs, fmt.Errorf("max_jobs %d: must not be negative (0 means unlimited)", c.MaxJobs))
+	}
+	for key, target := range c.KeyBindings {
+		if key == "" || target == "" {
+			errs = append(errs, fmt.Errorf("keybindings %q = %q: keys must not be empty", key, target))
+		}
+	}
+	for state, d := range c.AutoDismissStates {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("auto_dismiss %s: duration must not be negative", state))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "slurm-tui", "config.toml")
+}
+
+// loadConfig overlays the TOML file at path on top of base. A missing
+// file is not an error; keys absent from the file keep their base values.
+func loadConfig(path string, base Config) (Config, error) {
+	cfg := base
+	if path == "" {
+		return cfg, nil
+	}
+	md, err := toml.DecodeFile(path, &cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		return base, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return cfg, fmt.Errorf("config %s: unknown keys %s", path, strings.Join(keys, ", "))
+	}
+	for state, d := range cfg.AutoDismissStates {
+		if upper := strings.ToUpper(state); upper != state {
+			delete(cfg.AutoDismissStates, state)
+			cfg.AutoDismissStates[upper] = d
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+	if cfg.InitialFocus != "" {
+		cfg.InitialFocusArea, _ = parseFocusArea(cfg.InitialFocus)
+	}
+	return cfg, nil
+}
+
+// configPathFromArgs finds --config before flag parsing so the file can
+// supply defaults that command-line flags then override.
+func configPathFromArgs(args []string) string {
+	path := defaultConfigPath()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			path = value
+		} else if i+1 < len(args) {
+			path = args[i+1]
+			i++
+		}
+	}
+	return path
+}
+
+const defaultConfigTemplate = `# slurm-tui configuration. Every key is optional; command-line flags
+# override values set here.
+
+# How often squeue is polled, e.g. "5s" or "1m". At least 500ms.
+refresh_interval = %q
+
+# Directory holding job logs and the file name pattern inside it, without
+# the .out/.err suffix. Both accept %%j (job ID), %%n (job name), %%u (user),
+# %%N (first node) and %%%% (a literal percent sign).
+log_dir = %q
+log_pattern = %q
+
+# Full stdout/stderr path patterns with the same placeholders, for logs that
+# do not share a directory and base name, e.g. "/scratch/%%u/%%n-%%j.log".
+# Empty uses log_dir and log_pattern.
+log_out_pattern = %q
+log_err_pattern = %q
+
+# Show stdout and stderr merged into one pane at startup.
+merged_mode = %t
+
+# Follow log output of the selected job at startup.
+follow_by_default = %t
+
+# Pane focused at startup: jobs, stdout or stderr.
+focus = %q
+
+# Color theme: %s.
+theme = %q
+
+# Columns between tab stops when rendering logs.
+tab_width = %d
+
+# How much of an existing log is read when a job is selected, in bytes.
+initial_tail_bytes = %d
+
+# Read the last N lines instead of initial_tail_bytes; 0 disables.
+initial_tail_lines = %d
+
+# Show at most this many jobs; 0 means unlimited.
+max_jobs = %d
+
+# Federation cluster passed to squeue/scontrol/scancel as --cluster.
+cluster = %q
+
+# Only list jobs in these comma-separated partitions; empty lists all.
+partition = %q
+
+# Only list jobs charged to these comma-separated accounts; empty lists all.
+account = %q
+
+# Only list jobs requesting this generic resource, e.g. "gpu"; empty lists all.
+gres = %q
+
+# IANA time zone of the cluster, e.g. "America/New_York"; empty means local.
+timezone = %q
+
+# Flash the jobs panel when a job fails, times out or is cancelled.
+visual_bell = %t
+
+# Start without panel borders.
+compact = %t
+
+# Where log read offsets are remembered across restarts; empty disables.
+checkpoint_dir = %q
+
+# Where dismissed jobs and first-seen times are kept across restarts; empty disables.
+store_path = %q
+
+# Dismiss terminal jobs this long after they were last seen; "0s" dismisses
+# them right away and a negative duration keeps them until dismissed by hand.
+terminal_retention = %q
+
+# Remap keys: the key on the left acts like the built-in key on the right.
+[keybindings]
+# x = "c"
+
+# Auto-dismiss terminal jobs per state after a delay.
+[auto_dismiss]
+# COMPLETED = "10m"
+`
+
+func writeDefaultConfig(path string, cfg Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	content := fmt.Sprintf(defaultConfigTemplate,
+		cfg.RefreshInterval.String(),
+		cfg.LogDir,
+		cfg.LogPattern,
+		cfg.LogOutPattern,
+		cfg.LogErrPattern,
+		cfg.InitialMergedMode,
+		cfg.InitialFollow,
+		cfg.InitialFocus,
+		strings.Join(themeNames(), ", "),
+		cfg.Theme,
+		cfg.TabWidth,
+		cfg.InitialTailBytes,
+		cfg.InitialTailLines,
+		cfg.MaxJobs,
+		cfg.Cluster,
+		cfg.Partition,
+		cfg.Account,
+		cfg.GRES,
+		cfg.Timezone,
+		cfg.VisualBell,
+		cfg.CompactMode,
+		cfg.CheckpointDir,
+		cfg.StorePath,
+		cfg.TerminalRetentionDuration.String(),
+	)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type theme struct {
+	selectedBg string
+	selectedFg string
+	flashBg    string
+	gpuBadge   string
+	columnSep  string
+	markedBg   string
+}
+
+var themes = map[string]theme{
+	"default": {selectedBg: "238", selectedFg: "255", flashBg: "196", gpuBadge: "141", columnSep: "240", markedBg: "24"},
+	"light":   {selectedBg: "153", selectedFg: "16", flashBg: "210", gpuBadge: "91", columnSep: "248", markedBg: "223"},
+	"mono":    {selectedBg: "250", selectedFg: "16", flashBg: "244", gpuBadge: "250", columnSep: "244", markedBg: "246"},
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseFocusArea(name string) (int, error) {
+	switch strings.ToLower(name) {
+	case "jobs":
+		return 0, nil
+	case "stdout", "out", "logs":
+		return 1, nil
+	case "stderr", "err":
+		return 2, nil
+	}
+	return 0, fmt.Errorf("unknown focus %q: want jobs, stdout or stderr", name)
+}
+
+func parseAutoDismiss(spec string) (map[string]time.Duration, error) {
+	out := make(map[string]time.Duration)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		state, raw, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("auto-dismiss %q: want STATE=DURATION", part)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("auto-dismiss %q: invalid duration", part)
+		}
+		out[strings.ToUpper(strings.TrimSpace(state))] = d
+	}
+	return out, nil
+}
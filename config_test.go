@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigMissingFileUsesDefaults(t *testing.T) {
+	cfg, err := loadConfig(filepath.Join(t.TempDir(), "absent.toml"), defaultConfig())
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, defaultConfig()) {
+		t.Fatalf("expected defaults, got %#v", cfg)
+	}
+}
+
+func TestLoadConfigOverlaysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `refresh_interval = "30s"
+log_dir = "/scratch/me/logs"
+merged_mode = true
+follow_by_default = false
+focus = "stderr"
+theme = "mono"
+max_jobs = 50
+
+[keybindings]
+x = "c"
+
+[auto_dismiss]
+completed = "10m"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(path, defaultConfig())
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if cfg.RefreshInterval != 30*time.Second || cfg.LogDir != "/scratch/me/logs" || !cfg.InitialMergedMode || cfg.InitialFollow {
+		t.Fatalf("file values not applied: %#v", cfg)
+	}
+	if cfg.Theme != "mono" || cfg.MaxJobs != 50 || cfg.KeyBindings["x"] != "c" || cfg.InitialFocusArea != 2 {
+		t.Fatalf("file values not applied: %#v", cfg)
+	}
+	if cfg.AutoDismissStates["COMPLETED"] != 10*time.Minute {
+		t.Fatalf("expected auto_dismiss states upper-cased, got %v", cfg.AutoDismissStates)
+	}
+	if cfg.LogPattern != "%j" || cfg.TabWidth != 8 || cfg.InitialTailBytes != initialTailBytes {
+		t.Fatalf("absent keys should keep defaults: %#v", cfg)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown key", "refresh = \"5s\"\n", "unknown keys refresh"},
+		{"wrong type", "tab_width = \"wide\"\n", "tab_width"},
+		{"too fast", "refresh_interval = \"100ms\"\n", "at least 500ms"},
+		{"bad theme", "theme = \"neon\"\n", "want one of default, light, mono"},
+		{"negative max", "max_jobs = -1\n", "max_jobs -1"},
+		{"bad focus", "focus = \"sidebar\"\n", "unknown focus \"sidebar\""},
+	}
+	for _, tc := range cases {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadConfig(path, defaultConfig())
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestWriteDefaultConfigRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slurm-tui", "config.toml")
+	if err := writeDefaultConfig(path, defaultConfig()); err != nil {
+		t.Fatalf("writeDefaultConfig: %v", err)
+	}
+	cfg, err := loadConfig(path, Config{})
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	want := defaultConfig()
+	cfg.KeyBindings, cfg.AutoDismissStates = nil, nil
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("round trip mismatch:\n got %#v\nwant %#v", cfg, want)
+	}
+	if err := writeDefaultConfig(path, defaultConfig()); err == nil {
+		t.Fatalf("expected an existing config file not to be overwritten")
+	}
+}
+
+func TestConfigPathFromArgs(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--config", "/etc/a.toml"}, "/etc/a.toml"},
+		{[]string{"-config=/etc/b.toml", "--merged"}, "/etc/b.toml"},
+		{[]string{"--merged", "--", "--config", "x"}, defaultConfigPath()},
+		{nil, defaultConfigPath()},
+	}
+	for _, tc := range cases {
+		if got := configPathFromArgs(tc.args); got != tc.want {
+			t.Fatalf("configPathFromArgs(%q) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
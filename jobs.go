@@ -1,6 +1,19 @@
 package main
 
-import "time"
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Job struct {
 	ID        string
@@ -9,23 +22,59 @@ type Job struct {
 	Time      string
 	TimeLimit string
 	Nodes     string
+	TRES      string
+	User      string
+	Partition string
+	Reason    string // why a pending job waits, without parentheses
+	Account   string
+	QOS       string
+	Priority  int
+	Position  int // queue rank of a pending job, 0 when unknown
+
+	ArrayJobID  string
+	ArrayTaskID string
+	ArrayTasks  int // task count on a collapsed array row
+}
+
+func (j Job) IsArrayTask() bool {
+	return j.ArrayJobID != "" && j.ArrayTasks == 0
+}
+
+func (j Job) IsArrayGroup() bool {
+	return j.ArrayTasks > 0
 }
 
 type JobRecord struct {
-	Job       Job
-	FirstSeen time.Time
-	LastSeen  time.Time
-	Terminal  bool
-	Dismissed bool
+	Job        Job
+	FirstSeen  time.Time
+	LastSeen   time.Time
+	Terminal   bool
+	TerminalAt time.Time
+	Dismissed  bool
+
+	LogOutPath      string
+	LogErrPath      string
+	LogPathsQueried bool // scontrol was asked, even if it had no answer
+
+	Efficiency *EfficiencyReport
+	Accounting *AccountingInfo
+	LiveStats  *LiveStats
 }
 
 type JobStore struct {
-	records map[string]JobRecord
-	order   []string
+	records     map[string]JobRecord
+	order       []string
+	expanded    map[string]bool
+	arrayGroups map[string][]string // array parent ID -> task IDs in first-seen order
 }
 
 func NewJobStore() JobStore {
-	return JobStore{records: make(map[string]JobRecord), order: []string{}}
+	return JobStore{
+		records:     make(map[string]JobRecord),
+		order:       []string{},
+		expanded:    make(map[string]bool),
+		arrayGroups: make(map[string][]string),
+	}
 }
 
 func isActiveState(state string) bool {
@@ -41,52 +90,371 @@ func isTerminalState(state string) bool {
 	}
 }
 
+type StateChange struct {
+	JobID    string
+	OldState string
+	NewState string
+}
+
 func (s *JobStore) ApplySnapshot(jobs []Job, now time.Time) {
+	s.ApplySnapshotWithDiff(jobs, now)
+}
+
+func (s *JobStore) ApplySnapshotWithDiff(jobs []Job, now time.Time) []StateChange {
+	var changes []StateChange
 	seen := make(map[string]bool, len(jobs))
 
 	for _, incoming := range jobs {
 		seen[incoming.ID] = true
 
 		rec, exists := s.records[incoming.ID]
+		oldState := ""
 		if !exists {
 			rec = JobRecord{Job: incoming, FirstSeen: now}
 			s.order = append(s.order, incoming.ID)
+			if parent := incoming.ArrayJobID; parent != "" {
+				s.arrayGroups[parent] = append(s.arrayGroups[parent], incoming.ID)
+			}
+		} else {
+			oldState = rec.Job.State
+		}
+		if oldState != incoming.State {
+			changes = append(changes, StateChange{JobID: incoming.ID, OldState: oldState, NewState: incoming.State})
 		}
 
 		rec.Job = incoming
 		rec.LastSeen = now
-		rec.Terminal = isTerminalState(incoming.State)
+		terminal := isTerminalState(incoming.State)
+		if terminal && !rec.Terminal {
+			rec.TerminalAt = now
+		}
+		rec.Terminal = terminal
 		s.records[incoming.ID] = rec
 	}
 
-	for id, rec := range s.records {
-		if seen[id] {
+	for _, id := range s.order {
+		rec, ok := s.records[id]
+		if !ok || seen[id] {
 			continue
 		}
 		if !rec.Terminal {
+			changes = append(changes, StateChange{JobID: id, OldState: rec.Job.State, NewState: "COMPLETED"})
 			rec.Job.State = "COMPLETED"
 			rec.Terminal = true
+			rec.TerminalAt = now
 			rec.LastSeen = now
 			s.records[id] = rec
 		}
 	}
+
+	return changes
 }
 
 func (s *JobStore) VisibleJobs() []Job {
 	jobs := make([]Job, 0, len(s.order))
+	groups := make(map[string]int)
 	for _, id := range s.order {
 		rec, ok := s.records[id]
 		if !ok || rec.Dismissed {
 			continue
 		}
-		jobs = append(jobs, rec.Job)
+		parent := rec.Job.ArrayJobID
+		if parent == "" || s.expanded[parent] {
+			jobs = append(jobs, rec.Job)
+			continue
+		}
+		if _, seen := groups[parent]; !seen {
+			groups[parent] = len(jobs)
+			jobs = append(jobs, s.arraySummary(parent))
+		}
 	}
 	return jobs
 }
 
+func (s *JobStore) arrayTasks(parent string) []Job {
+	var tasks []Job
+	for _, id := range s.arrayGroups[parent] {
+		rec, ok := s.records[id]
+		if ok && !rec.Dismissed {
+			tasks = append(tasks, rec.Job)
+		}
+	}
+	return tasks
+}
+
+func (s *JobStore) arraySummary(parent string) Job {
+	tasks := s.arrayTasks(parent)
+	counts := make(map[string]int)
+	for _, t := range tasks {
+		counts[t.State]++
+	}
+	state := summaryState(counts)
+	first := tasks[0]
+	return Job{
+		ID:         parent,
+		Name:       fmt.Sprintf("%s [%d/%d %s]", first.Name, counts[state], len(tasks), state),
+		State:      state,
+		Time:       first.Time,
+		TimeLimit:  first.TimeLimit,
+		TRES:       first.TRES,
+		Partition:  first.Partition,
+		Account:    first.Account,
+		QOS:        first.QOS,
+		ArrayJobID: parent,
+		ArrayTasks: len(tasks),
+	}
+}
+
+func summaryState(counts map[string]int) string {
+	for _, state := range []string{"RUNNING", "PENDING", "FAILED", "TIMEOUT", "OUT_OF_MEMORY", "NODE_FAIL", "CANCELLED"} {
+		if counts[state] > 0 {
+			return state
+		}
+	}
+	best, n := "", -1
+	for state, c := range counts {
+		if c > n || c == n && state < best {
+			best, n = state, c
+		}
+	}
+	return best
+}
+
+func (s *JobStore) ToggleArrayExpansion(parentID string) bool {
+	s.expanded[parentID] = !s.expanded[parentID]
+	return s.expanded[parentID]
+}
+
+func filterJobsByState(jobs []Job, state string) []Job {
+	if state == "" {
+		return jobs
+	}
+	var filtered []Job
+	for _, job := range jobs {
+		if job.State == state {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered
+}
+
+// filterJobsByPartition keeps jobs in one of the comma-separated partitions.
+// Jobs recorded before the partition was known are kept.
+func filterJobsByPartition(jobs []Job, partition string) []Job {
+	if partition == "" {
+		return jobs
+	}
+	wanted := strings.Split(partition, ",")
+	var filtered []Job
+	for _, job := range jobs {
+		if job.Partition == "" || slices.Contains(wanted, job.Partition) {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered
+}
+
+// filterJobsByAccount keeps jobs charged to one of the comma-separated
+// accounts. Jobs recorded before the account was known are kept.
+func filterJobsByAccount(jobs []Job, account string) []Job {
+	if account == "" {
+		return jobs
+	}
+	wanted := strings.Split(account, ",")
+	var filtered []Job
+	for _, job := range jobs {
+		if job.Account == "" || slices.Contains(wanted, job.Account) {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered
+}
+
+// filterJobsByGRES keeps jobs that request the generic resource gresType,
+// e.g. gpu.
+func filterJobsByGRES(jobs []Job, gresType string) []Job {
+	if gresType == "" {
+		return jobs
+	}
+	var filtered []Job
+	for _, job := range jobs {
+		for _, entry := range strings.Split(job.TRES, ",") {
+			if g := parseGRES(entry); strings.EqualFold(g.Type, gresType) && g.Count > 0 {
+				filtered = append(filtered, job)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// computeQueuePositions ranks pending jobs within each partition they were
+// submitted to, highest priority first and by job ID on ties, keyed by
+// partition and then job ID.
+func computeQueuePositions(jobs []Job) map[string]map[string]int {
+	byPartition := make(map[string][]Job)
+	for _, job := range jobs {
+		if job.State != "PENDING" {
+			continue
+		}
+		for _, partition := range strings.Split(job.Partition, ",") {
+			byPartition[partition] = append(byPartition[partition], job)
+		}
+	}
+	positions := make(map[string]map[string]int, len(byPartition))
+	for partition, queue := range byPartition {
+		sort.SliceStable(queue, func(i, j int) bool {
+			if queue[i].Priority != queue[j].Priority {
+				return queue[i].Priority > queue[j].Priority
+			}
+			return compareJobIDs(queue[i].ID, queue[j].ID) < 0
+		})
+		ranks := make(map[string]int, len(queue))
+		for i, job := range queue {
+			ranks[job.ID] = i + 1
+		}
+		positions[partition] = ranks
+	}
+	return positions
+}
+
+// applyQueuePositions sets Position on pending jobs to their best rank
+// across the partitions they were submitted to.
+func applyQueuePositions(jobs []Job) {
+	positions := computeQueuePositions(jobs)
+	for i, job := range jobs {
+		jobs[i].Position = 0
+		if job.State != "PENDING" {
+			continue
+		}
+		for _, partition := range strings.Split(job.Partition, ",") {
+			if pos := positions[partition][job.ID]; pos > 0 && (jobs[i].Position == 0 || pos < jobs[i].Position) {
+				jobs[i].Position = pos
+			}
+		}
+	}
+}
+
+// matchesJobSearch reports whether the job ID or name contains query,
+// ignoring case. An empty query matches every job.
+func matchesJobSearch(job Job, query string) bool {
+	query = strings.ToLower(query)
+	return strings.Contains(strings.ToLower(job.ID), query) || strings.Contains(strings.ToLower(job.Name), query)
+}
+
+var sortFields = []string{"id", "name", "state", "time", "node"}
+
+// sortJobs returns a stably sorted copy of jobs. An empty field keeps the
+// store's first-seen order.
+func sortJobs(jobs []Job, field string, asc bool) []Job {
+	sorted := append([]Job(nil), jobs...)
+	if field == "" {
+		return sorted
+	}
+	cmp := func(a, b Job) int {
+		switch field {
+		case "id":
+			return compareJobIDs(a.ID, b.ID)
+		case "name":
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		case "state":
+			return strings.Compare(a.State, b.State)
+		case "time":
+			return cmpInt64(int64(jobElapsed(a)), int64(jobElapsed(b)))
+		case "node":
+			return strings.Compare(a.Nodes, b.Nodes)
+		}
+		return 0
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if asc {
+			return cmp(sorted[i], sorted[j]) < 0
+		}
+		return cmp(sorted[i], sorted[j]) > 0
+	})
+	return sorted
+}
+
+// compareJobIDs orders "9" before "10" and array tasks "7_2" before "7_10".
+func compareJobIDs(a, b string) int {
+	aJob, aTask, _ := strings.Cut(a, "_")
+	bJob, bTask, _ := strings.Cut(b, "_")
+	if c := compareNumeric(aJob, bJob); c != 0 {
+		return c
+	}
+	return compareNumeric(aTask, bTask)
+}
+
+func compareNumeric(a, b string) int {
+	an, aErr := strconv.ParseInt(a, 10, 64)
+	bn, bErr := strconv.ParseInt(b, 10, 64)
+	if aErr == nil && bErr == nil {
+		return cmpInt64(an, bn)
+	}
+	return strings.Compare(a, b)
+}
+
+func cmpInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func jobElapsed(j Job) time.Duration {
+	d, err := parseSlurmDuration(j.Time)
+	if err != nil {
+		return -1
+	}
+	return d
+}
+
+func (s *JobStore) FilteredVisibleJobs(states []string, name string) []Job {
+	jobs := s.VisibleJobs()
+	if len(states) == 0 && name == "" {
+		return jobs
+	}
+	filtered := jobs[:0]
+	for _, job := range jobs {
+		if matchesStates(job.State, states) && (matchesName(job.Name, name) || job.ID == name) {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered
+}
+
+func matchesStates(state string, states []string) bool {
+	if len(states) == 0 {
+		return true
+	}
+	for _, s := range states {
+		if strings.EqualFold(strings.TrimSpace(s), state) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesName(jobName, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+	if strings.ContainsAny(pattern, "*?[") {
+		ok, err := path.Match(pattern, jobName)
+		return err == nil && ok
+	}
+	return strings.Contains(strings.ToLower(jobName), strings.ToLower(pattern))
+}
+
 func (s *JobStore) DismissIfTerminal(jobID string) bool {
 	rec, ok := s.records[jobID]
-	if !ok || !rec.Terminal {
+	if !ok {
+		return s.dismissArrayIfTerminal(jobID)
+	}
+	if !rec.Terminal {
 		return false
 	}
 	rec.Dismissed = true
@@ -94,6 +462,26 @@ func (s *JobStore) DismissIfTerminal(jobID string) bool {
 	return true
 }
 
+func (s *JobStore) dismissArrayIfTerminal(parent string) bool {
+	var ids []string
+	for _, id := range s.arrayGroups[parent] {
+		rec := s.records[id]
+		if rec.Dismissed {
+			continue
+		}
+		if !rec.Terminal {
+			return false
+		}
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		rec := s.records[id]
+		rec.Dismissed = true
+		s.records[id] = rec
+	}
+	return len(ids) > 0
+}
+
 func (s *JobStore) ClearDismissedAndTerminal() {
 	for id, rec := range s.records {
 		if rec.Terminal {
@@ -103,7 +491,239 @@ func (s *JobStore) ClearDismissedAndTerminal() {
 	}
 }
 
+func (s *JobStore) DismissExpiredByState(cfg map[string]time.Duration, now time.Time) []string {
+	var dismissed []string
+	for _, id := range s.order {
+		rec := s.records[id]
+		if !rec.Terminal || rec.Dismissed {
+			continue
+		}
+		ttl := cfg[rec.Job.State]
+		if ttl <= 0 || now.Sub(rec.TerminalAt) < ttl {
+			continue
+		}
+		rec.Dismissed = true
+		s.records[id] = rec
+		dismissed = append(dismissed, id)
+	}
+	return dismissed
+}
+
+// AutoDismissExpired dismisses terminal jobs last seen at least maxAge ago.
+// States with their own rule in perState are left to DismissExpiredByState.
+// A negative maxAge disables it.
+func (s *JobStore) AutoDismissExpired(now time.Time, maxAge time.Duration, perState map[string]time.Duration) []string {
+	if maxAge < 0 {
+		return nil
+	}
+	var dismissed []string
+	for _, id := range s.order {
+		rec := s.records[id]
+		if !rec.Terminal || rec.Dismissed || now.Sub(rec.LastSeen) < maxAge {
+			continue
+		}
+		if _, ok := perState[rec.Job.State]; ok {
+			continue
+		}
+		rec.Dismissed = true
+		s.records[id] = rec
+		dismissed = append(dismissed, id)
+	}
+	return dismissed
+}
+
+func (s *JobStore) SetEfficiency(jobID string, report EfficiencyReport) {
+	rec, ok := s.records[jobID]
+	if !ok {
+		return
+	}
+	rec.Efficiency = &report
+	s.records[jobID] = rec
+}
+
+func (s *JobStore) SetAccounting(jobID string, info AccountingInfo) {
+	rec, ok := s.records[jobID]
+	if !ok {
+		return
+	}
+	rec.Accounting = &info
+	s.records[jobID] = rec
+}
+
+func (s *JobStore) SetLiveStats(jobID string, stats LiveStats) {
+	rec, ok := s.records[jobID]
+	if !ok {
+		return
+	}
+	rec.LiveStats = &stats
+	s.records[jobID] = rec
+}
+
+func (s *JobStore) AllRecords() []JobRecord {
+	records := make([]JobRecord, 0, len(s.order))
+	for _, id := range s.order {
+		if rec, ok := s.records[id]; ok {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
 func (s *JobStore) Record(jobID string) (JobRecord, bool) {
 	rec, ok := s.records[jobID]
 	return rec, ok
 }
+
+func (s *JobStore) SetLogPaths(jobID, outPath, errPath string) {
+	rec, ok := s.records[jobID]
+	if !ok {
+		return
+	}
+	rec.LogOutPath = outPath
+	rec.LogErrPath = errPath
+	s.records[jobID] = rec
+}
+
+// SetLogPathsQueried records the outcome of asking scontrol for the log
+// paths; empty paths keep the ones already stored.
+func (s *JobStore) SetLogPathsQueried(jobID, outPath, errPath string) {
+	rec, ok := s.records[jobID]
+	if !ok {
+		return
+	}
+	if outPath != "" || errPath != "" {
+		rec.LogOutPath = outPath
+		rec.LogErrPath = errPath
+	}
+	rec.LogPathsQueried = true
+	s.records[jobID] = rec
+}
+
+func defaultStorePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "slurm-tui", "store.json")
+}
+
+// dismissedStoreRetention is how long a dismissed job that squeue no longer
+// reports stays in the store file. Jobs squeue still lists are kept so
+// they don't come back undismissed.
+const dismissedStoreRetention = 7 * 24 * time.Hour
+
+// PruneDismissed drops dismissed records last seen at least maxAge ago and
+// returns their IDs.
+func (s *JobStore) PruneDismissed(now time.Time, maxAge time.Duration) []string {
+	var pruned []string
+	kept := s.order[:0]
+	for _, id := range s.order {
+		rec := s.records[id]
+		if rec.Dismissed && now.Sub(rec.LastSeen) >= maxAge {
+			delete(s.records, id)
+			pruned = append(pruned, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+	if len(pruned) == 0 {
+		return nil
+	}
+	for parent, ids := range s.arrayGroups {
+		live := ids[:0]
+		for _, id := range ids {
+			if _, ok := s.records[id]; ok {
+				live = append(live, id)
+			}
+		}
+		if len(live) == 0 {
+			delete(s.arrayGroups, parent)
+			delete(s.expanded, parent)
+			continue
+		}
+		s.arrayGroups[parent] = live
+	}
+	return pruned
+}
+
+// Save writes one JSON record per line in first-seen order.
+func (s *JobStore) Save(path string) error {
+	return saveRecords(path, s.AllRecords())
+}
+
+// saveRecords goes through a temporary file so a failed write never
+// truncates the previous store.
+func saveRecords(path string, records []JobRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load adds the records saved at path to the store, skipping malformed lines
+// and jobs the store already knows.
+func (s *JobStore) Load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var rec JobRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || validateRecord(rec) != nil {
+			continue
+		}
+		if _, ok := s.records[rec.Job.ID]; ok {
+			continue
+		}
+		s.records[rec.Job.ID] = rec
+		s.order = append(s.order, rec.Job.ID)
+		if parent := rec.Job.ArrayJobID; parent != "" {
+			s.arrayGroups[parent] = append(s.arrayGroups[parent], rec.Job.ID)
+		}
+	}
+	return scanner.Err()
+}
+
+func validateRecord(rec JobRecord) error {
+	switch {
+	case rec.Job.ID == "":
+		return errors.New("missing job ID")
+	case rec.Job.State == "":
+		return fmt.Errorf("job %s: missing state", rec.Job.ID)
+	case rec.FirstSeen.IsZero() || rec.LastSeen.Before(rec.FirstSeen):
+		return fmt.Errorf("job %s: invalid first/last seen times", rec.Job.ID)
+	case rec.Terminal != isTerminalState(rec.Job.State):
+		return fmt.Errorf("job %s: terminal flag does not match state %s", rec.Job.ID, rec.Job.State)
+	case rec.Terminal && rec.TerminalAt.IsZero():
+		return fmt.Errorf("job %s: terminal without a time", rec.Job.ID)
+	case rec.Job.ArrayJobID != "" && rec.Job.ArrayTasks > 0:
+		return fmt.Errorf("job %s: stored array summary row", rec.Job.ID)
+	}
+	return nil
+}
@@ -1,6 +1,12 @@
 package main
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -59,3 +65,570 @@ func TestJobStoreMarksMissingCompletingAsTerminal(t *testing.T) {
 		t.Fatalf("expected dismiss to succeed for terminal job")
 	}
 }
+
+func TestJobStoreFilteredVisibleJobs(t *testing.T) {
+	store := NewJobStore()
+	store.ApplySnapshot([]Job{
+		{ID: "1", Name: "train-a", State: "RUNNING"},
+		{ID: "2", Name: "eval", State: "RUNNING"},
+		{ID: "3", Name: "train-b", State: "PENDING"},
+	}, time.Now())
+
+	if got := store.FilteredVisibleJobs(nil, ""); len(got) != 3 {
+		t.Fatalf("expected all 3 jobs without filter, got %d", len(got))
+	}
+	got := store.FilteredVisibleJobs([]string{"RUNNING"}, "train*")
+	if len(got) != 1 || got[0].ID != "1" {
+		t.Fatalf("unexpected filtered jobs: %+v", got)
+	}
+	got = store.FilteredVisibleJobs(nil, "TRAIN")
+	if len(got) != 2 {
+		t.Fatalf("expected substring match on 2 jobs, got %d", len(got))
+	}
+}
+
+func TestApplySnapshotWithDiff(t *testing.T) {
+	now := time.Now()
+	store := NewJobStore()
+
+	changes := store.ApplySnapshotWithDiff([]Job{{ID: "1", State: "RUNNING"}}, now)
+	if len(changes) != 1 || changes[0] != (StateChange{JobID: "1", OldState: "", NewState: "RUNNING"}) {
+		t.Fatalf("unexpected diff for new job: %+v", changes)
+	}
+
+	changes = store.ApplySnapshotWithDiff([]Job{{ID: "1", State: "RUNNING"}}, now.Add(time.Second))
+	if len(changes) != 0 {
+		t.Fatalf("expected empty diff without state change, got %+v", changes)
+	}
+
+	changes = store.ApplySnapshotWithDiff([]Job{{ID: "1", State: "COMPLETED"}}, now.Add(2*time.Second))
+	if len(changes) != 1 || changes[0] != (StateChange{JobID: "1", OldState: "RUNNING", NewState: "COMPLETED"}) {
+		t.Fatalf("unexpected diff for transition: %+v", changes)
+	}
+
+	store.ApplySnapshotWithDiff([]Job{{ID: "2", State: "RUNNING"}, {ID: "3", State: "PENDING"}, {ID: "4", State: "PENDING"}}, now.Add(3*time.Second))
+	changes = store.ApplySnapshotWithDiff([]Job{{ID: "3", State: "RUNNING"}, {ID: "4", State: "PENDING"}}, now.Add(4*time.Second))
+	want := []StateChange{
+		{JobID: "3", OldState: "PENDING", NewState: "RUNNING"},
+		{JobID: "2", OldState: "RUNNING", NewState: "COMPLETED"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("change %d: got %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+
+	states := map[string]string{}
+	for _, job := range store.VisibleJobs() {
+		states[job.ID] = job.State
+	}
+	if states["1"] != "COMPLETED" || states["2"] != "COMPLETED" || states["3"] != "RUNNING" || states["4"] != "PENDING" {
+		t.Fatalf("unexpected visible states: %v", states)
+	}
+}
+
+func TestJobStoreSetLogPaths(t *testing.T) {
+	s := NewJobStore()
+	now := time.Now()
+	s.ApplySnapshot([]Job{{ID: "1", State: "RUNNING"}}, now)
+	s.SetLogPaths("1", "/tmp/a.out", "/tmp/a.err")
+	s.SetLogPaths("missing", "/tmp/b.out", "/tmp/b.err")
+
+	s.ApplySnapshot([]Job{{ID: "1", State: "RUNNING"}}, now.Add(time.Second))
+	rec, ok := s.Record("1")
+	if !ok || rec.LogOutPath != "/tmp/a.out" || rec.LogErrPath != "/tmp/a.err" {
+		t.Fatalf("expected log paths to survive refresh, got %#v", rec)
+	}
+	if _, ok := s.Record("missing"); ok {
+		t.Fatalf("SetLogPaths must not create records")
+	}
+}
+
+func TestJobStoreDismissExpiredByState(t *testing.T) {
+	s := NewJobStore()
+	start := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	s.ApplySnapshot([]Job{
+		{ID: "1", State: "RUNNING"},
+		{ID: "2", State: "RUNNING"},
+		{ID: "3", State: "RUNNING"},
+	}, start)
+	s.ApplySnapshot([]Job{
+		{ID: "1", State: "COMPLETED"},
+		{ID: "2", State: "FAILED"},
+		{ID: "3", State: "RUNNING"},
+	}, start.Add(time.Minute))
+
+	rules := map[string]time.Duration{"COMPLETED": 10 * time.Minute, "FAILED": 0}
+	if got := s.DismissExpiredByState(rules, start.Add(5*time.Minute)); len(got) != 0 {
+		t.Fatalf("expected nothing dismissed before ttl, got %v", got)
+	}
+	got := s.DismissExpiredByState(rules, start.Add(11*time.Minute))
+	if len(got) != 1 || got[0] != "1" {
+		t.Fatalf("expected only the completed job dismissed, got %v", got)
+	}
+	if got := s.DismissExpiredByState(rules, start.Add(24*time.Hour)); len(got) != 0 {
+		t.Fatalf("expected FAILED (ttl 0) and running jobs to stay, got %v", got)
+	}
+	visible := s.VisibleJobs()
+	if len(visible) != 2 || visible[0].ID != "2" || visible[1].ID != "3" {
+		t.Fatalf("unexpected visible jobs: %#v", visible)
+	}
+}
+
+func TestJobStoreArrayGrouping(t *testing.T) {
+	s := NewJobStore()
+	now := time.Now()
+	s.ApplySnapshot([]Job{
+		{ID: "7_1", Name: "train", State: "RUNNING", ArrayJobID: "7", ArrayTaskID: "1"},
+		{ID: "8", Name: "solo", State: "RUNNING"},
+		{ID: "7_2", Name: "train", State: "COMPLETED", ArrayJobID: "7", ArrayTaskID: "2"},
+		{ID: "7_3", Name: "train", State: "RUNNING", ArrayJobID: "7", ArrayTaskID: "3"},
+	}, now)
+
+	jobs := s.VisibleJobs()
+	if len(jobs) != 2 || jobs[0].ID != "7" || jobs[1].ID != "8" {
+		t.Fatalf("expected one collapsed row per array in first-seen order, got %#v", jobs)
+	}
+	if jobs[0].Name != "train [2/3 RUNNING]" || jobs[0].State != "RUNNING" || jobs[0].ArrayTasks != 3 {
+		t.Fatalf("unexpected summary row %#v", jobs[0])
+	}
+
+	if !s.ToggleArrayExpansion("7") {
+		t.Fatalf("expected toggle to expand")
+	}
+	if jobs := s.VisibleJobs(); len(jobs) != 4 || jobs[0].ID != "7_1" || jobs[2].ID != "7_2" {
+		t.Fatalf("expected expanded tasks, got %#v", jobs)
+	}
+	s.ToggleArrayExpansion("7")
+
+	if s.DismissIfTerminal("7") {
+		t.Fatalf("expected parent dismiss to fail while tasks are running")
+	}
+	s.ApplySnapshot([]Job{
+		{ID: "7_1", Name: "train", State: "FAILED", ArrayJobID: "7", ArrayTaskID: "1"},
+		{ID: "7_2", Name: "train", State: "COMPLETED", ArrayJobID: "7", ArrayTaskID: "2"},
+		{ID: "7_3", Name: "train", State: "COMPLETED", ArrayJobID: "7", ArrayTaskID: "3"},
+	}, now.Add(time.Second))
+	if jobs := s.VisibleJobs(); jobs[0].Name != "train [1/3 FAILED]" {
+		t.Fatalf("expected failures to dominate the summary, got %q", jobs[0].Name)
+	}
+	if !s.DismissIfTerminal("7") {
+		t.Fatalf("expected parent dismiss to succeed once all tasks are terminal")
+	}
+	if jobs := s.VisibleJobs(); len(jobs) != 1 || jobs[0].ID != "8" {
+		t.Fatalf("expected only the solo job after dismissing the array, got %#v", jobs)
+	}
+}
+
+func TestJobStoreArraySummaryStates(t *testing.T) {
+	s := NewJobStore()
+	now := time.Now()
+	s.ApplySnapshot([]Job{
+		{ID: "9_3", Name: "sweep", State: "COMPLETED", ArrayJobID: "9", ArrayTaskID: "3"},
+		{ID: "9_1", Name: "sweep", State: "PENDING", ArrayJobID: "9", ArrayTaskID: "1"},
+		{ID: "9_2", Name: "sweep", State: "PENDING", ArrayJobID: "9", ArrayTaskID: "2"},
+	}, now)
+	s.ApplySnapshot([]Job{
+		{ID: "9_1", Name: "sweep", State: "PENDING", ArrayJobID: "9", ArrayTaskID: "1"},
+		{ID: "9_2", Name: "sweep", State: "PENDING", ArrayJobID: "9", ArrayTaskID: "2"},
+		{ID: "9_4", Name: "sweep", State: "PENDING", ArrayJobID: "9", ArrayTaskID: "4"},
+	}, now.Add(time.Second))
+
+	if got := s.arrayGroups["9"]; !reflect.DeepEqual(got, []string{"9_3", "9_1", "9_2", "9_4"}) {
+		t.Fatalf("expected tasks indexed in first-seen order, got %v", got)
+	}
+	jobs := s.VisibleJobs()
+	if len(jobs) != 1 || jobs[0].Name != "sweep [3/4 PENDING]" || jobs[0].State != "PENDING" {
+		t.Fatalf("expected pending to outrank completed tasks, got %#v", jobs)
+	}
+
+	s.DismissIfTerminal("9_3")
+	if jobs := s.VisibleJobs(); jobs[0].ArrayTasks != 3 || jobs[0].Name != "sweep [3/3 PENDING]" {
+		t.Fatalf("expected the dismissed task to drop out of the summary, got %#v", jobs[0])
+	}
+}
+
+func TestSortJobs(t *testing.T) {
+	jobs := []Job{
+		{ID: "10", Name: "beta", State: "RUNNING", Time: "1:00:00", Nodes: "n2"},
+		{ID: "9", Name: "Alpha", State: "PENDING", Time: "0:00", Nodes: ""},
+		{ID: "7_10", Name: "gamma", State: "RUNNING", Time: "5:00", Nodes: "n1"},
+		{ID: "7_2", Name: "beta", State: "COMPLETED", Time: "1-00:00:00", Nodes: "n3"},
+	}
+	ids := func(js []Job) []string {
+		out := make([]string, len(js))
+		for i, j := range js {
+			out[i] = j.ID
+		}
+		return out
+	}
+	cases := []struct {
+		field string
+		asc   bool
+		want  []string
+	}{
+		{"", true, []string{"10", "9", "7_10", "7_2"}},
+		{"id", true, []string{"7_2", "7_10", "9", "10"}},
+		{"id", false, []string{"10", "9", "7_10", "7_2"}},
+		{"name", true, []string{"9", "10", "7_2", "7_10"}},
+		{"name", false, []string{"7_10", "10", "7_2", "9"}},
+		{"state", true, []string{"7_2", "9", "10", "7_10"}},
+		{"state", false, []string{"10", "7_10", "9", "7_2"}},
+		{"time", true, []string{"9", "7_10", "10", "7_2"}},
+		{"time", false, []string{"7_2", "10", "7_10", "9"}},
+		{"node", true, []string{"9", "7_10", "10", "7_2"}},
+		{"node", false, []string{"7_2", "10", "7_10", "9"}},
+	}
+	for _, tc := range cases {
+		if got := ids(sortJobs(jobs, tc.field, tc.asc)); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("sortJobs(%q, asc=%v) = %v, want %v", tc.field, tc.asc, got, tc.want)
+		}
+	}
+	if ids(jobs)[0] != "10" {
+		t.Fatalf("expected sortJobs to leave its input untouched")
+	}
+}
+
+func TestFilterJobsByState(t *testing.T) {
+	jobs := []Job{
+		{ID: "1", State: "RUNNING"},
+		{ID: "2", State: "PENDING"},
+		{ID: "3", State: "RUNNING"},
+	}
+	if got := filterJobsByState(jobs, ""); !reflect.DeepEqual(got, jobs) {
+		t.Fatalf("expected all jobs without a filter, got %v", got)
+	}
+	if got := filterJobsByState(jobs, "RUNNING"); len(got) != 2 || got[0].ID != "1" || got[1].ID != "3" {
+		t.Fatalf("expected running jobs only, got %v", got)
+	}
+	if got := filterJobsByState(jobs[:1], "RUNNING"); len(got) != 1 {
+		t.Fatalf("expected an all-match filter to keep every job, got %v", got)
+	}
+	if got := filterJobsByState(jobs, "FAILED"); len(got) != 0 {
+		t.Fatalf("expected no matches, got %v", got)
+	}
+}
+
+func TestMatchesJobSearch(t *testing.T) {
+	job := Job{ID: "4821_3", Name: "Train-ResNet"}
+	cases := map[string]bool{
+		"":       true,
+		"4821":   true,
+		"_3":     true,
+		"resnet": true,
+		"TRAIN":  true,
+		"eval":   false,
+		"48213":  false,
+	}
+	for query, want := range cases {
+		if got := matchesJobSearch(job, query); got != want {
+			t.Fatalf("matchesJobSearch(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestJobStoreSaveLoadRoundTrip(t *testing.T) {
+	first := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	s := NewJobStore()
+	s.ApplySnapshot([]Job{
+		{ID: "100", Name: "train", State: "RUNNING", Time: "1:00", TimeLimit: "4:00:00", Nodes: "gpu01", TRES: "gres/gpu:2", User: "alice"},
+		{ID: "200_1", Name: "sweep", State: "RUNNING", ArrayJobID: "200", ArrayTaskID: "1"},
+		{ID: "200_2", Name: "sweep", State: "PENDING", ArrayJobID: "200", ArrayTaskID: "2"},
+	}, first)
+	s.ApplySnapshot([]Job{{ID: "200_2", Name: "sweep", State: "RUNNING", ArrayJobID: "200", ArrayTaskID: "2"}}, first.Add(time.Hour))
+	s.DismissIfTerminal("100")
+	s.SetLogPaths("100", "/scratch/train.out", "/scratch/train.err")
+	s.SetEfficiency("100", EfficiencyReport{CPUEfficiency: 87.5, MemEfficiency: 40, WallTime: "01:00:00", CPUTime: "00:52:30", CPUKnown: true, MemKnown: true})
+	s.SetAccounting("100", AccountingInfo{ExitCode: "0:0", CPUTime: "00:52:30", MaxRSS: "2G", MaxVMSize: "4G"})
+
+	path := filepath.Join(t.TempDir(), "nested", "store.json")
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewJobStore()
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.AllRecords(), s.AllRecords()) {
+		t.Fatalf("round trip mismatch\n got %+v\nwant %+v", loaded.AllRecords(), s.AllRecords())
+	}
+	if rec, _ := loaded.Record("100"); !rec.Dismissed || !rec.Terminal {
+		t.Fatalf("expected the dismissed terminal job to stay dismissed, got %+v", rec)
+	}
+	if got := loaded.arrayGroups["200"]; !reflect.DeepEqual(got, []string{"200_1", "200_2"}) {
+		t.Fatalf("expected the array index to be rebuilt, got %v", got)
+	}
+}
+
+func TestJobStorePruneDismissed(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := NewJobStore()
+	s.ApplySnapshot([]Job{
+		{ID: "1", State: "FAILED"},
+		{ID: "2", State: "COMPLETED"},
+		{ID: "3_1", State: "COMPLETED", ArrayJobID: "3", ArrayTaskID: "1"},
+		{ID: "4", State: "COMPLETED"},
+	}, start)
+	// Job 2 is still reported by squeue, so it must stay dismissed.
+	now := start.Add(dismissedStoreRetention)
+	s.ApplySnapshot([]Job{{ID: "2", State: "COMPLETED"}}, now)
+	s.expanded["3"] = true
+	for _, id := range []string{"1", "2", "3_1"} {
+		s.DismissIfTerminal(id)
+	}
+
+	if got := s.PruneDismissed(now, dismissedStoreRetention); !reflect.DeepEqual(got, []string{"1", "3_1"}) {
+		t.Fatalf("unexpected pruned IDs %v", got)
+	}
+	var ids []string
+	for _, rec := range s.AllRecords() {
+		ids = append(ids, rec.Job.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"2", "4"}) {
+		t.Fatalf("expected the recent dismissal and the undismissed job kept, got %v", ids)
+	}
+	if _, ok := s.arrayGroups["3"]; ok || s.expanded["3"] {
+		t.Fatalf("expected the emptied array group to be dropped")
+	}
+	if got := s.PruneDismissed(now, dismissedStoreRetention); got != nil {
+		t.Fatalf("expected nothing left to prune, got %v", got)
+	}
+}
+
+func TestJobStoreSaveLoadEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	empty := NewJobStore()
+	if err := empty.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewJobStore()
+	if err := loaded.Load(path); err != nil || len(loaded.AllRecords()) != 0 {
+		t.Fatalf("expected an empty store, got %v %+v", err, loaded.AllRecords())
+	}
+	if err := loaded.Load(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a not-exist error for a missing store, got %v", err)
+	}
+}
+
+func TestJobStoreLoadSkipsMalformedRecords(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	lines := []string{
+		`{"Job":{"ID":"1","State":"RUNNING"},"FirstSeen":"` + now + `","LastSeen":"` + now + `"}`,
+		`not json`,
+		`{"Job":{"ID":"","State":"RUNNING"},"FirstSeen":"` + now + `","LastSeen":"` + now + `"}`,
+		`{"Job":{"ID":"3","State":""},"FirstSeen":"` + now + `","LastSeen":"` + now + `"}`,
+		`{"Job":{"ID":"4","State":"RUNNING"}}`,
+		`{"Job":{"ID":"5","State":"FAILED"},"FirstSeen":"` + now + `","LastSeen":"` + now + `"}`,
+		`{"Job":{"ID":"6","State":"FAILED"},"FirstSeen":"` + now + `","LastSeen":"` + now + `","Terminal":true,"TerminalAt":"` + now + `","Dismissed":true}`,
+		`{"Job":{"ID":"1","State":"FAILED"},"FirstSeen":"` + now + `","LastSeen":"` + now + `"}`,
+	}
+	path := filepath.Join(t.TempDir(), "store.json")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewJobStore()
+	if err := s.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, rec := range s.AllRecords() {
+		ids = append(ids, rec.Job.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"1", "6"}) {
+		t.Fatalf("expected only the valid records, got %v", ids)
+	}
+	if rec, _ := s.Record("1"); rec.Job.State != "RUNNING" {
+		t.Fatalf("expected the first record for a duplicate ID to win, got %+v", rec)
+	}
+}
+
+func TestJobStoreSaveErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "store.json")
+	s := NewJobStore()
+	s.ApplySnapshot([]Job{{ID: "1", State: "RUNNING"}}, time.Now())
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(path)
+
+	blocker := filepath.Join(dir, "file")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(filepath.Join(blocker, "store.json")); err == nil {
+		t.Fatalf("expected an error saving below a regular file")
+	}
+	if err := s.Save(dir); err == nil {
+		t.Fatalf("expected an error saving over a directory")
+	}
+
+	// Concurrent saves each write a complete file through their own temp file.
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.Save(path)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent save: %v", err)
+		}
+	}
+	after, _ := os.ReadFile(path)
+	if string(after) != string(before) {
+		t.Fatalf("expected an intact store after concurrent saves, got %q", after)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(leftovers) != 0 {
+		t.Fatalf("expected temp files to be cleaned up, got %v", leftovers)
+	}
+}
+
+func TestJobStoreAutoDismissExpired(t *testing.T) {
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	newStore := func() JobStore {
+		s := NewJobStore()
+		s.ApplySnapshot([]Job{
+			{ID: "1", State: "RUNNING"},
+			{ID: "2", State: "FAILED"},
+			{ID: "3", State: "PENDING"},
+		}, start)
+		// Job 1 leaves the queue ten minutes later and is marked COMPLETED.
+		s.ApplySnapshot([]Job{{ID: "3", State: "PENDING"}}, start.Add(10*time.Minute))
+		return s
+	}
+
+	s := newStore()
+	if got := s.AutoDismissExpired(start.Add(59*time.Minute), time.Hour, nil); len(got) != 0 {
+		t.Fatalf("expected nothing expired yet, got %v", got)
+	}
+	if got := s.AutoDismissExpired(start.Add(time.Hour), time.Hour, nil); !reflect.DeepEqual(got, []string{"2"}) {
+		t.Fatalf("expected job 2 to expire first, got %v", got)
+	}
+	if got := s.AutoDismissExpired(start.Add(70*time.Minute), time.Hour, nil); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Fatalf("expected job 1 to expire an hour after it was last seen, got %v", got)
+	}
+	if got := s.AutoDismissExpired(start.Add(24*time.Hour), time.Hour, nil); len(got) != 0 {
+		t.Fatalf("expected pending and dismissed jobs to stay, got %v", got)
+	}
+
+	s = newStore()
+	if got := s.AutoDismissExpired(start.Add(10*time.Minute), 0, nil); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Fatalf("expected zero retention to dismiss every terminal job, got %v", got)
+	}
+
+	s = newStore()
+	if got := s.AutoDismissExpired(start.Add(24*time.Hour), -time.Second, nil); len(got) != 0 {
+		t.Fatalf("expected negative retention to dismiss nothing, got %v", got)
+	}
+
+	// A per-state rule wins over the retention, so FAILED = 0 keeps job 2.
+	s = newStore()
+	perState := map[string]time.Duration{"FAILED": 0}
+	now := start.Add(24 * time.Hour)
+	if got := s.AutoDismissExpired(now, time.Hour, perState); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Fatalf("expected only the job without a per-state rule to expire, got %v", got)
+	}
+	if got := s.DismissExpiredByState(perState, now); len(got) != 0 {
+		t.Fatalf("expected FAILED = 0 to never dismiss, got %v", got)
+	}
+}
+
+func TestFilterJobsByPartition(t *testing.T) {
+	jobs := []Job{
+		{ID: "1", Partition: "gpu"},
+		{ID: "2", Partition: "cpu"},
+		{ID: "3", Partition: "debug"},
+		{ID: "4"},
+	}
+	ids := func(jobs []Job) []string {
+		var out []string
+		for _, j := range jobs {
+			out = append(out, j.ID)
+		}
+		return out
+	}
+	if got := ids(filterJobsByPartition(jobs, "")); !reflect.DeepEqual(got, []string{"1", "2", "3", "4"}) {
+		t.Fatalf("expected no filtering, got %v", got)
+	}
+	if got := ids(filterJobsByPartition(jobs, "gpu")); !reflect.DeepEqual(got, []string{"1", "4"}) {
+		t.Fatalf("expected gpu jobs and unknown partitions, got %v", got)
+	}
+	if got := ids(filterJobsByPartition(jobs, "gpu,debug")); !reflect.DeepEqual(got, []string{"1", "3", "4"}) {
+		t.Fatalf("expected a comma-separated list to match either, got %v", got)
+	}
+}
+
+func TestFilterJobsByAccount(t *testing.T) {
+	jobs := []Job{{ID: "1", Account: "ml-lab"}, {ID: "2", Account: "physics"}, {ID: "3"}}
+	var ids []string
+	for _, j := range filterJobsByAccount(jobs, "ml-lab") {
+		ids = append(ids, j.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"1", "3"}) {
+		t.Fatalf("expected ml-lab jobs and unknown accounts, got %v", ids)
+	}
+	if got := filterJobsByAccount(jobs, ""); len(got) != 3 {
+		t.Fatalf("expected no filtering without an account, got %+v", got)
+	}
+}
+
+func TestFilterJobsByGRES(t *testing.T) {
+	jobs := []Job{
+		{ID: "1", TRES: "gres/gpu:2"},
+		{ID: "2", TRES: "(null)"},
+		{ID: "3", TRES: "cpu=8,mem=64G,gres/gpu:a100=1"},
+		{ID: "4", TRES: "mps:100"},
+		{ID: "5"},
+	}
+	var ids []string
+	for _, j := range filterJobsByGRES(jobs, "gpu") {
+		ids = append(ids, j.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"1", "3"}) {
+		t.Fatalf("expected only GPU jobs, got %v", ids)
+	}
+	if got := filterJobsByGRES(jobs, "cpu"); len(got) != 0 {
+		t.Fatalf("expected plain TRES entries not to count as GRES, got %+v", got)
+	}
+	if got := filterJobsByGRES(jobs, ""); len(got) != len(jobs) {
+		t.Fatalf("expected no filtering without a type, got %+v", got)
+	}
+}
+
+func TestComputeQueuePositions(t *testing.T) {
+	jobs := []Job{
+		{ID: "10", State: "RUNNING", Partition: "gpu", Priority: 900},
+		{ID: "11", State: "PENDING", Partition: "gpu", Priority: 100},
+		{ID: "12", State: "PENDING", Partition: "gpu", Priority: 300},
+		{ID: "13", State: "COMPLETED", Partition: "cpu"},
+		{ID: "14", State: "PENDING", Partition: "cpu"},
+		{ID: "9", State: "PENDING", Partition: "cpu"},
+		{ID: "15", State: "PENDING", Partition: "gpu,cpu", Priority: 200},
+	}
+	want := map[string]map[string]int{
+		"gpu": {"12": 1, "15": 2, "11": 3},
+		"cpu": {"15": 1, "9": 2, "14": 3},
+	}
+	if got := computeQueuePositions(jobs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("computeQueuePositions = %v, want %v", got, want)
+	}
+
+	applyQueuePositions(jobs)
+	positions := map[string]int{}
+	for _, j := range jobs {
+		positions[j.ID] = j.Position
+	}
+	wantPos := map[string]int{"10": 0, "11": 3, "12": 1, "13": 0, "14": 3, "9": 2, "15": 1}
+	if !reflect.DeepEqual(positions, wantPos) {
+		t.Fatalf("applyQueuePositions = %v, want %v", positions, wantPos)
+	}
+}
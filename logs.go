@@ -1,19 +1,28 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
+	"github.com/charmbracelet/x/ansi"
 	"github.com/mattn/go-runewidth"
 )
 
 const (
 	initialTailBytes = 1024 * 1024
 	renderLineLimit  = 20000
+	tailChunkSize    = 64 * 1024
 )
 
 type streamLabel string
@@ -40,6 +49,13 @@ type tailRenderer struct {
 	activeWindow int
 	pendingUTF8  []byte
 	pendingCSI   []byte
+	pendingOSC   []byte
+	tabWidth     int
+	// pen is the SGR state in effect, applied to every rune written until
+	// the next reset so colorized output keeps its styling.
+	pen string
+	// dropped counts history lines discarded to stay within limit.
+	dropped int
 }
 
 func newTailRenderer(limit int) tailRenderer {
@@ -50,6 +66,7 @@ func newTailRenderer(limit int) tailRenderer {
 		activeWindow: 256,
 		pendingUTF8:  make([]byte, 0, 8),
 		pendingCSI:   make([]byte, 0, 32),
+		tabWidth:     8,
 	}
 	return r
 }
@@ -58,16 +75,29 @@ func (r *tailRenderer) reset() {
 	r.history = r.history[:0]
 	r.active = r.active[:1]
 	r.active[0].runes = r.active[0].runes[:0]
+	r.active[0].styles = nil
 	r.cursorLine = 0
 	r.cursorCol = 0
 	r.pendingUTF8 = r.pendingUTF8[:0]
 	r.pendingCSI = r.pendingCSI[:0]
+	r.pendingOSC = r.pendingOSC[:0]
+	r.pen = ""
+	r.dropped = 0
 }
 
 func (r *tailRenderer) ingest(data []byte) (newLines []string, currentChanged bool) {
 	for _, b := range data {
+		if len(r.pendingOSC) > 0 {
+			r.ingestOSC(b)
+			continue
+		}
 		if len(r.pendingCSI) > 0 {
 			if len(r.pendingCSI) == 1 {
+				if b == ']' || b == 'P' {
+					r.pendingOSC = append(r.pendingOSC[:0], 0x1b, b)
+					r.pendingCSI = r.pendingCSI[:0]
+					continue
+				}
 				if b != '[' {
 					r.pendingCSI = r.pendingCSI[:0]
 					continue
@@ -75,6 +105,11 @@ func (r *tailRenderer) ingest(data []byte) (newLines []string, currentChanged bo
 				r.pendingCSI = append(r.pendingCSI, b)
 				continue
 			}
+			if b < 0x20 || b > 0x7e {
+				// Not a CSI byte; drop the malformed sequence.
+				r.pendingCSI = r.pendingCSI[:0]
+				continue
+			}
 			r.pendingCSI = append(r.pendingCSI, b)
 			if csiDone(b) {
 				if r.applyCSI(string(r.pendingCSI)) {
@@ -86,6 +121,9 @@ func (r *tailRenderer) ingest(data []byte) (newLines []string, currentChanged bo
 		}
 
 		switch b {
+		case 0x00:
+			// SLURM can pad log blocks with NULs; they carry no text.
+			continue
 		case 0x1b:
 			r.flushPendingUTF8(&currentChanged)
 			r.pendingCSI = append(r.pendingCSI[:0], b)
@@ -93,6 +131,10 @@ func (r *tailRenderer) ingest(data []byte) (newLines []string, currentChanged bo
 			r.flushPendingUTF8(&currentChanged)
 			r.cursorCol = 0
 			currentChanged = true
+		case '\t':
+			r.flushPendingUTF8(&currentChanged)
+			r.writeTab()
+			currentChanged = true
 		case '\n':
 			r.flushPendingUTF8(&currentChanged)
 			line := r.active[r.cursorLine].String()
@@ -107,6 +149,23 @@ func (r *tailRenderer) ingest(data []byte) (newLines []string, currentChanged bo
 	return newLines, currentChanged
 }
 
+// ingestOSC swallows OSC and DCS strings (window titles, hyperlinks) up to
+// their BEL or ST terminator. Runaway sequences are abandoned after
+// maxOSCLen bytes so an unterminated one cannot hide the rest of the log.
+func (r *tailRenderer) ingestOSC(b byte) {
+	last := r.pendingOSC[len(r.pendingOSC)-1]
+	switch {
+	case b == 0x07, b == '\\' && last == 0x1b:
+		r.pendingOSC = r.pendingOSC[:0]
+	case len(r.pendingOSC) >= maxOSCLen:
+		r.pendingOSC = r.pendingOSC[:0]
+	default:
+		r.pendingOSC = append(r.pendingOSC, b)
+	}
+}
+
+const maxOSCLen = 4096
+
 func (r *tailRenderer) content() string {
 	return strings.Join(r.logicalLines(), "\n")
 }
@@ -147,13 +206,20 @@ func (r *tailRenderer) flushPendingUTF8(changed *bool) {
 
 func (r *tailRenderer) writeRune(ru rune) {
 	line := &r.active[r.cursorLine]
-	line.WriteAt(r.cursorCol, ru)
+	line.WriteStyledAt(r.cursorCol, ru, r.pen)
 	r.cursorCol += runewidth.RuneWidth(ru)
 	if r.cursorCol < 0 {
 		r.cursorCol = 0
 	}
 }
 
+func (r *tailRenderer) writeTab() {
+	width := max(r.tabWidth, 1)
+	for next := (r.cursorCol/width + 1) * width; r.cursorCol < next; {
+		r.writeRune(' ')
+	}
+}
+
 func (r *tailRenderer) advanceLine() {
 	if r.cursorLine == len(r.active)-1 {
 		r.active = append(r.active, lineBuffer{})
@@ -173,6 +239,17 @@ func (r *tailRenderer) moveCursorUp(n int) {
 	}
 }
 
+func (r *tailRenderer) moveCursorTo(row, col int) {
+	if row < 0 {
+		row = 0
+	}
+	for row >= len(r.active) {
+		r.active = append(r.active, lineBuffer{})
+	}
+	r.cursorLine = row
+	r.cursorCol = max(col, 0)
+}
+
 func (r *tailRenderer) applyCSI(seq string) bool {
 	if len(seq) < 3 || seq[0] != 0x1b || seq[1] != '[' {
 		return false
@@ -181,21 +258,102 @@ func (r *tailRenderer) applyCSI(seq string) bool {
 	params := seq[2 : len(seq)-1]
 	switch cmd {
 	case 'A':
-		n := 1
-		if params != "" {
-			parts := strings.Split(params, ";")
-			if v, err := strconv.Atoi(parts[0]); err == nil && v > 0 {
-				n = v
-			}
+		r.moveCursorUp(csiCount(params))
+		return true
+	case 'C':
+		r.cursorCol = min(r.cursorCol+csiCount(params), r.active[r.cursorLine].visualWidth())
+		return true
+	case 'D':
+		r.cursorCol = max(r.cursorCol-csiCount(params), 0)
+		return true
+	case 'H', 'f':
+		row, col := csiPosition(params)
+		r.moveCursorTo(row-1, col-1)
+		return true
+	case '@':
+		r.active[r.cursorLine].InsertAt(r.cursorCol, csiCount(params))
+		return true
+	case 'P':
+		r.active[r.cursorLine].DeleteAt(r.cursorCol, csiCount(params))
+		return true
+	case 'J':
+		r.eraseDisplay(params)
+		return true
+	case 'K':
+		line := &r.active[r.cursorLine]
+		switch params {
+		case "1":
+			line.EraseTo(r.cursorCol)
+		case "2":
+			line.Clear()
+		default:
+			line.EraseFrom(r.cursorCol)
 		}
-		r.moveCursorUp(n)
 		return true
+	case 'm':
+		r.applySGR(seq, params)
+		return false
 	default:
 		return false
 	}
 }
 
+func (r *tailRenderer) eraseDisplay(params string) {
+	switch params {
+	case "2", "3":
+		pen := r.pen
+		r.reset()
+		r.pen = pen
+	case "1":
+		for i := 0; i < r.cursorLine; i++ {
+			r.active[i].Clear()
+		}
+		r.active[r.cursorLine].EraseTo(r.cursorCol)
+	default:
+		r.active[r.cursorLine].EraseFrom(r.cursorCol)
+		r.active = r.active[:r.cursorLine+1]
+	}
+}
+
+func (r *tailRenderer) applySGR(seq, params string) {
+	first, _, _ := strings.Cut(params, ";")
+	switch {
+	case first == "" || first == "0":
+		if params == "" || params == "0" {
+			r.pen = ""
+		} else {
+			r.pen = seq
+		}
+	case len(r.pen)+len(seq) > maxPenLen:
+		r.pen = seq
+	default:
+		r.pen += seq
+	}
+}
+
+const maxPenLen = 64
+
+func csiCount(params string) int {
+	first, _, _ := strings.Cut(params, ";")
+	if v, err := strconv.Atoi(first); err == nil && v > 0 {
+		return v
+	}
+	return 1
+}
+
+func csiPosition(params string) (row, col int) {
+	rowParam, colParam, _ := strings.Cut(params, ";")
+	return csiCount(rowParam), csiCount(colParam)
+}
+
 func (r *tailRenderer) logicalLines() []string {
+	lines, _ := r.limitedLines()
+	return lines
+}
+
+// limitedLines returns the retained lines and how many earlier lines were
+// dropped to stay within limit.
+func (r *tailRenderer) limitedLines() ([]string, int) {
 	out := make([]string, 0, len(r.history)+len(r.active))
 	out = append(out, r.history...)
 	activeLen := len(r.active)
@@ -205,10 +363,12 @@ func (r *tailRenderer) logicalLines() []string {
 	for i := 0; i < activeLen; i++ {
 		out = append(out, r.active[i].String())
 	}
+	dropped := r.dropped
 	if r.limit > 0 && len(out) > r.limit {
+		dropped += len(out) - r.limit
 		out = out[len(out)-r.limit:]
 	}
-	return out
+	return out, dropped
 }
 
 func (r *tailRenderer) compactActive() {
@@ -237,6 +397,7 @@ func (r *tailRenderer) compactActive() {
 			maxHistory = 0
 		}
 		if len(r.history) > maxHistory {
+			r.dropped += len(r.history) - maxHistory
 			r.history = r.history[len(r.history)-maxHistory:]
 		}
 	}
@@ -248,22 +409,94 @@ func csiDone(b byte) bool {
 
 type lineBuffer struct {
 	runes []rune
+	// styles holds the SGR prefix for each rune; nil until a styled rune
+	// is written so plain logs pay nothing.
+	styles []string
 }
 
 func (l *lineBuffer) WriteAt(col int, ru rune) {
+	l.WriteStyledAt(col, ru, "")
+}
+
+func (l *lineBuffer) WriteStyledAt(col int, ru rune, style string) {
 	if col < 0 {
 		col = 0
 	}
+	if style != "" && l.styles == nil {
+		l.styles = make([]string, len(l.runes), cap(l.runes))
+	}
 	pos := l.runeIndexForColumn(col)
 
 	if pos >= len(l.runes) {
 		for w := l.visualWidth(); w < col; w++ {
 			l.runes = append(l.runes, ' ')
+			if l.styles != nil {
+				l.styles = append(l.styles, "")
+			}
 		}
 		l.runes = append(l.runes, ru)
+		if l.styles != nil {
+			l.styles = append(l.styles, style)
+		}
 		return
 	}
 	l.runes[pos] = ru
+	if l.styles != nil {
+		l.styles[pos] = style
+	}
+}
+
+func (l *lineBuffer) InsertAt(col, n int) {
+	pos := l.runeIndexForColumn(col)
+	if pos >= len(l.runes) || n <= 0 {
+		return
+	}
+	spaces := make([]rune, n)
+	for i := range spaces {
+		spaces[i] = ' '
+	}
+	l.runes = append(l.runes[:pos], append(spaces, l.runes[pos:]...)...)
+	if l.styles != nil {
+		l.styles = append(l.styles[:pos], append(make([]string, n), l.styles[pos:]...)...)
+	}
+}
+
+func (l *lineBuffer) DeleteAt(col, n int) {
+	pos := l.runeIndexForColumn(col)
+	if pos >= len(l.runes) || n <= 0 {
+		return
+	}
+	end := min(pos+n, len(l.runes))
+	l.runes = append(l.runes[:pos], l.runes[end:]...)
+	if l.styles != nil {
+		l.styles = append(l.styles[:pos], l.styles[end:]...)
+	}
+}
+
+func (l *lineBuffer) EraseFrom(col int) {
+	pos := l.runeIndexForColumn(col)
+	if pos >= len(l.runes) {
+		return
+	}
+	l.runes = l.runes[:pos]
+	if l.styles != nil {
+		l.styles = l.styles[:pos]
+	}
+}
+
+func (l *lineBuffer) EraseTo(col int) {
+	pos := l.runeIndexForColumn(col)
+	for i := 0; i <= pos && i < len(l.runes); i++ {
+		l.runes[i] = ' '
+		if l.styles != nil {
+			l.styles[i] = ""
+		}
+	}
+}
+
+func (l *lineBuffer) Clear() {
+	l.runes = l.runes[:0]
+	l.styles = nil
 }
 
 func (l *lineBuffer) runeIndexForColumn(col int) int {
@@ -300,9 +533,29 @@ func (l *lineBuffer) visualWidth() int {
 }
 
 func (l *lineBuffer) String() string {
-	return string(l.runes)
+	if l.styles == nil {
+		return string(l.runes)
+	}
+	var b strings.Builder
+	current := ""
+	for i, ru := range l.runes {
+		if style := l.styles[i]; style != current {
+			if current != "" {
+				b.WriteString(sgrReset)
+			}
+			b.WriteString(style)
+			current = style
+		}
+		b.WriteRune(ru)
+	}
+	if current != "" {
+		b.WriteString(sgrReset)
+	}
+	return b.String()
 }
 
+const sgrReset = "\x1b[0m"
+
 func wrapRunes(line string, width int) []string {
 	if width <= 0 {
 		return []string{line}
@@ -313,23 +566,37 @@ func wrapRunes(line string, width int) []string {
 	var out []string
 	var b strings.Builder
 	w := 0
-	for _, ru := range line {
+	active := ""
+	for i := 0; i < len(line); {
+		if seq := sgrAt(line, i); seq != "" {
+			b.WriteString(seq)
+			if seq == sgrReset {
+				active = ""
+			} else {
+				active += seq
+			}
+			i += len(seq)
+			continue
+		}
+		ru, size := utf8.DecodeRuneInString(line[i:])
+		i += size
 		rw := runewidth.RuneWidth(ru)
 		if rw < 1 {
 			rw = 1
 		}
-		if w+rw > width && b.Len() > 0 {
+		if w+rw > width && w > 0 {
+			// Close the style at the wrap and reopen it on the next row so
+			// colors never bleed into the pane border.
+			if active != "" {
+				b.WriteString(sgrReset)
+			}
 			out = append(out, b.String())
 			b.Reset()
+			b.WriteString(active)
 			w = 0
 		}
 		b.WriteRune(ru)
 		w += rw
-		if w >= width {
-			out = append(out, b.String())
-			b.Reset()
-			w = 0
-		}
 	}
 	if b.Len() > 0 || len(out) == 0 {
 		out = append(out, b.String())
@@ -337,30 +604,149 @@ func wrapRunes(line string, width int) []string {
 	return out
 }
 
+func sgrAt(s string, i int) string {
+	if i+1 >= len(s) || s[i] != 0x1b || s[i+1] != '[' {
+		return ""
+	}
+	for j := i + 2; j < len(s); j++ {
+		if csiDone(s[j]) {
+			return s[i : j+1]
+		}
+	}
+	return ""
+}
+
+var logLevelPattern = regexp.MustCompile(`(?i)^\s*(?:\d[\d.,T+]*[/:-][\d/:.,T+-]*\s+){0,2}[\[(<]?(ERROR|FATAL|CRITICAL|WARNING|WARN|INFO|DEBUG)(?:[\])>:]|\s|$)`)
+
+var logLevelColors = map[string]string{
+	"ERROR":    "\x1b[38;5;196m",
+	"FATAL":    "\x1b[38;5;196m",
+	"CRITICAL": "\x1b[38;5;196m",
+	"WARNING":  "\x1b[38;5;220m",
+	"WARN":     "\x1b[38;5;220m",
+	"INFO":     "\x1b[38;5;51m",
+	"DEBUG":    "\x1b[2m",
+}
+
+// colorizeLogLine colors a line by the log level at its start, optionally
+// after a timestamp. Only the color is prepended so SGR sequences already
+// in the line keep working.
+func colorizeLogLine(line string) string {
+	match := logLevelPattern.FindStringSubmatch(ansi.Strip(line))
+	if match == nil {
+		return line
+	}
+	return logLevelColors[strings.ToUpper(match[1])] + line + sgrReset
+}
+
+func colorizedContent(content string, enabled bool) string {
+	if !enabled || content == "" {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = colorizeLogLine(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func wrapContent(content string, width int) string {
+	if width <= 0 {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	wrapped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		wrapped = append(wrapped, wrapRunes(line, width)...)
+	}
+	return strings.Join(wrapped, "\n")
+}
+
+// expandLogPattern fills in %j (job ID), %n (job name), %u (user) and %N
+// (first node) in a log path template; %% is a literal percent sign and
+// unknown placeholders are kept verbatim.
+func expandLogPattern(pattern string, job Job, user string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' || i+1 == len(pattern) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'j':
+			b.WriteString(job.ID)
+		case 'n':
+			b.WriteString(job.Name)
+		case 'u':
+			b.WriteString(user)
+		case 'N':
+			b.WriteString(firstNode(job.Nodes))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(pattern[i])
+		}
+	}
+	return b.String()
+}
+
 type logFollower struct {
 	path        string
 	offset      int64
 	initialized bool
 	renderer    tailRenderer
 	missing     bool
+	info        os.FileInfo
+	tailBytes   int64
+	tailLines   int
+
+	// compressed logs are re-decompressed whenever compressedSize changes;
+	// offset then counts decompressed bytes.
+	compressed     bool
+	compressedSize int64
+
+	// cutOffset is where rendering started when the initial tail skipped
+	// the head of the file; skippedLines caches the line count before it
+	// (-1 until counted).
+	cutOffset    int64
+	skippedLines int
+
+	checkpointID string
+	reader       LogReader
 }
 
 func newLogFollower(path string) *logFollower {
 	return &logFollower{
-		path:     path,
-		renderer: newTailRenderer(renderLineLimit),
+		path:      path,
+		renderer:  newTailRenderer(renderLineLimit),
+		tailBytes: initialTailBytes,
 	}
 }
 
+func newLogFollowerWithReader(path string, reader LogReader) *logFollower {
+	f := newLogFollower(path)
+	f.reader = reader
+	return f
+}
+
 func (f *logFollower) reset(path string) {
 	f.path = path
 	f.offset = 0
 	f.initialized = false
+	f.cutOffset, f.skippedLines = 0, 0
 	f.renderer.reset()
 	f.missing = false
+	f.info = nil
+	f.compressed, f.compressedSize = false, 0
 }
 
 func (f *logFollower) poll(label streamLabel) (streamChunk, error) {
+	if f.reader != nil {
+		return f.pollReader(label)
+	}
 	chunk := streamChunk{Label: label}
 
 	st, err := os.Stat(f.path)
@@ -373,10 +759,15 @@ func (f *logFollower) poll(label streamLabel) (streamChunk, error) {
 		return chunk, err
 	}
 
-	if st.Size() < f.offset {
+	rotated := f.info != nil && !os.SameFile(f.info, st)
+	fresh := f.info == nil || rotated
+	f.info = st
+	if rotated || (!f.compressed && st.Size() < f.offset) {
 		f.offset = 0
 		f.initialized = false
+		f.cutOffset, f.skippedLines = 0, 0
 		f.renderer.reset()
+		f.compressedSize = 0
 	}
 
 	file, err := os.Open(f.path)
@@ -385,10 +776,25 @@ func (f *logFollower) poll(label streamLabel) (streamChunk, error) {
 	}
 	defer file.Close()
 
+	if fresh {
+		if f.compressed, err = isGzipFile(file, f.path); err != nil {
+			return chunk, err
+		}
+	}
+	if f.compressed {
+		return f.pollCompressed(file, st.Size(), chunk)
+	}
+
 	if !f.initialized {
 		start := int64(0)
-		if st.Size() > initialTailBytes {
-			start = st.Size() - initialTailBytes
+		lineAligned := false
+		if f.tailLines > 0 {
+			if start, err = seekToLastNLines(file, f.tailLines, tailChunkSize); err != nil {
+				return chunk, err
+			}
+			lineAligned = true
+		} else if st.Size() > f.tailBytes {
+			start = st.Size() - f.tailBytes
 		}
 		if _, err := file.Seek(start, io.SeekStart); err != nil {
 			return chunk, err
@@ -397,9 +803,12 @@ func (f *logFollower) poll(label streamLabel) (streamChunk, error) {
 		if err != nil {
 			return chunk, err
 		}
-		if start > 0 {
+		if start > 0 && lineAligned {
+			f.cutOffset, f.skippedLines = start, -1
+		} else if start > 0 {
 			if idx := strings.IndexByte(string(buf), '\n'); idx >= 0 && idx+1 < len(buf) {
 				buf = buf[idx+1:]
+				f.cutOffset, f.skippedLines = start+int64(idx)+1, -1
 			}
 		}
 		newLines, changed := f.renderer.ingest(buf)
@@ -435,29 +844,439 @@ func (f *logFollower) poll(label streamLabel) (streamChunk, error) {
 	return chunk, nil
 }
 
+func isGzipFile(file *os.File, path string) (bool, error) {
+	if strings.HasSuffix(path, ".gz") {
+		return true, nil
+	}
+	var magic [2]byte
+	n, err := file.ReadAt(magic[:], 0)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	return n == len(magic) && magic == [2]byte{0x1f, 0x8b}, nil
+}
+
+// pollCompressed decompresses the whole file, since gzip streams can't be
+// read from an offset, and only when its on-disk size has changed.
+func (f *logFollower) pollCompressed(file *os.File, size int64, chunk streamChunk) (streamChunk, error) {
+	if f.initialized && size == f.compressedSize {
+		chunk.CurrentLine = f.renderer.currentLine()
+		return chunk, nil
+	}
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return chunk, err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	// A member still being written ends early; use what decompressed so far.
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return chunk, err
+	}
+	f.compressedSize = size
+
+	if int64(len(data)) < f.offset {
+		f.offset = 0
+		f.initialized = false
+		f.cutOffset, f.skippedLines = 0, 0
+		f.renderer.reset()
+	}
+	buf := data[f.offset:]
+	if !f.initialized {
+		if start := f.tailStart(data); start > 0 {
+			buf = data[start:]
+			f.cutOffset, f.skippedLines = int64(start), bytes.Count(data[:start], []byte{'\n'})
+		}
+	}
+	newLines, changed := f.renderer.ingest(buf)
+	f.offset = int64(len(data))
+	f.initialized = true
+	f.missing = false
+
+	chunk.NewLines = newLines
+	chunk.CurrentChanged = changed
+	chunk.CurrentLine = f.renderer.currentLine()
+	return chunk, nil
+}
+
+// tailStart is the in-memory counterpart of the initial tail in poll.
+func (f *logFollower) tailStart(data []byte) int {
+	if f.tailLines > 0 {
+		end := len(data)
+		if end > 0 && data[end-1] == '\n' {
+			end--
+		}
+		for n := 1; ; n++ {
+			idx := bytes.LastIndexByte(data[:end], '\n')
+			if idx < 0 {
+				return 0
+			}
+			if n == f.tailLines {
+				return idx + 1
+			}
+			end = idx
+		}
+	}
+	if int64(len(data)) <= f.tailBytes {
+		return 0
+	}
+	start := len(data) - int(f.tailBytes)
+	if idx := bytes.IndexByte(data[start:], '\n'); idx >= 0 && start+idx+1 < len(data) {
+		return start + idx + 1
+	}
+	return start
+}
+
+// seekToLastNLines returns the offset where the last n lines of f start,
+// scanning backwards chunkSize bytes at a time. A trailing newline ends the
+// last line rather than starting an empty one.
+func seekToLastNLines(f *os.File, n int, chunkSize int) (int64, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := st.Size()
+	if n <= 0 {
+		return size, nil
+	}
+	buf := make([]byte, chunkSize)
+	newlines := 0
+	for end := size; end > 0; {
+		start := end - int64(chunkSize)
+		if start < 0 {
+			start = 0
+		}
+		chunk := buf[:end-start]
+		if _, err := f.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			pos := start + int64(i)
+			if chunk[i] != '\n' || pos == size-1 {
+				continue
+			}
+			newlines++
+			if newlines == n {
+				return pos + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+type logCheckpoint struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+}
+
+func checkpointPath(dir, id string) string {
+	return filepath.Join(dir, id+".checkpoint.json")
+}
+
+func (f *logFollower) SaveCheckpoint(dir string) error {
+	if f.checkpointID == "" || !f.initialized {
+		return nil
+	}
+	data, err := json.Marshal(logCheckpoint{Path: f.path, Offset: f.offset})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(checkpointPath(dir, f.checkpointID), data, 0o644)
+}
+
+func loadCheckpoint(dir, id string) (logCheckpoint, error) {
+	var cp logCheckpoint
+	data, err := os.ReadFile(checkpointPath(dir, id))
+	if err != nil {
+		return cp, err
+	}
+	err = json.Unmarshal(data, &cp)
+	return cp, err
+}
+
+// resumeFrom continues after the checkpointed offset and consumes the
+// checkpoint, so it only applies to the session right after it was saved.
+func (f *logFollower) resumeFrom(dir string) bool {
+	cp, err := loadCheckpoint(dir, f.checkpointID)
+	f.discardCheckpoint(dir)
+	if err != nil || cp.Path != f.path || cp.Offset <= 0 {
+		return false
+	}
+	f.offset = cp.Offset
+	f.initialized = true
+	f.cutOffset, f.skippedLines = cp.Offset, -1
+	return true
+}
+
+func (f *logFollower) discardCheckpoint(dir string) {
+	_ = os.Remove(checkpointPath(dir, f.checkpointID))
+}
+
+func defaultCheckpointDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "slurm-tui", "checkpoints")
+}
+
+func (f *logFollower) pollReader(label streamLabel) (streamChunk, error) {
+	return f.applyRemote(label, f.remoteRead()())
+}
+
+// remoteRead is the result of reading a follower's file through its
+// LogReader, tagged with the follower state the read was planned from.
+type remoteRead struct {
+	path        string
+	offset      int64 // follower offset when the read was planned
+	initialized bool
+	start       int64
+	size        int64
+	data        []byte
+	missing     bool
+	unchanged   bool
+	err         error
+}
+
+// remoteRead plans the next read from the follower's current state and
+// returns the I/O as a function that touches no follower state, so it can
+// run on a tea.Cmd goroutine.
+func (f *logFollower) remoteRead() func() remoteRead {
+	reader, tailBytes := f.reader, f.tailBytes
+	r := remoteRead{path: f.path, offset: f.offset, initialized: f.initialized}
+	return func() remoteRead {
+		size, err := reader.Size(r.path)
+		if err != nil {
+			r.missing = errors.Is(err, os.ErrNotExist)
+			if !r.missing {
+				r.err = err
+			}
+			return r
+		}
+		r.size = size
+		initialized := r.initialized && size >= r.offset
+		if initialized && size == r.offset {
+			r.unchanged = true
+			return r
+		}
+		r.start = r.offset
+		if !initialized {
+			r.start = 0
+			if size > tailBytes {
+				r.start = size - tailBytes
+			}
+		}
+		r.data, r.err = reader.Read(r.path, r.start)
+		return r
+	}
+}
+
+// applyRemote feeds a finished remoteRead into the follower. Reads planned
+// before the follower moved on (reset, switched path or newer data) are
+// dropped.
+func (f *logFollower) applyRemote(label streamLabel, r remoteRead) (streamChunk, error) {
+	chunk := streamChunk{Label: label}
+	if r.path != f.path || r.offset != f.offset || r.initialized != f.initialized {
+		chunk.CurrentLine = f.renderer.currentLine()
+		chunk.Missing = f.missing
+		return chunk, nil
+	}
+	if r.err != nil {
+		return chunk, r.err
+	}
+	if r.missing {
+		f.missing = true
+		chunk.Missing = true
+		return chunk, nil
+	}
+	f.missing = false
+	if r.unchanged {
+		chunk.CurrentLine = f.renderer.currentLine()
+		return chunk, nil
+	}
+	if r.size < f.offset {
+		f.offset = 0
+		f.initialized = false
+		f.cutOffset, f.skippedLines = 0, 0
+		f.renderer.reset()
+	}
+
+	buf := r.data
+	f.offset = r.start + int64(len(buf))
+	if !f.initialized && r.start > 0 {
+		if idx := bytes.IndexByte(buf, '\n'); idx >= 0 && idx+1 < len(buf) {
+			buf = buf[idx+1:]
+			f.cutOffset, f.skippedLines = r.start+int64(idx)+1, -1
+		}
+	}
+	newLines, changed := f.renderer.ingest(buf)
+	chunk.NewLines = newLines
+	chunk.CurrentChanged = changed
+	chunk.CurrentLine = f.renderer.currentLine()
+	f.initialized = true
+	return chunk, nil
+}
+
+func (f *logFollower) size() int64 {
+	if f.reader == nil {
+		return statSize(f.path)
+	}
+	if f.missing {
+		return -1
+	}
+	return f.offset
+}
+
 func (f *logFollower) content(width int) string {
 	return f.renderer.contentWrapped(width)
 }
 
+// searchContent returns the indices of the lines in content that contain
+// query, ignoring case and SGR styling.
+func searchContent(content, query string) []int {
+	if query == "" {
+		return nil
+	}
+	query = strings.ToLower(query)
+	var matches []int
+	for i, line := range strings.Split(content, "\n") {
+		if strings.Contains(strings.ToLower(ansi.Strip(line)), query) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+const lineNumberWidth = 9 // "%6d │ "
+
+func lineNumberPrefix(n int) string {
+	return fmt.Sprintf("%6d │ ", n)
+}
+
+// addLineNumbers numbers each line of content from startLine.
+func addLineNumbers(content string, startLine int) string {
+	if content == "" {
+		return ""
+	}
+	prefixes := make([]string, strings.Count(content, "\n")+1)
+	for i := range prefixes {
+		prefixes[i] = lineNumberPrefix(startLine + i)
+	}
+	return prefixLines(content, prefixes)
+}
+
+// prefixLines prepends prefixes[i] to line i of content.
+func prefixLines(content string, prefixes []string) string {
+	lines := strings.Split(content, "\n")
+	for i := range lines {
+		if i < len(prefixes) {
+			lines[i] = prefixes[i] + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// firstLineNumber is the file line number of the first retained line. Lines
+// before a tail cut are only counted for local files.
+func (f *logFollower) firstLineNumber() int {
+	_, dropped := f.renderer.limitedLines()
+	if f.skippedLines < 0 {
+		f.skippedLines = 0
+		if f.reader == nil {
+			if n, err := countLines(f.path, f.cutOffset); err == nil {
+				f.skippedLines = n
+			}
+		}
+	}
+	return 1 + f.skippedLines + dropped
+}
+
+func countLines(path string, limit int64) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	n := 0
+	buf := make([]byte, 64*1024)
+	r := io.LimitReader(file, limit)
+	for {
+		k, err := r.Read(buf)
+		n += bytes.Count(buf[:k], []byte{'\n'})
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// lineNumberPrefixes returns the gutter for each line of content(width):
+// the number on the first wrapped segment and a blank gutter after it.
+func (f *logFollower) lineNumberPrefixes(width int) []string {
+	lines := f.renderer.logicalLines()
+	start := f.firstLineNumber()
+	blank := strings.Repeat(" ", lineNumberWidth-2) + "│ "
+	prefixes := make([]string, 0, len(lines))
+	for i, line := range lines {
+		prefixes = append(prefixes, lineNumberPrefix(start+i))
+		for range len(wrapRunes(line, width)) - 1 {
+			prefixes = append(prefixes, blank)
+		}
+	}
+	return prefixes
+}
+
+func (f *logFollower) wrappedOffset(lineIdx, width int) int {
+	lines := f.renderer.logicalLines()
+	if lineIdx > len(lines) {
+		lineIdx = len(lines)
+	}
+	if width <= 0 {
+		return lineIdx
+	}
+	offset := 0
+	for _, line := range lines[:lineIdx] {
+		offset += len(wrapRunes(line, width))
+	}
+	return offset
+}
+
+const mergedTimestampLayout = "15:04:05.000"
+
+type mergedLine struct {
+	Label streamLabel
+	Text  string
+	At    time.Time
+}
+
 type mergedBuffer struct {
-	lines      []string
+	lines      []mergedLine
 	limit      int
-	outCurrent string
-	errCurrent string
+	outCurrent mergedLine
+	errCurrent mergedLine
+
+	showTimestamps bool
+	colorize       bool
 }
 
 func newMergedBuffer(limit int) mergedBuffer {
-	return mergedBuffer{lines: make([]string, 0, 256), limit: limit}
+	return mergedBuffer{lines: make([]mergedLine, 0, 256), limit: limit}
 }
 
 func (m *mergedBuffer) reset() {
 	m.lines = m.lines[:0]
-	m.outCurrent = ""
-	m.errCurrent = ""
+	m.outCurrent = mergedLine{}
+	m.errCurrent = mergedLine{}
 }
 
 func (m *mergedBuffer) addLine(label streamLabel, line string) {
-	m.lines = append(m.lines, fmt.Sprintf("[%s] %s", label, line))
+	m.lines = append(m.lines, mergedLine{Label: label, Text: line, At: time.Now()})
 	if len(m.lines) > m.limit {
 		drop := len(m.lines) - m.limit
 		m.lines = m.lines[drop:]
@@ -469,22 +1288,86 @@ func (m *mergedBuffer) applyChunk(chunk streamChunk) {
 		m.addLine(chunk.Label, line)
 	}
 	if chunk.CurrentChanged {
+		current := mergedLine{Label: chunk.Label, Text: chunk.CurrentLine, At: time.Now()}
 		switch chunk.Label {
 		case streamOut:
-			m.outCurrent = chunk.CurrentLine
+			m.outCurrent = current
 		case streamErr:
-			m.errCurrent = chunk.CurrentLine
+			m.errCurrent = current
 		}
 	}
 }
 
 func (m *mergedBuffer) content() string {
-	out := append([]string{}, m.lines...)
-	if m.outCurrent != "" {
-		out = append(out, fmt.Sprintf("[%s] %s", streamOut, m.outCurrent))
+	return m.contentWrapped(0)
+}
+
+// contentWrapped wraps each line's text to width minus its label, indenting
+// continuation rows so they line up under the text. Lines carry their
+// arrival time while showTimestamps is set.
+func (m *mergedBuffer) contentWrapped(width int) string {
+	return joinRows(m.wrappedRows(width))
+}
+
+// lineNumberPrefixes numbers the merged lines from 1, leaving continuation
+// rows of wrapped lines blank.
+func (m *mergedBuffer) lineNumberPrefixes(width int) []string {
+	blank := strings.Repeat(" ", lineNumberWidth-2) + "│ "
+	var prefixes []string
+	for i, rows := range m.wrappedRows(width) {
+		prefixes = append(prefixes, lineNumberPrefix(i+1))
+		for range len(rows) - 1 {
+			prefixes = append(prefixes, blank)
+		}
 	}
-	if m.errCurrent != "" {
-		out = append(out, fmt.Sprintf("[%s] %s", streamErr, m.errCurrent))
+	return prefixes
+}
+
+func (m *mergedBuffer) timestampLayout() string {
+	if m.showTimestamps {
+		return mergedTimestampLayout
+	}
+	return ""
+}
+
+func (m *mergedBuffer) wrappedRows(width int) [][]string {
+	layout := m.timestampLayout()
+	lines := append([]mergedLine{}, m.lines...)
+	for _, current := range []mergedLine{m.outCurrent, m.errCurrent} {
+		if current.Text != "" {
+			lines = append(lines, current)
+		}
+	}
+	rows := make([][]string, 0, len(lines))
+	for _, l := range lines {
+		prefix := fmt.Sprintf("[%s] ", l.Label)
+		if layout != "" {
+			prefix = l.At.Format(layout) + " " + prefix
+		}
+		text := l.Text
+		if m.colorize {
+			text = colorizeLogLine(text)
+		}
+		avail := width - runewidth.StringWidth(prefix)
+		if width <= 0 || avail < 1 {
+			rows = append(rows, wrapRunes(prefix+text, width))
+			continue
+		}
+		segments := wrapRunes(text, avail)
+		indent := strings.Repeat(" ", width-avail)
+		segments[0] = prefix + segments[0]
+		for i := 1; i < len(segments); i++ {
+			segments[i] = indent + segments[i]
+		}
+		rows = append(rows, segments)
+	}
+	return rows
+}
+
+func joinRows(rows [][]string) string {
+	var out []string
+	for _, r := range rows {
+		out = append(out, r...)
 	}
 	return strings.Join(out, "\n")
 }
@@ -0,0 +1,54 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLines(t *testing.T, path, prefix string, n int) {
+	t.Helper()
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "%s %d\n", prefix, i)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestLogFollowerRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "job.out")
+	writeLines(t, path, "old", 10)
+
+	f := newLogFollower(path)
+	chunk, err := f.poll(streamOut)
+	if err != nil {
+		t.Fatalf("first poll: %v", err)
+	}
+	if len(chunk.NewLines) != 10 {
+		t.Fatalf("expected 10 lines, got %d", len(chunk.NewLines))
+	}
+
+	if err := os.Rename(path, filepath.Join(dir, "job.out.1")); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	// Larger than the old offset would still be read incrementally without rotation detection.
+	writeLines(t, path, "new-and-longer", 5)
+
+	chunk, err = f.poll(streamOut)
+	if err != nil {
+		t.Fatalf("second poll: %v", err)
+	}
+	if len(chunk.NewLines) != 5 || chunk.NewLines[0] != "new-and-longer 1" {
+		t.Fatalf("expected 5 new lines after rotation, got %q", chunk.NewLines)
+	}
+	if got := f.content(0); strings.Contains(got, "old") {
+		t.Fatalf("expected rotated content to be reset, got %q", got)
+	}
+}
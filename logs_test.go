@@ -1,6 +1,17 @@
 package main
 
-import "testing"
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
 
 func TestTailRendererCarriageReturnProgress(t *testing.T) {
 	r := newTailRenderer(100)
@@ -64,3 +75,768 @@ func TestTailRendererSoftWrap(t *testing.T) {
 		t.Fatalf("unexpected wrapped content: %q", got)
 	}
 }
+
+func TestTailRendererNULBytes(t *testing.T) {
+	r := newTailRenderer(100)
+	r.ingest([]byte("before\x00after\nnewline"))
+
+	got := r.content()
+	if !utf8.ValidString(got) {
+		t.Fatalf("invalid utf8 content: %q", got)
+	}
+	if got != "beforeafter\nnewline" {
+		t.Fatalf("unexpected content: %q", got)
+	}
+}
+
+func TestWrapRunesCJK(t *testing.T) {
+	cases := []struct {
+		line  string
+		width int
+		want  []string
+	}{
+		{"你好世界", 4, []string{"你好", "世界"}},
+		{"AB你好", 4, []string{"AB你", "好"}},
+		{"A你B好", 3, []string{"A你", "B好"}},
+		{"你", 1, []string{"你"}},
+		{"", 5, []string{""}},
+	}
+	for _, tc := range cases {
+		if got := wrapRunes(tc.line, tc.width); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("wrapRunes(%q, %d) = %q, want %q", tc.line, tc.width, got, tc.want)
+		}
+	}
+}
+
+func FuzzTailRendererIngest(f *testing.F) {
+	f.Add([]byte("\x1b[1;32mgreen\x1b[0m plain\n"), uint(3))
+	f.Add([]byte("line 1\nline 2\n\x1b[2Aup\x1b[Aagain"), uint(15))
+	f.Add([]byte("before\x00after\n\x00"), uint(6))
+	f.Add([]byte("█▌")[:4], uint(1))
+	f.Add([]byte("\x1b["+string(make([]byte, 64))+"m"), uint(2))
+	f.Add([]byte("\x1b\x1b[\x1b"), uint(1))
+	f.Add([]byte("progress 10%\rprogress 20%\r\n"), uint(13))
+	f.Add([]byte("\x1b[\xff0m00"), uint(1))
+
+	f.Fuzz(func(t *testing.T, data []byte, split uint) {
+		i := int(split % uint(len(data)+1))
+		r := newTailRenderer(100)
+		r.ingest(data[:i])
+		r.ingest(data[i:])
+		_ = r.contentWrapped(80)
+		got := r.content()
+		if !utf8.ValidString(got) {
+			t.Fatalf("invalid utf8 content %q for input %q split at %d", got, data, i)
+		}
+		whole := newTailRenderer(100)
+		whole.ingest(data)
+		if want := whole.content(); got != want {
+			t.Fatalf("split at %d rendered %q, want %q for input %q", i, got, want, data)
+		}
+	})
+}
+
+func syntheticLog(lines, width int) []byte {
+	var b strings.Builder
+	body := strings.Repeat("x", width)
+	for i := 0; i < lines; i++ {
+		switch {
+		case i%100 == 0:
+			b.WriteString("\x1b[1;32m" + body[:width-11] + "\x1b[0m")
+		case i%20 == 0:
+			b.WriteString("\x1b[A" + body[:width-3])
+		case i%10 == 0:
+			b.WriteString(body[:width/2] + "\r" + body[:width/2-1])
+		default:
+			b.WriteString(body[:width-1])
+		}
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+func BenchmarkTailRendererIngest(b *testing.B) {
+	data := syntheticLog(10000, 100)
+	r := newTailRenderer(renderLineLimit)
+
+	b.Run("ingest", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			r.reset()
+			r.ingest(data)
+		}
+	})
+
+	b.Run("contentWrapped", func(b *testing.B) {
+		r.reset()
+		r.ingest(data)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = r.contentWrapped(120)
+		}
+	})
+}
+
+func TestLogFollowerCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "job.out")
+	if err := os.WriteFile(logPath, []byte("seen 1\nseen 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newLogFollower(logPath)
+	f.checkpointID = "42"
+	if _, err := f.poll(streamOut); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.SaveCheckpoint(dir); err != nil {
+		t.Fatal(err)
+	}
+	if cp, err := loadCheckpoint(dir, "42"); err != nil || cp.Offset != 14 || cp.Path != logPath {
+		t.Fatalf("loadCheckpoint = %+v, %v; want offset 14", cp, err)
+	}
+
+	file, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("new 3\n")
+	file.Close()
+
+	resumed := newLogFollower(logPath)
+	resumed.checkpointID = "42"
+	if !resumed.resumeFrom(dir) {
+		t.Fatalf("expected resume from checkpoint")
+	}
+	chunk, err := resumed.poll(streamOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(chunk.NewLines, []string{"new 3"}) {
+		t.Fatalf("expected only unseen lines after resume, got %q", chunk.NewLines)
+	}
+	if n := resumed.firstLineNumber(); n != 3 {
+		t.Fatalf("expected line numbers to continue at 3, got %d", n)
+	}
+	if _, err := os.Stat(checkpointPath(dir, "42")); !os.IsNotExist(err) {
+		t.Fatalf("expected the checkpoint to be consumed, got %v", err)
+	}
+	again := newLogFollower(logPath)
+	again.checkpointID = "42"
+	if again.resumeFrom(dir) {
+		t.Fatalf("expected a consumed checkpoint not to resume twice")
+	}
+
+	f.SaveCheckpoint(dir)
+	other := newLogFollower(filepath.Join(dir, "other.out"))
+	other.checkpointID = "42"
+	if other.resumeFrom(dir) {
+		t.Fatalf("expected a checkpoint for a different path to be ignored")
+	}
+}
+
+func TestTailRendererICHDCH(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"delete one", "abcdef\rab\x1b[P", "abdef"},
+		{"delete many", "abcdef\ra\x1b[3P", "aef"},
+		{"delete past end", "abc\ra\x1b[10P", "a"},
+		{"insert one", "abcdef\rab\x1b[@", "ab cdef"},
+		{"insert then overwrite", "step 9/10\rstep \x1b[@10", "step 10/10"},
+		{"insert at end is a no-op", "abc\x1b[3@", "abc"},
+		{"wide runes stay intact", "進捗abc\r進\x1b[P", "進abc"},
+	}
+	for _, tc := range cases {
+		r := newTailRenderer(100)
+		r.ingest([]byte(tc.input))
+		if got := r.content(); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+type fakeLogReader struct {
+	files map[string]string
+	reads []int64
+}
+
+func (r *fakeLogReader) Size(path string) (int64, error) {
+	data, ok := r.files[path]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+	return int64(len(data)), nil
+}
+
+func (r *fakeLogReader) Read(path string, offset int64) ([]byte, error) {
+	r.reads = append(r.reads, offset)
+	return []byte(r.files[path][offset:]), nil
+}
+
+func TestLogFollowerWithReader(t *testing.T) {
+	reader := &fakeLogReader{files: map[string]string{}}
+	f := newLogFollowerWithReader("/remote/job.out", reader)
+
+	chunk, err := f.poll(streamOut)
+	if err != nil || !chunk.Missing {
+		t.Fatalf("expected a missing remote file, got %#v, %v", chunk, err)
+	}
+
+	reader.files["/remote/job.out"] = "one\ntwo\n"
+	if chunk, _ = f.poll(streamOut); !reflect.DeepEqual(chunk.NewLines, []string{"one", "two"}) {
+		t.Fatalf("unexpected initial lines %q", chunk.NewLines)
+	}
+	reader.files["/remote/job.out"] += "three\n"
+	if chunk, _ = f.poll(streamOut); !reflect.DeepEqual(chunk.NewLines, []string{"three"}) {
+		t.Fatalf("unexpected appended lines %q", chunk.NewLines)
+	}
+	if len(reader.reads) != 2 || reader.reads[1] != 8 {
+		t.Fatalf("expected incremental reads from the last offset, got %v", reader.reads)
+	}
+	if _, _ = f.poll(streamOut); len(reader.reads) != 2 {
+		t.Fatalf("expected no read when the size is unchanged")
+	}
+
+	reader.files["/remote/job.out"] = "fresh\n"
+	f.poll(streamOut)
+	if got := f.content(0); got != "fresh" {
+		t.Fatalf("expected truncation to restart the follower, got %q", got)
+	}
+	if f.size() != 6 {
+		t.Fatalf("expected size from the remote offset, got %d", f.size())
+	}
+}
+
+func TestTailRendererSGR(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"multi attribute", "\x1b[1;32mPASSED\x1b[0m ok", "\x1b[1;32mPASSED\x1b[0m ok"},
+		{"explicit reset", "\x1b[31mred\x1b[0m plain", "\x1b[31mred\x1b[0m plain"},
+		{"256 color", "\x1b[38;5;208morange", "\x1b[38;5;208morange\x1b[0m"},
+		{"stacked sequences", "\x1b[1m\x1b[33mwarn\x1b[m", "\x1b[1m\x1b[33mwarn\x1b[0m"},
+		{"overwrite keeps new style", "\x1b[31m10%\x1b[0m\r\x1b[32m100%", "\x1b[32m100%\x1b[0m"},
+		{"pen carries across lines", "\x1b[36ma\nb\x1b[0m", "\x1b[36ma\x1b[0m\n\x1b[36mb\x1b[0m"},
+	}
+	for _, tc := range cases {
+		r := newTailRenderer(100)
+		r.ingest([]byte(tc.input))
+		if got := r.content(); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWrapRunesKeepsSGR(t *testing.T) {
+	got := wrapRunes("\x1b[32mabcdef\x1b[0mgh", 4)
+	want := []string{"\x1b[32mabcd\x1b[0m", "\x1b[32mef\x1b[0mgh"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("row %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTailRendererEraseInLine(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"erase to end", "progress 50%\rprogress\x1b[K", "progress"},
+		{"erase to end explicit", "abcdef\rab\x1b[0K", "ab"},
+		{"erase to end then redraw", "loss=0.12345\rloss=0.9\x1b[K", "loss=0.9"},
+		{"erase to start", "abcdef\rabc\x1b[1K", "    ef"},
+		{"erase to start keeps cursor", "abcdef\rab\x1b[1KX", "  Xdef"},
+		{"erase whole line", "abcdef\rabc\x1b[2K", ""},
+		{"erase whole line then redraw", "old status\rold\x1b[2K\rnew", "new"},
+	}
+	for _, tc := range cases {
+		r := newTailRenderer(100)
+		r.ingest([]byte(tc.input))
+		if got := r.content(); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTailRendererCursorPosition(t *testing.T) {
+	r := newTailRenderer(100)
+	for _, frame := range []string{"|", "/", "-", "\\"} {
+		r.ingest([]byte("\x1b[1;1H" + frame + " working\n" + frame + " epoch 3\n" + frame + " step 42"))
+	}
+	if got, want := r.content(), "\\ working\n\\ epoch 3\n\\ step 42"; got != want {
+		t.Fatalf("spinner: got %q, want %q", got, want)
+	}
+
+	r = newTailRenderer(100)
+	r.ingest([]byte("abc\ndef\x1b[2;2fX\x1b[0;5HY"))
+	if got, want := r.content(), "abc Y\ndXf"; got != want {
+		t.Fatalf("positioning: got %q, want %q", got, want)
+	}
+}
+
+func TestTailRendererCursorForwardBack(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"forward to end", "abcdef\r\x1b[6CX", "abcdefX"},
+		{"forward past end clamps", "abc\r\x1b[10CX", "abcX"},
+		{"forward default one", "abc\r\x1b[CX", "aXc"},
+		{"forward into middle", "abcdef\r\x1b[2CX", "abXdef"},
+		{"back to start", "abcdef\x1b[6DX", "Xbcdef"},
+		{"back past start clamps", "abc\x1b[9DX", "Xbc"},
+		{"back default one", "abc\x1b[DX", "abX"},
+		{"wide runes", "進捗abc\r\x1b[2CX", "進Xabc"},
+		{"wide runes back", "進捗abc\x1b[5DX", "進Xabc"},
+	}
+	for _, tc := range cases {
+		r := newTailRenderer(100)
+		r.ingest([]byte(tc.input))
+		if got := r.content(); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTailRendererEraseInDisplay(t *testing.T) {
+	r := newTailRenderer(100)
+	r.ingest([]byte("banner\n"))
+	for i := 1; i <= 3; i++ {
+		r.ingest([]byte(fmt.Sprintf("\x1b[2J\x1b[HEvery 2s: squeue\n\ntick %d\n", i)))
+	}
+	if got, want := r.content(), "Every 2s: squeue\n\ntick 3"; got != want {
+		t.Fatalf("full refresh: got %q, want %q", got, want)
+	}
+
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"erase below", "one\ntwo\nthree\x1b[2;2H\x1b[J", "one\nt"},
+		{"erase below explicit", "one\ntwo\nthree\x1b[1;1H\x1b[0J", ""},
+		{"erase above", "one\ntwo\nthree\x1b[2;2H\x1b[1J", "\n  o\nthree"},
+	}
+	for _, tc := range cases {
+		r := newTailRenderer(100)
+		r.ingest([]byte(tc.input))
+		if got := r.content(); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTailRendererStripsOSCAndDCS(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"window title bel", "\x1b]2;train.py\x07epoch 1", "epoch 1"},
+		{"window title st", "\x1b]0;job 42\x1b\\done", "done"},
+		{"hyperlink", "see \x1b]8;;https://example.com/run/7\x1b\\run 7\x1b]8;;\x1b\\ for details", "see run 7 for details"},
+		{"dcs", "a\x1bPq#0;2;0;0;0\x1b\\b", "ab"},
+		{"keeps csi after osc", "\x1b]2;t\x07abc\x1b[2DX", "aXc"},
+	}
+	for _, tc := range cases {
+		r := newTailRenderer(100)
+		r.ingest([]byte(tc.input))
+		if got := r.content(); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	r := newTailRenderer(100)
+	r.ingest([]byte("start \x1b]8;;https://example.com/lo"))
+	r.ingest([]byte("ng/path\x1b"))
+	r.ingest([]byte("\\link\x1b]8;;\x07 end"))
+	if got, want := r.content(), "start link end"; got != want {
+		t.Fatalf("split osc: got %q, want %q", got, want)
+	}
+}
+
+func TestTailRendererTabStops(t *testing.T) {
+	r := newTailRenderer(100)
+	r.tabWidth = 4
+	r.ingest([]byte("a\tbc\tdefg\th\n\tx"))
+	if got, want := r.content(), "a   bc  defg    h\n    x"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestExpandLogPattern(t *testing.T) {
+	job := Job{ID: "4242_3", Name: "train", Nodes: "gpu[07-09,12]"}
+	cases := []struct {
+		pattern string
+		want    string
+	}{
+		{"%j", "4242_3"},
+		{"job_%j", "job_4242_3"},
+		{"%n-%j", "train-4242_3"},
+		{"/scratch/%u/logs/%j", "/scratch/alice/logs/4242_3"},
+		{"%N/%j", "gpu07/4242_3"},
+		{"plain", "plain"},
+		{"100%%_%j", "100%_4242_3"},
+		{"%%j", "%j"},
+		{"/var/log/slurm/job.log", "/var/log/slurm/job.log"},
+		{"%x%j%", "%x4242_3%"},
+	}
+	for _, tc := range cases {
+		if got := expandLogPattern(tc.pattern, job, "alice"); got != tc.want {
+			t.Fatalf("expandLogPattern(%q) = %q, want %q", tc.pattern, got, tc.want)
+		}
+	}
+}
+
+func TestSearchContent(t *testing.T) {
+	content := "epoch 1 loss=0.9\nWARNING: lr too high\nepoch 2 loss=0.5\n\x1b[31mError\x1b[0m: nan loss\n"
+	cases := []struct {
+		query string
+		want  []int
+	}{
+		{"loss", []int{0, 2, 3}},
+		{"EPOCH", []int{0, 2}},
+		{"error: nan", []int{3}},
+		{"warning", []int{1}},
+		{"accuracy", nil},
+		{"", nil},
+	}
+	for _, tc := range cases {
+		if got := searchContent(content, tc.query); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("searchContent(%q) = %v, want %v", tc.query, got, tc.want)
+		}
+	}
+	if got := searchContent("single line", "line"); !reflect.DeepEqual(got, []int{0}) {
+		t.Fatalf("expected a single match, got %v", got)
+	}
+}
+
+func TestAddLineNumbers(t *testing.T) {
+	if got := addLineNumbers("", 1); got != "" {
+		t.Fatalf("expected empty input to stay empty, got %q", got)
+	}
+	if got := addLineNumbers("hello", 1); got != "     1 │ hello" {
+		t.Fatalf("unexpected single line %q", got)
+	}
+	got := addLineNumbers("a\n\nc", 998)
+	want := "   998 │ a\n   999 │ \n  1000 │ c"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestPrefixLines(t *testing.T) {
+	if got := prefixLines("", nil); got != "" {
+		t.Fatalf("expected empty input to stay empty, got %q", got)
+	}
+	if got := prefixLines("a\nb", []string{"> "}); got != "> a\nb" {
+		t.Fatalf("expected lines without a prefix to stay unchanged, got %q", got)
+	}
+
+	f := newLogFollower("")
+	f.skippedLines = 0
+	f.renderer.ingest([]byte("a\n\nc\n"))
+	got := prefixLines(f.content(0), f.lineNumberPrefixes(0))
+	if want := addLineNumbers("a\n\nc", 1); got != want {
+		t.Fatalf("numbered content = %q, want %q", got, want)
+	}
+}
+
+func TestLineNumbersAfterTailCut(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.out")
+	var b strings.Builder
+	for i := 1; i <= 200; i++ {
+		fmt.Fprintf(&b, "line %03d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := newLogFollower(path)
+	f.tailBytes = 95 // lands mid-line; the partial line is skipped
+	if _, err := f.poll(streamOut); err != nil {
+		t.Fatal(err)
+	}
+	lines := f.renderer.logicalLines()
+	if lines[0] != "line 191" {
+		t.Fatalf("expected the tail to start at line 191, got %q", lines[0])
+	}
+	if got := f.firstLineNumber(); got != 191 {
+		t.Fatalf("firstLineNumber = %d, want 191", got)
+	}
+
+	prefixes := f.lineNumberPrefixes(4)
+	if prefixes[0] != "   191 │ " || prefixes[1] != "       │ " || prefixes[2] != "   192 │ " {
+		t.Fatalf("expected numbers only on the first wrapped segment, got %q", prefixes[:3])
+	}
+}
+
+func TestColorizeLogLine(t *testing.T) {
+	red, yellow, cyan, dim := "\x1b[38;5;196m", "\x1b[38;5;220m", "\x1b[38;5;51m", "\x1b[2m"
+	cases := []struct {
+		line  string
+		color string
+	}{
+		{"[ERROR] CUDA out of memory", red},
+		{"ERROR: bad input", red},
+		{"FATAL: cannot allocate", red},
+		{"CRITICAL worker died", red},
+		{"  error: linker failed", red},
+		{"WARNING: lr too high", yellow},
+		{"[WARN] retrying", yellow},
+		{"Warning: deprecated flag", yellow},
+		{"INFO loaded 10 shards", cyan},
+		{"<INFO> ready", cyan},
+		{"DEBUG step=3", dim},
+		{"2024-01-15 12:00:00,123 ERROR something broke", red},
+		{"2024-01-15T12:00:00 INFO started", cyan},
+		{"12:00:01 - WARNING - slow", ""},
+		{"epoch 3: no ERROR found", ""},
+		{"3 ERROR rows skipped", ""},
+		{"Information about the run", ""},
+		{"INFOS not a level", ""},
+		{"loss=0.5 [INFO]", ""},
+		{"", ""},
+	}
+	for _, tc := range cases {
+		got := colorizeLogLine(tc.line)
+		want := tc.line
+		if tc.color != "" {
+			want = tc.color + tc.line + sgrReset
+		}
+		if got != want {
+			t.Fatalf("colorizeLogLine(%q) = %q, want %q", tc.line, got, want)
+		}
+	}
+
+	styled := "\x1b[1mERROR\x1b[0m: \x1b[4mbad\x1b[0m"
+	if got := colorizeLogLine(styled); got != red+styled+sgrReset {
+		t.Fatalf("expected existing SGR kept and the color prepended, got %q", got)
+	}
+	if got := colorizedContent("INFO a\nplain", false); got != "INFO a\nplain" {
+		t.Fatalf("expected no change when disabled, got %q", got)
+	}
+	if got := colorizedContent("INFO a\nplain", true); got != cyan+"INFO a"+sgrReset+"\nplain" {
+		t.Fatalf("unexpected colorized content %q", got)
+	}
+}
+
+func TestSeekToLastNLines(t *testing.T) {
+	var many strings.Builder
+	for i := 1; i <= 500; i++ {
+		fmt.Fprintf(&many, "line %03d\n", i)
+	}
+	cases := []struct {
+		name    string
+		content string
+		n       int
+		want    string
+	}{
+		{"shorter than n", "a\nb\n", 5, "a\nb\n"},
+		{"exactly n", "a\nb\nc\n", 3, "a\nb\nc\n"},
+		{"no trailing newline", "a\nb\nc", 2, "b\nc"},
+		{"many more than n", many.String(), 3, "line 498\nline 499\nline 500\n"},
+		{"blank lines count", "a\n\n\nb\n", 2, "\nb\n"},
+		{"empty file", "", 4, ""},
+	}
+	for _, tc := range cases {
+		path := filepath.Join(t.TempDir(), "job.out")
+		if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// A tiny chunk size forces the scan across several chunk boundaries.
+		offset, err := seekToLastNLines(f, tc.n, 4)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := tc.content[offset:]; got != tc.want {
+			t.Fatalf("%s: tail = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestLogFollowerTailLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.out")
+	var b strings.Builder
+	for i := 1; i <= 200; i++ {
+		fmt.Fprintf(&b, "line %03d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := newLogFollower(path)
+	f.tailLines = 5
+	if _, err := f.poll(streamOut); err != nil {
+		t.Fatal(err)
+	}
+	lines := f.renderer.logicalLines()
+	if len(lines) != 5 || lines[0] != "line 196" {
+		t.Fatalf("expected the last 5 lines, got %q", lines)
+	}
+	if got := f.firstLineNumber(); got != 196 {
+		t.Fatalf("firstLineNumber = %d, want 196", got)
+	}
+}
+
+func appendGzip(t *testing.T, path, content string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zw := gzip.NewWriter(file)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogFollowerGzip(t *testing.T) {
+	for _, name := range []string{"job.out.gz", "job.out"} {
+		path := filepath.Join(t.TempDir(), name)
+		appendGzip(t, path, "first\nsecond\n")
+
+		f := newLogFollower(path)
+		chunk, err := f.poll(streamOut)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !f.compressed || f.content(0) != "first\nsecond" {
+			t.Fatalf("%s: expected decompressed content, got compressed=%v %q", name, f.compressed, f.content(0))
+		}
+		if len(chunk.NewLines) != 2 {
+			t.Fatalf("%s: expected 2 new lines, got %q", name, chunk.NewLines)
+		}
+
+		if chunk, _ = f.poll(streamOut); len(chunk.NewLines) != 0 {
+			t.Fatalf("%s: expected no new lines while the size is unchanged, got %q", name, chunk.NewLines)
+		}
+
+		// gzip readers concatenate appended members into one stream.
+		appendGzip(t, path, "third\n")
+		chunk, err = f.poll(streamOut)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(chunk.NewLines, []string{"third"}) || f.content(0) != "first\nsecond\nthird" {
+			t.Fatalf("%s: expected appended line, got %q / %q", name, chunk.NewLines, f.content(0))
+		}
+	}
+}
+
+func TestLogFollowerGzipTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.out.gz")
+	var b strings.Builder
+	for i := 1; i <= 200; i++ {
+		fmt.Fprintf(&b, "line %03d\n", i)
+	}
+	appendGzip(t, path, b.String())
+
+	f := newLogFollower(path)
+	f.tailLines = 3
+	if _, err := f.poll(streamOut); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.content(0); got != "line 198\nline 199\nline 200" {
+		t.Fatalf("expected the last 3 lines, got %q", got)
+	}
+	if got := f.firstLineNumber(); got != 198 {
+		t.Fatalf("firstLineNumber = %d, want 198", got)
+	}
+}
+
+func TestMergedBufferTimestamps(t *testing.T) {
+	at := time.Date(2024, 1, 15, 14, 32, 5, 123_000_000, time.UTC)
+	b := newMergedBuffer(100)
+	b.lines = []mergedLine{
+		{Label: streamOut, Text: "epoch 1", At: at},
+		{Label: streamErr, Text: "warning", At: at.Add(40 * time.Millisecond)},
+		{Label: streamOut, Text: "epoch 2", At: at.Add(2 * time.Second)},
+	}
+
+	want := "14:32:05.123 [OUT] epoch 1\n14:32:05.163 [ERR] warning\n14:32:07.123 [OUT] epoch 2"
+	b.showTimestamps = true
+	if got := b.content(); got != want {
+		t.Fatalf("content = %q, want %q", got, want)
+	}
+	b.showTimestamps = false
+	if got := b.content(); got != "[OUT] epoch 1\n[ERR] warning\n[OUT] epoch 2" {
+		t.Fatalf("expected no timestamps when they are hidden, got %q", got)
+	}
+}
+
+func TestMergedBufferTimestampToggle(t *testing.T) {
+	b := newMergedBuffer(100)
+	b.applyChunk(streamChunk{Label: streamOut, NewLines: []string{"out 1"}})
+	b.applyChunk(streamChunk{Label: streamErr, NewLines: []string{"err 1"}})
+	b.applyChunk(streamChunk{Label: streamOut, NewLines: []string{"out 2"}})
+	for i := 1; i < len(b.lines); i++ {
+		if b.lines[i].At.Before(b.lines[i-1].At) {
+			t.Fatalf("expected arrival times in order, got %v", b.lines)
+		}
+	}
+
+	plain := "[OUT] out 1\n[ERR] err 1\n[OUT] out 2"
+	if got := b.content(); got != plain {
+		t.Fatalf("content = %q, want %q", got, plain)
+	}
+	b.showTimestamps = true
+	stamped := regexp.MustCompile(`^\d{2}:\d{2}:\d{2}\.\d{3} \[OUT\] out 1\n\d{2}:\d{2}:\d{2}\.\d{3} \[ERR\] err 1\n\d{2}:\d{2}:\d{2}\.\d{3} \[OUT\] out 2$`)
+	if got := b.content(); !stamped.MatchString(got) {
+		t.Fatalf("expected timestamped content, got %q", got)
+	}
+	b.showTimestamps = false
+	if got := b.content(); got != plain {
+		t.Fatalf("expected plain content after toggling off, got %q", got)
+	}
+}
+
+func TestMergedBufferContentWrapped(t *testing.T) {
+	b := newMergedBuffer(100)
+	b.addLine(streamOut, "abcdefghijklmnopqrstuvwxyz")
+	b.addLine(streamErr, "short")
+
+	cases := []struct {
+		width int
+		want  string
+	}{
+		{0, "[OUT] abcdefghijklmnopqrstuvwxyz\n[ERR] short"},
+		{20, "[OUT] abcdefghijklmn\n      opqrstuvwxyz\n[ERR] short"},
+		{80, "[OUT] abcdefghijklmnopqrstuvwxyz\n[ERR] short"},
+		{len("[OUT] abcdefghijklmnopqrstuvwxyz"), "[OUT] abcdefghijklmnopqrstuvwxyz\n[ERR] short"},
+	}
+	for _, tc := range cases {
+		if got := b.contentWrapped(tc.width); got != tc.want {
+			t.Fatalf("width %d: got %q, want %q", tc.width, got, tc.want)
+		}
+		for _, line := range strings.Split(b.contentWrapped(tc.width), "\n") {
+			if tc.width > 0 && utf8.RuneCountInString(line) > tc.width {
+				t.Fatalf("width %d: line %q overflows", tc.width, line)
+			}
+		}
+	}
+
+	if got := b.lineNumberPrefixes(20); len(got) != 3 || got[0] != "     1 │ " || got[1] != "       │ " || got[2] != "     2 │ " {
+		t.Fatalf("expected blank prefixes on continuation rows, got %q", got)
+	}
+}
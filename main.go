@@ -1,14 +1,95 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+	base := defaultConfig()
+	base.CheckpointDir = defaultCheckpointDir()
+	base.StorePath = defaultStorePath()
+	configPath := configPathFromArgs(os.Args[1:])
+	cfg, err := loadConfig(configPath, base)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	flag.StringVar(&configPath, "config", configPath, "TOML config file with default settings")
+	writeConfig := flag.Bool("write-config", false, "write a commented default config file to the --config path and exit")
+	flag.StringVar(&cfg.Timezone, "timezone", cfg.Timezone, "IANA time zone of the cluster, e.g. America/New_York (default: local)")
+	columns := flag.String("columns", "", "comma-separated optional job table columns (gpu, partition, account, qos)")
+	states := flag.String("state", "", "only show jobs in these comma-separated states, e.g. RUNNING,PENDING")
+	flag.DurationVar(&cfg.RefreshInterval, "refresh-interval", cfg.RefreshInterval, "how often squeue is polled, e.g. 2s or 1m (at least 500ms)")
+	flag.DurationVar(&cfg.RefreshInterval, "r", cfg.RefreshInterval, "shorthand for --refresh-interval")
+	flag.StringVar(&cfg.FilterName, "name", cfg.FilterName, "only show jobs whose name matches this pattern, e.g. train*")
+	flag.BoolVar(&cfg.VisualBell, "visual-bell", cfg.VisualBell, "flash the jobs panel when a job fails, times out or is cancelled")
+	flag.StringVar(&cfg.Cluster, "cluster", cfg.Cluster, "federation cluster to query, passed to squeue/scontrol/scancel as --cluster")
+	flag.BoolVar(&cfg.AllUsers, "all-users", cfg.AllUsers, "show jobs from all users instead of only your own")
+	flag.StringVar(&cfg.User, "user", cfg.User, "show jobs of these comma-separated users instead of your own")
+	flag.StringVar(&cfg.User, "u", cfg.User, "shorthand for --user")
+	flag.StringVar(&cfg.Partition, "partition", cfg.Partition, "only show jobs in these comma-separated partitions (toggle with p)")
+	flag.StringVar(&cfg.Partition, "p", cfg.Partition, "shorthand for --partition")
+	flag.StringVar(&cfg.Account, "account", cfg.Account, "only show jobs charged to these comma-separated accounts")
+	flag.StringVar(&cfg.GRES, "gres", cfg.GRES, "only show jobs requesting this generic resource, e.g. gpu")
+	flag.BoolVar(&cfg.CompactMode, "compact", cfg.CompactMode, "start in compact mode without panel borders (toggle with ctrl+b)")
+	focus := flag.String("focus", cfg.InitialFocus, "pane focused at startup: jobs, stdout or stderr")
+	flag.BoolVar(&cfg.InitialMergedMode, "merged", cfg.InitialMergedMode, "start with stdout and stderr merged into one pane")
+	flag.BoolVar(&cfg.InitialFollow, "follow", cfg.InitialFollow, "follow log output of the selected job")
+	flag.StringVar(&cfg.LogDir, "log-dir", cfg.LogDir, "directory holding job logs")
+	flag.StringVar(&cfg.LogPattern, "log-pattern", cfg.LogPattern, "log file name inside --log-dir without .out/.err; supports %j job ID, %n name, %u user, %N first node, %% percent")
+	flag.StringVar(&cfg.LogOutPattern, "log-out", cfg.LogOutPattern, "full stdout path pattern with the --log-pattern placeholders; overrides --log-dir/--log-pattern")
+	flag.StringVar(&cfg.LogErrPattern, "log-err", cfg.LogErrPattern, "full stderr path pattern with the --log-pattern placeholders; overrides --log-dir/--log-pattern")
+	flag.Int64Var(&cfg.InitialTailBytes, "tail-bytes", cfg.InitialTailBytes, "how many bytes of an existing log are read when a job is selected")
+	flag.IntVar(&cfg.InitialTailLines, "tail-lines", cfg.InitialTailLines, "read the last N lines of an existing log instead of --tail-bytes (0 disables)")
+	flag.StringVar(&cfg.CheckpointDir, "checkpoint-dir", cfg.CheckpointDir, "where to remember log read offsets across restarts (empty disables)")
+	flag.StringVar(&cfg.RemoteLogHost, "remote-log-host", cfg.RemoteLogHost, "read log files over SSH from this host[:port] instead of the local filesystem")
+	flag.StringVar(&cfg.RemoteLogUser, "remote-log-user", cfg.RemoteLogUser, "SSH user for --remote-log-host (default: $USER)")
+	flag.DurationVar(&cfg.TerminalRetentionDuration, "retention", cfg.TerminalRetentionDuration, "dismiss terminal jobs this long after they were last seen; 0 dismisses them at once, negative keeps them")
+	noPersist := flag.Bool("no-persist", false, "don't load or save dismissed jobs and job history across restarts")
+	autoDismiss := flag.String("auto-dismiss", "", "auto-dismiss terminal jobs per state after a delay, e.g. COMPLETED=10m,CANCELLED=1h")
+	flag.Parse()
+	if *writeConfig {
+		if err := writeDefaultConfig(configPath, base); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("wrote", configPath)
+		return
+	}
+	if *noPersist {
+		cfg.StorePath = ""
+	}
+	if *columns != "" {
+		cfg.Columns = strings.Split(*columns, ",")
+	}
+	if *states != "" {
+		cfg.FilterStates = strings.Split(strings.ToUpper(*states), ",")
+	}
+	area, err := parseFocusArea(*focus)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	cfg.InitialFocusArea = area
+	if *autoDismiss != "" {
+		rules, err := parseAutoDismiss(*autoDismiss)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		cfg.AutoDismissStates = rules
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	p := tea.NewProgram(initialModel(cfg), tea.WithAltScreen(), tea.WithMouseCellMotion())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("There has been an error: %v", err)
 		os.Exit(1)
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const sinfoFormat = "%P %a %l %D %T %N"
+
+type PartitionInfo struct {
+	Name      string
+	Available string
+	TimeLimit string
+	NodeCount string
+	State     string
+	Nodes     string
+}
+
+func parseSinfoOutput(output string) []PartitionInfo {
+	var partitions []PartitionInfo
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		p := PartitionInfo{
+			Name:      fields[0],
+			Available: fields[1],
+			TimeLimit: fields[2],
+			NodeCount: fields[3],
+			State:     fields[4],
+		}
+		if len(fields) >= 6 {
+			p.Nodes = fields[5]
+		}
+		partitions = append(partitions, p)
+	}
+	return partitions
+}
+
+func sinfoStateColor(state string) lipgloss.Color {
+	state = strings.ToLower(state)
+	for _, bad := range []string{"down", "drain", "fail", "inact"} {
+		if strings.Contains(state, bad) {
+			return lipgloss.Color("196")
+		}
+	}
+	return lipgloss.Color("42")
+}
+
+func sinfoLines(partitions []PartitionInfo) []string {
+	if len(partitions) == 0 {
+		return []string{"no partitions reported by sinfo"}
+	}
+	headers := []string{"PARTITION", "AVAIL", "TIMELIMIT", "NODES", "STATE", "NODELIST"}
+	widths := make([]int, len(headers)-1)
+	rows := make([][]string, 0, len(partitions))
+	for _, p := range partitions {
+		rows = append(rows, []string{p.Name, p.Available, p.TimeLimit, p.NodeCount, p.State, p.Nodes})
+	}
+	for i := range widths {
+		widths[i] = len(headers[i])
+		for _, row := range rows {
+			widths[i] = max(widths[i], len(row[i]))
+		}
+	}
+
+	format := func(row []string, style func(i int, s string) string) string {
+		var b strings.Builder
+		for i, cell := range row {
+			if i < len(widths) {
+				cell = fmt.Sprintf("%-*s", widths[i], cell)
+			}
+			b.WriteString(style(i, cell))
+			if i < len(row)-1 {
+				b.WriteString("  ")
+			}
+		}
+		return b.String()
+	}
+
+	lines := []string{format(headers, func(_ int, s string) string { return s }), ""}
+	for _, row := range rows {
+		lines = append(lines, format(row, func(i int, s string) string {
+			switch i {
+			case 1, 4:
+				return lipgloss.NewStyle().Foreground(sinfoStateColor(strings.TrimSpace(s))).Render(s)
+			}
+			return s
+		}))
+	}
+	return lines
+}
+
+type sinfoMsg struct {
+	partitions []PartitionInfo
+	err        error
+}
+
+func fetchSinfoCmd(cluster string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scontrolTimeout)
+		defer cancel()
+		args := clusterArgs(cluster, "--noheader", "-o", sinfoFormat)
+		output, err := exec.CommandContext(ctx, "sinfo", args...).CombinedOutput()
+		if err != nil {
+			msg := strings.TrimSpace(string(output))
+			if msg == "" {
+				msg = err.Error()
+			}
+			return sinfoMsg{err: fmt.Errorf("sinfo: %s", msg)}
+		}
+		return sinfoMsg{partitions: parseSinfoOutput(string(output))}
+	}
+}
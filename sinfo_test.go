@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestParseSinfoOutput(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		want   []PartitionInfo
+	}{
+		{
+			"mixed states",
+			"cpu* up 1-00:00:00 12 mixed cpu[01-12]\ngpu up 2-00:00:00 2 idle gpu[01-02]\ngpu up 2-00:00:00 1 drained gpu03\ndebug down 30:00 1 down* dbg01\n",
+			[]PartitionInfo{
+				{Name: "cpu*", Available: "up", TimeLimit: "1-00:00:00", NodeCount: "12", State: "mixed", Nodes: "cpu[01-12]"},
+				{Name: "gpu", Available: "up", TimeLimit: "2-00:00:00", NodeCount: "2", State: "idle", Nodes: "gpu[01-02]"},
+				{Name: "gpu", Available: "up", TimeLimit: "2-00:00:00", NodeCount: "1", State: "drained", Nodes: "gpu03"},
+				{Name: "debug", Available: "down", TimeLimit: "30:00", NodeCount: "1", State: "down*", Nodes: "dbg01"},
+			},
+		},
+		{
+			"empty partition",
+			"long up infinite 0 n/a \n",
+			[]PartitionInfo{{Name: "long", Available: "up", TimeLimit: "infinite", NodeCount: "0", State: "n/a"}},
+		},
+		{
+			"blank and malformed lines",
+			"\n  \ngarbage line\n",
+			nil,
+		},
+	}
+	for _, tc := range cases {
+		if got := parseSinfoOutput(tc.output); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSinfoStateColor(t *testing.T) {
+	cases := map[string]lipgloss.Color{
+		"up": "42", "idle": "42", "mixed": "42", "allocated": "42",
+		"down": "196", "down*": "196", "drained": "196", "draining": "196", "inact": "196",
+	}
+	for state, want := range cases {
+		if got := sinfoStateColor(state); got != want {
+			t.Fatalf("sinfoStateColor(%q) = %v, want %v", state, got, want)
+		}
+	}
+}
+
+func TestSinfoLines(t *testing.T) {
+	lines := sinfoLines([]PartitionInfo{
+		{Name: "cpu*", Available: "up", TimeLimit: "1-00:00:00", NodeCount: "12", State: "mixed", Nodes: "cpu[01-12]"},
+		{Name: "debug", Available: "down", TimeLimit: "30:00", NodeCount: "1", State: "down*", Nodes: "dbg01"},
+	})
+	want := []string{
+		"PARTITION  AVAIL  TIMELIMIT   NODES  STATE  NODELIST",
+		"",
+		"cpu*       up     1-00:00:00  12     mixed  cpu[01-12]",
+		"debug      down   30:00       1      down*  dbg01",
+	}
+	for i := range lines {
+		lines[i] = ansi.Strip(lines[i])
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
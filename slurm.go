@@ -1,11 +1,30 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
+const slurmTimestampLayout = "2006-01-02T15:04:05"
+
+var squeueFields = []string{"%i", "%j", "%T", "%M", "%l", "%N", "%b", "%u", "%P", "%R", "%a", "%q", "%Q"}
+
+const squeueFieldSep = "|"
+
+func squeueFormat() string {
+	return strings.Join(squeueFields, squeueFieldSep)
+}
+
 func parseSqueueOutput(output string) []Job {
 	var jobs []Job
 	lines := strings.Split(output, "\n")
@@ -16,8 +35,17 @@ func parseSqueueOutput(output string) []Job {
 			continue
 		}
 
-		parts := strings.Fields(line)
-		if len(parts) < 5 {
+		parts := strings.Split(line, squeueFieldSep)
+		if extra := len(parts) - len(squeueFields); extra > 0 {
+			// The name is the only free-text field before the trailing
+			// columns, so any surplus separators belong to it.
+			name := strings.Join(parts[1:2+extra], squeueFieldSep)
+			parts = append(append(parts[:1:1], name), parts[2+extra:]...)
+		}
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		if len(parts) < 5 || parts[0] == "" || !isStateToken(parts[2]) {
 			continue
 		}
 
@@ -29,17 +57,229 @@ func parseSqueueOutput(output string) []Job {
 			TimeLimit: parts[4],
 			Nodes:     "",
 		}
+		job.ID, job.ArrayJobID, job.ArrayTaskID = parseJobIDAndArray(job.ID)
 		if len(parts) >= 6 {
 			job.Nodes = parts[5]
 		}
+		if len(parts) >= 7 && parts[6] != "N/A" {
+			job.TRES = parts[6]
+		}
+		if len(parts) >= 8 {
+			job.User = parts[7]
+		}
+		if len(parts) >= 9 {
+			job.Partition = parts[8]
+		}
+		if len(parts) >= 10 {
+			job.Reason = parseSqueueReason(parts[9])
+		}
+		if len(parts) >= 12 {
+			job.Account = parts[10]
+			job.QOS = parts[11]
+		}
+		if len(parts) >= 13 {
+			job.Priority, _ = strconv.Atoi(parts[12])
+		}
 		jobs = append(jobs, job)
 	}
 
 	return jobs
 }
 
-func checkSlurm() ([]Job, error) {
-	cmd := exec.Command("squeue", "--me", "--noheader", "-o", "%i %j %T %M %L %N")
+// parseSqueueReason unwraps a %R value. squeue puts the reason in
+// parentheses and prints the node list instead for running jobs, which is
+// already in %N.
+func parseSqueueReason(field string) string {
+	if strings.HasPrefix(field, "(") && strings.HasSuffix(field, ")") {
+		return strings.TrimSpace(field[1 : len(field)-1])
+	}
+	return ""
+}
+
+const sacctFormat = "JobID,JobName,State,Elapsed,Timelimit,NodeList"
+
+func parseSacctOutput(output string) []Job {
+	var jobs []Job
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(strings.TrimSuffix(line, "|"), "|")
+		if len(parts) < 6 || parts[0] == "" || strings.Contains(parts[0], ".") {
+			continue
+		}
+		state, _, _ := strings.Cut(parts[2], " ") // "CANCELLED by 1000"
+		if !isStateToken(state) {
+			continue
+		}
+		job := Job{
+			Name:      parts[1],
+			State:     state,
+			Time:      parts[3],
+			TimeLimit: parts[4],
+		}
+		job.ID, job.ArrayJobID, job.ArrayTaskID = parseJobIDAndArray(parts[0])
+		if parts[5] != "None assigned" {
+			job.Nodes = parts[5]
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func parseJobIDAndArray(raw string) (jobID, arrayJobID, arrayTaskID string) {
+	jobID = raw
+	parent, task, ok := strings.Cut(raw, "_")
+	if !ok || parent == "" || task == "" {
+		return jobID, "", ""
+	}
+	return jobID, parent, task
+}
+
+// firstNode returns the first host of a SLURM hostlist such as
+// "gpu[03-05,09],cpu01".
+func firstNode(nodes string) string {
+	end := len(nodes)
+	depth := 0
+	for i, c := range nodes {
+		if c == '[' {
+			depth++
+		} else if c == ']' {
+			depth--
+		} else if c == ',' && depth == 0 {
+			end = i
+			break
+		}
+	}
+	host := nodes[:end]
+	prefix, rest, ok := strings.Cut(host, "[")
+	if !ok {
+		return host
+	}
+	ranges, suffix, _ := strings.Cut(rest, "]")
+	first, _, _ := strings.Cut(ranges, ",")
+	first, _, _ = strings.Cut(first, "-")
+	return prefix + first + firstNode(suffix)
+}
+
+// expandNodeList expands a SLURM hostlist such as "gpu[01-03,07],cpu1"
+// into individual host names, keeping zero padding.
+func expandNodeList(nodes string) []string {
+	var hosts []string
+	start, depth := 0, 0
+	for i, c := range nodes {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				hosts = append(hosts, expandHost(nodes[start:i])...)
+				start = i + 1
+			}
+		}
+	}
+	return append(hosts, expandHost(nodes[start:])...)
+}
+
+func expandHost(host string) []string {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return nil
+	}
+	prefix, rest, ok := strings.Cut(host, "[")
+	if !ok {
+		return []string{host}
+	}
+	ranges, suffix, _ := strings.Cut(rest, "]")
+	tails := []string{""}
+	if suffix != "" {
+		tails = expandHost(suffix)
+	}
+	var hosts []string
+	for _, r := range strings.Split(ranges, ",") {
+		for _, n := range expandRange(r) {
+			for _, tail := range tails {
+				hosts = append(hosts, prefix+n+tail)
+			}
+		}
+	}
+	return hosts
+}
+
+func expandRange(r string) []string {
+	lo, hi, ok := strings.Cut(r, "-")
+	if !ok {
+		return []string{r}
+	}
+	from, err1 := strconv.Atoi(lo)
+	to, err2 := strconv.Atoi(hi)
+	if err1 != nil || err2 != nil || to < from {
+		return []string{r}
+	}
+	out := make([]string, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		out = append(out, fmt.Sprintf("%0*d", len(lo), n))
+	}
+	return out
+}
+
+func isStateToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func clusterArgs(cluster string, args ...string) []string {
+	if cluster == "" {
+		return args
+	}
+	return append([]string{"--cluster=" + cluster}, args...)
+}
+
+func squeueFlags(cfg Config) []string {
+	var flags []string
+	switch {
+	case cfg.AllUsers:
+	case cfg.User != "":
+		flags = []string{"-u", cfg.User}
+	default:
+		flags = []string{"--me"}
+	}
+	if cfg.Partition != "" {
+		flags = append(flags, "-p", cfg.Partition)
+	}
+	if cfg.Account != "" {
+		flags = append(flags, "-A", cfg.Account)
+	}
+	return clusterArgs(cfg.Cluster, flags...)
+}
+
+func squeueArgs(cfg Config) []string {
+	return squeueCommandArgs(squeueFlags(cfg))
+}
+
+func squeueCommandArgs(flags []string) []string {
+	return append(append([]string(nil), flags...), "--noheader", "-o", squeueFormat())
+}
+
+func checkSlurmWithFlags(flags []string) ([]Job, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), squeueTimeout)
+	defer cancel()
+	return runSqueue(ctx, squeueCommandArgs(flags))
+}
+
+func runSqueue(ctx context.Context, args []string) ([]Job, error) {
+	cmd := exec.CommandContext(ctx, "squeue", args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, err
@@ -47,8 +287,205 @@ func checkSlurm() ([]Job, error) {
 	return parseSqueueOutput(string(output)), nil
 }
 
-func cancelJob(jobID string) error {
-	cmd := exec.Command("scancel", jobID)
+const squeueTimeout = 10 * time.Second
+
+type squeueCache struct {
+	jobs []Job
+	key  string
+	at   time.Time
+	mu   sync.Mutex
+}
+
+var jobsCache squeueCache
+
+func (c *squeueCache) get(key string, ttl time.Duration, now time.Time) ([]Job, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.at.IsZero() || c.key != key || now.Sub(c.at) >= ttl {
+		return nil, false
+	}
+	return append([]Job(nil), c.jobs...), true
+}
+
+func (c *squeueCache) set(key string, jobs []Job, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.jobs = append([]Job(nil), jobs...)
+	c.key = key
+	c.at = now
+}
+
+func (c *squeueCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.at = time.Time{}
+}
+
+func checkSlurmCached(ttl time.Duration, cfg Config) ([]Job, error) {
+	flags := squeueFlags(cfg)
+	key := strings.Join(flags, " ")
+	if jobs, ok := jobsCache.get(key, ttl, time.Now()); ok {
+		return jobs, nil
+	}
+	jobs, err := checkSlurmWithFlags(flags)
+	if err != nil {
+		return nil, err
+	}
+	jobsCache.set(key, jobs, time.Now())
+	return jobs, nil
+}
+
+const scontrolTimeout = 2 * time.Second
+
+// discoverLogPaths asks scontrol where the job actually writes its output,
+// for jobs submitted with --output/--error outside the configured log layout.
+func discoverLogPaths(jobID, cluster string) (outPath, errPath string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), scontrolTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "scontrol", clusterArgs(cluster, "show", "job", jobID)...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", "", fmt.Errorf("scontrol show job %s: %s", jobID, msg)
+	}
+	return parseLogPaths(jobID, string(output))
+}
+
+func parseLogPaths(jobID, output string) (outPath, errPath string, err error) {
+	outPath = jobDetailFields.value(output, "StdOut")
+	errPath = jobDetailFields.value(output, "StdErr")
+	if outPath == "" {
+		return "", "", fmt.Errorf("scontrol show job %s: no StdOut", jobID)
+	}
+	if errPath == "" {
+		errPath = outPath
+	}
+	return outPath, errPath, nil
+}
+
+type ClusterMeta struct {
+	ClusterName  string
+	MaxArraySize string
+	MaxJobCount  string
+	DefaultTime  string
+	MaxTime      string
+	Config       map[string]string
+}
+
+func scontrolShowConfig(ctx context.Context, cluster string) (map[string]string, error) {
+	cmd := exec.CommandContext(ctx, "scontrol", clusterArgs(cluster, "show", "config")...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("scontrol show config: %s", msg)
+	}
+	return parseScontrolConfig(string(output)), nil
+}
+
+func parseScontrolConfig(output string) map[string]string {
+	config := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			continue
+		}
+		config[key] = strings.TrimSpace(value)
+	}
+	return config
+}
+
+func newClusterMeta(config map[string]string) ClusterMeta {
+	return ClusterMeta{
+		ClusterName:  config["ClusterName"],
+		MaxArraySize: config["MaxArraySize"],
+		MaxJobCount:  config["MaxJobCount"],
+		DefaultTime:  config["DefaultTime"],
+		MaxTime:      config["MaxTime"],
+		Config:       config,
+	}
+}
+
+type slurmClient interface {
+	CancelJob(jobID string) error
+	CancelArrayElement(arrayJobID, indices string) error
+	HoldJob(jobID string) error
+	ReleaseJob(jobID string) error
+	RequeueJob(jobID string) error
+	DiscoverLogPaths(jobID string) (outPath, errPath string, err error)
+}
+
+type execSlurmClient struct {
+	cluster string
+}
+
+func (c execSlurmClient) CancelJob(jobID string) error {
+	return cancelJob(jobID, c.cluster)
+}
+
+func (c execSlurmClient) CancelArrayElement(arrayJobID, indices string) error {
+	return cancelJobArrayElement(arrayJobID, indices, c.cluster)
+}
+
+func (c execSlurmClient) HoldJob(jobID string) error {
+	return holdJob(jobID, c.cluster)
+}
+
+func (c execSlurmClient) ReleaseJob(jobID string) error {
+	return releaseJob(jobID, c.cluster)
+}
+
+func (c execSlurmClient) RequeueJob(jobID string) error {
+	return requeueJob(jobID, c.cluster)
+}
+
+func (c execSlurmClient) DiscoverLogPaths(jobID string) (string, string, error) {
+	return discoverLogPaths(jobID, c.cluster)
+}
+
+func arrayElementID(arrayJobID, indices string) string {
+	return arrayJobID + "_" + indices
+}
+
+func cancelJobArrayElement(arrayJobID, indices, cluster string) error {
+	return cancelJob(arrayElementID(arrayJobID, indices), cluster)
+}
+
+func scancelArgs(jobID, cluster string) []string {
+	return clusterArgs(cluster, jobID)
+}
+
+func scancelBatchArgs(jobIDs []string, cluster string) []string {
+	return clusterArgs(cluster, jobIDs...)
+}
+
+type batchCancelMsg struct {
+	jobIDs []string
+	err    error
+}
+
+// batchCancelCmd cancels all jobIDs with a single scancel call.
+func batchCancelCmd(jobIDs []string, cluster string) tea.Cmd {
+	return func() tea.Msg {
+		output, err := exec.Command("scancel", scancelBatchArgs(jobIDs, cluster)...).CombinedOutput()
+		if err != nil {
+			msg := strings.TrimSpace(string(output))
+			if msg == "" {
+				msg = err.Error()
+			}
+			err = fmt.Errorf("cancel %s: %s", strings.Join(jobIDs, " "), msg)
+		}
+		return batchCancelMsg{jobIDs: jobIDs, err: err}
+	}
+}
+
+func cancelJob(jobID, cluster string) error {
+	cmd := exec.Command("scancel", scancelArgs(jobID, cluster)...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		msg := strings.TrimSpace(string(output))
@@ -59,3 +496,863 @@ func cancelJob(jobID string) error {
 	}
 	return nil
 }
+
+func parseSlurmTimestamp(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "", "Unknown", "None", "N/A":
+		return time.Time{}, fmt.Errorf("no timestamp: %q", s)
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return time.ParseInLocation(slurmTimestampLayout, s, loc)
+}
+
+func formatSlurmTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05 MST")
+}
+
+// unlimitedDuration is what parseSlurmDuration returns for UNLIMITED.
+const unlimitedDuration = time.Duration(math.MaxInt64)
+
+func parseSlurmDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "UNLIMITED", "INFINITE":
+		return unlimitedDuration, nil
+	case "", "N/A", "NOT_SET", "INVALID":
+		return 0, fmt.Errorf("no duration in %q", s)
+	}
+
+	var days, hours, mins, secs int
+	var format string
+	var fields []any
+	colons := strings.Count(s, ":")
+	if strings.Contains(s, "-") {
+		switch colons {
+		case 0:
+			format, fields = "%d-%d", []any{&days, &hours}
+		case 1:
+			format, fields = "%d-%d:%d", []any{&days, &hours, &mins}
+		case 2:
+			format, fields = "%d-%d:%d:%d", []any{&days, &hours, &mins, &secs}
+		}
+	} else {
+		switch colons {
+		case 0:
+			format, fields = "%d", []any{&mins}
+		case 1:
+			format, fields = "%d:%d", []any{&mins, &secs}
+		case 2:
+			format, fields = "%d:%d:%d", []any{&hours, &mins, &secs}
+		}
+	}
+	if format == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == '-' })
+	if len(parts) != len(fields) || strings.Trim(s, "0123456789:-") != "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if n, err := fmt.Sscanf(s, format, fields...); err != nil || n != len(fields) {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if secs > 59 || (len(fields) > 2 && mins > 59) {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	d := time.Duration(days)*24*time.Hour + time.Duration(hours)*time.Hour +
+		time.Duration(mins)*time.Minute + time.Duration(secs)*time.Second
+	return d, nil
+}
+
+// timeUsedFraction is how much of limit the elapsed time has used, or 0 when
+// either can't be parsed or the limit is unlimited.
+func timeUsedFraction(elapsed, limit string) float64 {
+	used, err := parseSlurmDuration(elapsed)
+	if err != nil {
+		return 0
+	}
+	total, err := parseSlurmDuration(limit)
+	if err != nil || total <= 0 || total == unlimitedDuration {
+		return 0
+	}
+	return float64(used) / float64(total)
+}
+
+func formatSlurmDuration(d time.Duration) string {
+	if d == unlimitedDuration {
+		return "UNLIMITED"
+	}
+	if d < 0 {
+		d = 0
+	}
+	total := int64(d / time.Second)
+	days := total / 86400
+	hours := total / 3600 % 24
+	mins := total / 60 % 60
+	secs := total % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%d-%02d:%02d:%02d", days, hours, mins, secs)
+	case hours > 0:
+		return fmt.Sprintf("%d:%02d:%02d", hours, mins, secs)
+	default:
+		return fmt.Sprintf("%d:%02d", mins, secs)
+	}
+}
+
+func ParseTRES(tres string) map[string]string {
+	out := make(map[string]string)
+	for _, part := range strings.Split(tres, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || part == "N/A" || part == "(null)" {
+			continue
+		}
+		if key, value, ok := strings.Cut(part, "="); ok {
+			out[key] = value
+			continue
+		}
+		// squeue's per-node form: gres/gpu:2, gres:gpu:a100:2 or a bare gres/gpu.
+		key, value := part, "1"
+		if i := strings.LastIndex(part, ":"); i > 0 {
+			if _, err := strconv.Atoi(part[i+1:]); err == nil {
+				key, value = part[:i], part[i+1:]
+			}
+		}
+		key = strings.Replace(key, "gres:", "gres/", 1)
+		out[key] = value
+	}
+	return out
+}
+
+type GRESInfo struct {
+	Type  string
+	Model string
+	Count int
+}
+
+// parseGRES reads one generic resource entry as squeue's %b prints it
+// (gpu:2, gpu:A100:2, gres/gpu:a100:2(IDX:0-1)) or as a TRES gres/gpu=2
+// pair. Other TRES entries such as cpu=8, (null) and N/A yield nothing.
+func parseGRES(s string) GRESInfo {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "(null)" || s == "N/A" {
+		return GRESInfo{}
+	}
+	isTRES := strings.Contains(s, "=")
+	if isTRES && !strings.HasPrefix(s, "gres/") {
+		return GRESInfo{}
+	}
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "gres/"), "gres:")
+	s, _, _ = strings.Cut(s, "(")
+
+	count := 1
+	if key, value, ok := strings.Cut(s, "="); ok {
+		s = key
+		count, _ = strconv.Atoi(value)
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 1 && !isTRES {
+		if n, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
+			count, parts = n, parts[:len(parts)-1]
+		}
+	}
+	info := GRESInfo{Type: parts[0], Count: count}
+	if len(parts) > 1 {
+		info.Model = parts[1]
+	}
+	return info
+}
+
+// gpuCount sums the GPUs in a TRES or GRES list. TRES lists repeat the
+// total as gres/gpu next to the per-model entries, so that total wins.
+func gpuCount(tres string) int {
+	total, byModel, untyped := 0, 0, false
+	for _, entry := range strings.Split(tres, ",") {
+		info := parseGRES(entry)
+		if info.Type != "gpu" {
+			continue
+		}
+		if info.Model == "" {
+			total += info.Count
+			untyped = true
+		} else {
+			byModel += info.Count
+		}
+	}
+	if untyped {
+		return total
+	}
+	return byModel
+}
+
+type Dependency struct {
+	Type  string
+	JobID string
+}
+
+func parseDependency(dep string) []Dependency {
+	dep = strings.TrimSpace(dep)
+	if dep == "" || dep == "(null)" {
+		return nil
+	}
+	var deps []Dependency
+	for _, cond := range strings.FieldsFunc(dep, func(r rune) bool { return r == ',' || r == '?' }) {
+		if i := strings.IndexByte(cond, '('); i >= 0 {
+			cond = cond[:i] // scontrol appends "(unfulfilled)" and similar
+		}
+		parts := strings.Split(cond, ":")
+		typ := strings.ToLower(strings.TrimSpace(parts[0]))
+		if typ == "" {
+			continue
+		}
+		if typ == "singleton" {
+			deps = append(deps, Dependency{Type: typ})
+			continue
+		}
+		for _, id := range parts[1:] {
+			id, _, _ = strings.Cut(strings.TrimSpace(id), "+")
+			if id != "" {
+				deps = append(deps, Dependency{Type: typ, JobID: id})
+			}
+		}
+	}
+	return deps
+}
+
+func describeDependencies(deps []Dependency) []string {
+	var types []string
+	ids := make(map[string][]string)
+	for _, d := range deps {
+		if _, ok := ids[d.Type]; !ok {
+			types = append(types, d.Type)
+			ids[d.Type] = nil
+		}
+		if d.JobID != "" {
+			ids[d.Type] = append(ids[d.Type], d.JobID)
+		}
+	}
+
+	lines := make([]string, 0, len(types))
+	for _, typ := range types {
+		jobs := "jobs " + strings.Join(ids[typ], ", ")
+		if len(ids[typ]) == 1 {
+			jobs = "job " + ids[typ][0]
+		}
+		switch typ {
+		case "after":
+			lines = append(lines, fmt.Sprintf("Starts after %s begin", jobs))
+		case "afterok":
+			lines = append(lines, fmt.Sprintf("Requires %s to succeed", jobs))
+		case "afternotok":
+			lines = append(lines, fmt.Sprintf("Requires %s to fail", jobs))
+		case "afterany":
+			lines = append(lines, fmt.Sprintf("Waits for %s to finish", jobs))
+		case "aftercorr":
+			lines = append(lines, fmt.Sprintf("Requires matching array tasks of %s to succeed", jobs))
+		case "singleton":
+			lines = append(lines, "Waits for earlier jobs with the same name and user to finish")
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %s", typ, strings.Join(ids[typ], ", ")))
+		}
+	}
+	return lines
+}
+
+type EfficiencyReport struct {
+	CPUEfficiency float64
+	MemEfficiency float64
+	WallTime      string
+	CPUTime       string
+
+	// CPUKnown is false when the job never ran (zero elapsed time) and
+	// MemKnown is false when no memory was requested, as seff reports.
+	CPUKnown bool
+	MemKnown bool
+}
+
+func (r EfficiencyReport) String() string {
+	return "CPU: " + r.cpuText() + "  Mem: " + r.memText()
+}
+
+func (r EfficiencyReport) cpuText() string {
+	if !r.CPUKnown {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%% efficient", r.CPUEfficiency)
+}
+
+func (r EfficiencyReport) memText() string {
+	if !r.MemKnown {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%% efficient", r.MemEfficiency)
+}
+
+func computeEfficiency(jobID, cluster string) (EfficiencyReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), scontrolTimeout)
+	defer cancel()
+	args := clusterArgs(cluster, "-j", jobID, "-o", "CPUTimeRaw,TotalCPU,ReqCPUS,MaxRSS,AllocTRES", "--parsable2", "--noheader")
+	output, err := exec.CommandContext(ctx, "sacct", args...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return EfficiencyReport{}, fmt.Errorf("sacct %s: %s", jobID, msg)
+	}
+	return parseEfficiency(string(output))
+}
+
+type LiveStats struct {
+	AveCPU       string
+	MaxRSS       string
+	AveDiskRead  string
+	AveDiskWrite string
+}
+
+// Summary renders the stats as "CPU: 87% RSS: 12 GB". The CPU share is
+// AveCPU over the job's elapsed time; it falls back to AveCPU itself.
+func (s LiveStats) Summary(elapsed string) string {
+	cpu := s.AveCPU
+	raw, _, _ := strings.Cut(s.AveCPU, ".")
+	if used, err := parseSlurmDuration(raw); err == nil {
+		if wall, err := parseSlurmDuration(elapsed); err == nil && wall > 0 {
+			cpu = fmt.Sprintf("%.0f%%", 100*used.Seconds()/wall.Seconds())
+		}
+	}
+	rss := s.MaxRSS
+	if n, ok := parseSlurmSize(s.MaxRSS, 'K'); ok {
+		rss = humanizeBytes(n)
+	}
+	return fmt.Sprintf("CPU: %s RSS: %s", cpu, rss)
+}
+
+type sstatMsg struct {
+	jobID string
+	stats LiveStats
+	err   error
+}
+
+func fetchSstatCmd(jobID, cluster string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scontrolTimeout)
+		defer cancel()
+		args := clusterArgs(cluster, "-j", jobID, "--noheader", "-o", "AveCPU,MaxRSS,AveDiskRead,AveDiskWrite", "-P")
+		output, err := exec.CommandContext(ctx, "sstat", args...).CombinedOutput()
+		if err != nil {
+			msg := strings.TrimSpace(string(output))
+			if msg == "" {
+				msg = err.Error()
+			}
+			return sstatMsg{jobID: jobID, err: fmt.Errorf("sstat %s: %s", jobID, msg)}
+		}
+		stats, err := parseSstat(string(output))
+		return sstatMsg{jobID: jobID, stats: stats, err: err}
+	}
+}
+
+// parseSstat reads sstat -P output, one line per running step, and keeps the
+// step using the most memory.
+func parseSstat(output string) (LiveStats, error) {
+	var best LiveStats
+	bestRSS := int64(-1)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, "|")
+		if len(parts) < 4 {
+			continue
+		}
+		rss, ok := parseSlurmSize(parts[1], 'K')
+		if !ok {
+			rss = 0
+		}
+		if rss > bestRSS {
+			best = LiveStats{AveCPU: parts[0], MaxRSS: parts[1], AveDiskRead: parts[2], AveDiskWrite: parts[3]}
+			bestRSS = rss
+		}
+	}
+	if bestRSS < 0 {
+		return LiveStats{}, errors.New("sstat: no running steps")
+	}
+	return best, nil
+}
+
+func parseEfficiency(output string) (EfficiencyReport, error) {
+	var report EfficiencyReport
+	var cpuTimeRaw, reqCPUs int64
+	var totalCPU time.Duration
+	var maxRSS, reqMem int64
+	seen := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(strings.TrimSuffix(line, "|"), "|")
+		if len(parts) < 5 {
+			continue
+		}
+		if !seen {
+			seen = true
+			cpuTimeRaw, _ = strconv.ParseInt(parts[0], 10, 64)
+			reqCPUs, _ = strconv.ParseInt(parts[2], 10, 64)
+			raw, _, _ := strings.Cut(parts[1], ".") // TotalCPU carries milliseconds
+			totalCPU, _ = parseSlurmDuration(raw)
+			report.CPUTime = parts[1]
+		}
+		if rss, ok := parseSlurmSize(parts[3], 'K'); ok && rss > maxRSS {
+			maxRSS = rss
+		}
+		if reqMem == 0 {
+			if mem, ok := parseSlurmSize(ParseTRES(parts[4])["mem"], 'M'); ok {
+				reqMem = mem
+			}
+		}
+	}
+	if !seen {
+		return report, fmt.Errorf("no accounting data")
+	}
+	if cpuTimeRaw > 0 {
+		report.CPUKnown = true
+		report.CPUEfficiency = 100 * totalCPU.Seconds() / float64(cpuTimeRaw)
+		if reqCPUs > 0 {
+			report.WallTime = formatSlurmDuration(time.Duration(cpuTimeRaw/reqCPUs) * time.Second)
+		}
+	}
+	if reqMem > 0 {
+		report.MemKnown = true
+		report.MemEfficiency = 100 * float64(maxRSS) / float64(reqMem)
+	}
+	return report, nil
+}
+
+func parseSlurmSize(s string, defaultUnit byte) (int64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	unit := defaultUnit
+	if last := s[len(s)-1]; last < '0' || last > '9' {
+		unit = last
+		s = s[:len(s)-1]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	scale := map[byte]float64{'B': 1, 'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30, 'T': 1 << 40}[unit]
+	if scale == 0 {
+		return 0, false
+	}
+	return int64(v * scale), true
+}
+
+type submitRequest struct {
+	Script    string
+	JobName   string
+	Partition string
+	NTasks    string
+	Time      string
+	Mem       string
+	Extra     string
+}
+
+func sbatchArgs(req submitRequest, cluster string) ([]string, error) {
+	if req.Script == "" {
+		return nil, fmt.Errorf("sbatch: script path is required")
+	}
+	var args []string
+	if req.JobName != "" {
+		args = append(args, "--job-name="+req.JobName)
+	}
+	if req.Partition != "" {
+		args = append(args, "--partition="+req.Partition)
+	}
+	if req.NTasks != "" {
+		if n, err := strconv.Atoi(req.NTasks); err != nil || n < 1 {
+			return nil, fmt.Errorf("sbatch: ntasks %q must be a positive number", req.NTasks)
+		}
+		args = append(args, "--ntasks="+req.NTasks)
+	}
+	if req.Time != "" {
+		args = append(args, "--time="+req.Time)
+	}
+	if req.Mem != "" {
+		args = append(args, "--mem="+req.Mem)
+	}
+	args = append(args, strings.Fields(req.Extra)...)
+	args = append(args, req.Script)
+	return clusterArgs(cluster, args...), nil
+}
+
+func parseSubmitOutput(output string) (string, error) {
+	for _, line := range strings.Split(output, "\n") {
+		_, rest, ok := strings.Cut(line, "Submitted batch job ")
+		if !ok {
+			continue
+		}
+		if fields := strings.Fields(rest); len(fields) > 0 {
+			if _, err := strconv.Atoi(fields[0]); err == nil {
+				return fields[0], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("sbatch: no job ID in output %q", strings.TrimSpace(output))
+}
+
+const sbatchTimeout = 30 * time.Second
+
+type submitMsg struct {
+	jobID string
+	err   error
+}
+
+func submitJobCmd(args []string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), sbatchTimeout)
+		defer cancel()
+		output, err := exec.CommandContext(ctx, "sbatch", args...).CombinedOutput()
+		if err != nil {
+			msg := strings.TrimSpace(string(output))
+			if msg == "" {
+				msg = err.Error()
+			}
+			return submitMsg{err: fmt.Errorf("sbatch: %s", msg)}
+		}
+		jobID, err := parseSubmitOutput(string(output))
+		return submitMsg{jobID: jobID, err: err}
+	}
+}
+
+func scontrolJobActionArgs(action, jobID, cluster string) []string {
+	return clusterArgs(cluster, action, jobID)
+}
+
+func scontrolJobAction(action, jobID, cluster string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), scontrolTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "scontrol", scontrolJobActionArgs(action, jobID, cluster)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("%s %s: %s", action, jobID, msg)
+	}
+	return nil
+}
+
+func holdJob(jobID, cluster string) error {
+	return scontrolJobAction("hold", jobID, cluster)
+}
+
+func releaseJob(jobID, cluster string) error {
+	return scontrolJobAction("release", jobID, cluster)
+}
+
+func requeueJob(jobID, cluster string) error {
+	return scontrolJobAction("requeue", jobID, cluster)
+}
+
+var signalPattern = regexp.MustCompile(`^[A-Z0-9]+$`)
+
+func validateSignal(signal string) error {
+	if !signalPattern.MatchString(signal) {
+		return fmt.Errorf("invalid signal %q: want a name like USR1 or a number", signal)
+	}
+	return nil
+}
+
+func scancelSignalArgs(jobID, signal, cluster string) []string {
+	return clusterArgs(cluster, "--signal="+signal, jobID)
+}
+
+type signalMsg struct {
+	jobID  string
+	signal string
+	err    error
+}
+
+func sendSignalCmd(jobID, signal, cluster string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scontrolTimeout)
+		defer cancel()
+		output, err := exec.CommandContext(ctx, "scancel", scancelSignalArgs(jobID, signal, cluster)...).CombinedOutput()
+		if err != nil {
+			msg := strings.TrimSpace(string(output))
+			if msg == "" {
+				msg = err.Error()
+			}
+			err = fmt.Errorf("signal %s to %s: %s", signal, jobID, msg)
+		}
+		return signalMsg{jobID: jobID, signal: signal, err: err}
+	}
+}
+
+type AccountingInfo struct {
+	ExitCode  string
+	CPUTime   string
+	MaxRSS    string
+	MaxVMSize string
+}
+
+func (a AccountingInfo) String() string {
+	parts := []string{"Exit:" + a.ExitCode}
+	if a.CPUTime != "" {
+		parts = append(parts, "CPUTime:"+a.CPUTime)
+	}
+	if a.MaxRSS != "" {
+		parts = append(parts, "MaxRSS:"+a.MaxRSS)
+	}
+	if a.MaxVMSize != "" {
+		parts = append(parts, "MaxVM:"+a.MaxVMSize)
+	}
+	return strings.Join(parts, "  ")
+}
+
+const accountingFormat = "JobID,State,ExitCode,CPUTime,MaxRSS,MaxVMSize"
+
+// parseAccountingOutput reads sacct -P rows for one job. Exit code and CPU
+// time come from the allocation row; memory peaks are only reported on the
+// step rows (batch, extern, numbered steps), so the largest one wins.
+func parseAccountingOutput(output string) (AccountingInfo, error) {
+	var info AccountingInfo
+	var maxRSS, maxVM int64 = -1, -1
+	seen := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, "|")
+		if len(parts) < 6 {
+			continue
+		}
+		if !seen {
+			seen = true
+			info.ExitCode = parts[2]
+			info.CPUTime = parts[3]
+		}
+		if rss, ok := parseSlurmSize(parts[4], 'K'); ok && rss > maxRSS {
+			maxRSS, info.MaxRSS = rss, parts[4]
+		}
+		if vm, ok := parseSlurmSize(parts[5], 'K'); ok && vm > maxVM {
+			maxVM, info.MaxVMSize = vm, parts[5]
+		}
+	}
+	if !seen {
+		return info, fmt.Errorf("sacct: no accounting rows")
+	}
+	return info, nil
+}
+
+type accountingMsg struct {
+	jobID string
+	info  AccountingInfo
+	err   error
+}
+
+func fetchAccountingCmd(jobID, cluster string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scontrolTimeout)
+		defer cancel()
+		args := clusterArgs(cluster, "-j", jobID, "-o", accountingFormat, "--noheader", "-P")
+		output, err := exec.CommandContext(ctx, "sacct", args...).CombinedOutput()
+		if err != nil {
+			msg := strings.TrimSpace(string(output))
+			if msg == "" {
+				msg = err.Error()
+			}
+			return accountingMsg{jobID: jobID, err: fmt.Errorf("sacct %s: %s", jobID, msg)}
+		}
+		info, err := parseAccountingOutput(string(output))
+		return accountingMsg{jobID: jobID, info: info, err: err}
+	}
+}
+
+type JobDetail struct {
+	JobID      string
+	Partition  string
+	Account    string
+	WorkDir    string
+	StdOut     string
+	StdErr     string
+	NodeList   string
+	Reason     string
+	SubmitTime string
+	StartTime  string
+	EndTime    string
+	Command    string
+	Priority   string
+	TimeLimit  string
+	Dependency string
+}
+
+// scontrolFields extracts Key=value pairs from multi-line scontrol show
+// output. A value runs until the next Key= token on the same line, so
+// single-field lines like WorkDir keep paths with spaces intact.
+type scontrolFields map[string]*regexp.Regexp
+
+func newScontrolFields(keys ...string) scontrolFields {
+	fields := make(scontrolFields, len(keys))
+	for _, key := range keys {
+		fields[key] = regexp.MustCompile(`(?m)(?:^|\s)` + key + `=(.*?)(?:\s+[A-Za-z][\w:/]*=|\s*$)`)
+	}
+	return fields
+}
+
+func (f scontrolFields) value(output, key string) string {
+	if match := f[key].FindStringSubmatch(output); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+var jobDetailFields = newScontrolFields(
+	"JobId", "Partition", "Account", "WorkDir", "StdOut", "StdErr", "NodeList", "Reason", "SubmitTime",
+	"StartTime", "EndTime", "Command", "Priority", "TimeLimit", "Dependency",
+)
+
+func parseJobDetail(output string) (JobDetail, error) {
+	d := JobDetail{
+		JobID:      jobDetailFields.value(output, "JobId"),
+		Partition:  jobDetailFields.value(output, "Partition"),
+		Account:    jobDetailFields.value(output, "Account"),
+		WorkDir:    jobDetailFields.value(output, "WorkDir"),
+		StdOut:     jobDetailFields.value(output, "StdOut"),
+		StdErr:     jobDetailFields.value(output, "StdErr"),
+		NodeList:   jobDetailFields.value(output, "NodeList"),
+		Reason:     jobDetailFields.value(output, "Reason"),
+		SubmitTime: jobDetailFields.value(output, "SubmitTime"),
+		StartTime:  jobDetailFields.value(output, "StartTime"),
+		EndTime:    jobDetailFields.value(output, "EndTime"),
+		Command:    jobDetailFields.value(output, "Command"),
+		Priority:   jobDetailFields.value(output, "Priority"),
+		TimeLimit:  jobDetailFields.value(output, "TimeLimit"),
+		Dependency: jobDetailFields.value(output, "Dependency"),
+	}
+	if d.JobID == "" {
+		return d, fmt.Errorf("scontrol: no job in output %q", strings.TrimSpace(output))
+	}
+	return d, nil
+}
+
+type jobDetailMsg struct {
+	jobID  string
+	detail JobDetail
+	err    error
+}
+
+func fetchJobDetailCmd(jobID, cluster string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scontrolTimeout)
+		defer cancel()
+		output, err := exec.CommandContext(ctx, "scontrol", clusterArgs(cluster, "show", "job", jobID)...).CombinedOutput()
+		if err != nil {
+			msg := strings.TrimSpace(string(output))
+			if msg == "" {
+				msg = err.Error()
+			}
+			return jobDetailMsg{jobID: jobID, err: fmt.Errorf("scontrol show job %s: %s", jobID, msg)}
+		}
+		detail, err := parseJobDetail(string(output))
+		return jobDetailMsg{jobID: jobID, detail: detail, err: err}
+	}
+}
+
+type batchScriptMsg struct {
+	jobID  string
+	script string
+	err    error
+}
+
+// fetchBatchScriptCmd asks slurmctld for the script a job was submitted
+// with. Slurm only keeps it while the job is known to the controller.
+func fetchBatchScriptCmd(jobID, cluster string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scontrolTimeout)
+		defer cancel()
+		var stderr strings.Builder
+		cmd := exec.CommandContext(ctx, "scontrol", clusterArgs(cluster, "write", "batch_script", jobID, "-")...)
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				msg = err.Error()
+			}
+			return batchScriptMsg{jobID: jobID, err: fmt.Errorf("scontrol write batch_script %s: %s", jobID, msg)}
+		}
+		return batchScriptMsg{jobID: jobID, script: string(output)}
+	}
+}
+
+type NodeDetail struct {
+	NodeName string
+	State    string
+	CPUTot   string
+	CPULoad  string
+	AllocMem string
+	FreeMem  string
+	OS       string
+	Features string
+	Gres     string
+}
+
+var nodeDetailFields = newScontrolFields(
+	"NodeName", "State", "CPUTot", "CPULoad", "AllocMem", "FreeMem", "OS",
+	"AvailableFeatures", "Features", "Gres",
+)
+
+func parseNodeDetail(output string) (NodeDetail, error) {
+	d := NodeDetail{
+		NodeName: nodeDetailFields.value(output, "NodeName"),
+		State:    nodeDetailFields.value(output, "State"),
+		CPUTot:   nodeDetailFields.value(output, "CPUTot"),
+		CPULoad:  nodeDetailFields.value(output, "CPULoad"),
+		AllocMem: nodeDetailFields.value(output, "AllocMem"),
+		FreeMem:  nodeDetailFields.value(output, "FreeMem"),
+		OS:       nodeDetailFields.value(output, "OS"),
+		Features: nodeDetailFields.value(output, "AvailableFeatures"),
+		Gres:     nodeDetailFields.value(output, "Gres"),
+	}
+	if d.Features == "" {
+		d.Features = nodeDetailFields.value(output, "Features") // pre-20.02 name
+	}
+	if d.NodeName == "" {
+		return d, fmt.Errorf("scontrol: no node in output %q", strings.TrimSpace(output))
+	}
+	return d, nil
+}
+
+type nodeDetailMsg struct {
+	node   string
+	detail NodeDetail
+	err    error
+}
+
+func fetchNodeDetailCmd(nodeName, cluster string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scontrolTimeout)
+		defer cancel()
+		output, err := exec.CommandContext(ctx, "scontrol", clusterArgs(cluster, "show", "node", nodeName)...).CombinedOutput()
+		if err != nil {
+			msg := strings.TrimSpace(string(output))
+			if msg == "" {
+				msg = err.Error()
+			}
+			return nodeDetailMsg{node: nodeName, err: fmt.Errorf("scontrol show node %s: %s", nodeName, msg)}
+		}
+		detail, err := parseNodeDetail(string(output))
+		return nodeDetailMsg{node: nodeName, detail: detail, err: err}
+	}
+}
@@ -1,9 +1,16 @@
 package main
 
-import "testing"
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestParseSqueueOutput(t *testing.T) {
-	input := "101 alpha RUNNING 00:10 01:00 node-a\n102 beta PENDING 00:00 02:00 (Priority)\n"
+	input := "101|alpha|RUNNING|00:10|01:00|node-a\n102|beta|PENDING|00:00|02:00|(Priority)\n"
 	jobs := parseSqueueOutput(input)
 
 	if len(jobs) != 2 {
@@ -18,7 +25,7 @@ func TestParseSqueueOutput(t *testing.T) {
 }
 
 func TestParseSqueueOutputSkipsMalformed(t *testing.T) {
-	input := "bad line\n103 gamma RUNNING 00:10 01:00\n"
+	input := "bad line\n103|gamma|RUNNING|00:10|01:00\n"
 	jobs := parseSqueueOutput(input)
 	if len(jobs) != 1 {
 		t.Fatalf("expected 1 parsed row, got %d", len(jobs))
@@ -27,3 +34,859 @@ func TestParseSqueueOutputSkipsMalformed(t *testing.T) {
 		t.Fatalf("expected id 103, got %s", jobs[0].ID)
 	}
 }
+
+func TestParseSlurmTimestampWithTZ(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+
+	ts, err := parseSlurmTimestamp("2024-01-15T14:32:05", loc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ts.UTC().Format(time.RFC3339); got != "2024-01-15T19:32:05Z" {
+		t.Fatalf("unexpected UTC time: %s", got)
+	}
+	if got := formatSlurmTimestamp(ts); got != "2024-01-15 14:32:05 EST" {
+		t.Fatalf("unexpected formatted time: %s", got)
+	}
+
+	if _, err := parseSlurmTimestamp("Unknown", loc); err == nil {
+		t.Fatalf("expected error for Unknown timestamp")
+	}
+	if ts, err := parseSlurmTimestamp("2024-07-01T08:00:00", nil); err != nil || ts.Location() != time.Local {
+		t.Fatalf("expected local fallback, got %v (%v)", ts.Location(), err)
+	}
+}
+
+func TestGPUCount(t *testing.T) {
+	cases := []struct {
+		tres string
+		want int
+	}{
+		{"cpu=8,mem=64G,node=1,billing=8,gres/gpu=2", 2},
+		{"cpu=8,mem=64G,node=1,gres/gpu=4,gres/gpu:a100=4", 4},
+		{"cpu=8,gres/gpu:a100=2,gres/gpu:v100=1", 3},
+		{"gres/gpu:2", 2},
+		{"gres:gpu:a100:1", 1},
+		{"gpu:2", 2},
+		{"gpu:A100:2", 2},
+		{"gres/gpu:a100:2(IDX:0-1)", 2},
+		{"gpu:a100:2(IDX:0,2)", 2},
+		{"cpu=4,mem=16G,node=1", 0},
+		{"", 0},
+		{"N/A", 0},
+	}
+	for _, tc := range cases {
+		if got := gpuCount(tc.tres); got != tc.want {
+			t.Fatalf("gpuCount(%q) = %d, want %d", tc.tres, got, tc.want)
+		}
+	}
+}
+
+func FuzzParseSqueueOutput(f *testing.F) {
+	f.Add([]byte("101|alpha|RUNNING|00:10|01:00|node-a|gres/gpu:1\n102|beta|PENDING|0:00|2:00||N/A\n"))
+	f.Add([]byte("103|my training run|RUNNING|1-02:03:04|2-00:00:00|node[01-04]|N/A\n"))
+	f.Add([]byte("\n\n   \n"))
+	f.Add([]byte("104|训练|RUNNING|00:01|00:10|nœud-é|N/A\n"))
+	f.Add([]byte("105|x|RUN NING|00:01|00:10\n|||||\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, job := range parseSqueueOutput(string(data)) {
+			if job.ID == "" {
+				t.Fatalf("empty job id in %+v", job)
+			}
+			if !isStateToken(job.State) {
+				t.Fatalf("unexpected state %q", job.State)
+			}
+		}
+	})
+}
+
+func TestParseJobIDAndArray(t *testing.T) {
+	cases := []struct {
+		raw, parent, task string
+	}{
+		{"12345_3", "12345", "3"},
+		{"12345_0", "12345", "0"},
+		{"99999", "", ""},
+		{"12345_0-9", "12345", "0-9"},
+		{"12345_[1-5%2]", "12345", "[1-5%2]"},
+		{"12345_", "", ""},
+	}
+	for _, tc := range cases {
+		id, parent, task := parseJobIDAndArray(tc.raw)
+		if id != tc.raw || parent != tc.parent || task != tc.task {
+			t.Fatalf("parseJobIDAndArray(%q) = %q, %q, %q", tc.raw, id, parent, task)
+		}
+	}
+
+	jobs := parseSqueueOutput("12345_3|sweep|RUNNING|00:10|01:00|node-a\n")
+	if len(jobs) != 1 || !jobs[0].IsArrayTask() || jobs[0].ArrayJobID != "12345" || jobs[0].ID != "12345_3" {
+		t.Fatalf("unexpected array job: %+v", jobs)
+	}
+}
+
+func TestParseLogPaths(t *testing.T) {
+	cases := []struct {
+		name    string
+		output  string
+		out     string
+		errPath string
+	}{
+		{
+			"one line",
+			"JobId=123 JobName=train ArrayJobId=N/A JobState=RUNNING Reason=None StdErr=/scratch/logs/train-123.err StdIn=/dev/null StdOut=/scratch/logs/train-123.out Power=\n",
+			"/scratch/logs/train-123.out", "/scratch/logs/train-123.err",
+		},
+		{
+			"multi line",
+			"JobId=123 JobName=train\n   WorkDir=/home/alice\n   StdErr=/home/alice/train.err\n   StdIn=/dev/null\n   StdOut=/home/alice/train.out\n   Power=\n",
+			"/home/alice/train.out", "/home/alice/train.err",
+		},
+		{
+			"paths with spaces",
+			"JobId=124 JobName=eval\n   StdErr=/home/alice/my runs/eval 124.err\n   StdIn=/dev/null\n   StdOut=/home/alice/my runs/eval 124.out\n",
+			"/home/alice/my runs/eval 124.out", "/home/alice/my runs/eval 124.err",
+		},
+		{
+			"spaces on one line",
+			"JobId=124 StdErr=/home/alice/my runs/eval.err StdIn=/dev/null StdOut=/home/alice/my runs/eval.out Power=",
+			"/home/alice/my runs/eval.out", "/home/alice/my runs/eval.err",
+		},
+		{
+			"no stderr",
+			"JobId=125\n   StdIn=/dev/null\n   StdOut=/tmp/slurm-125.out\n",
+			"/tmp/slurm-125.out", "/tmp/slurm-125.out",
+		},
+	}
+	for _, tc := range cases {
+		out, errPath, err := parseLogPaths("123", tc.output)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if out != tc.out || errPath != tc.errPath {
+			t.Fatalf("%s: got %q %q, want %q %q", tc.name, out, errPath, tc.out, tc.errPath)
+		}
+	}
+
+	if _, _, err := parseLogPaths("126", "JobId=126 JobState=PENDING\n"); err == nil {
+		t.Fatalf("expected an error without StdOut")
+	}
+}
+
+func TestSqueueCacheTTL(t *testing.T) {
+	var c squeueCache
+	now := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	if _, ok := c.get("", time.Second, now); ok {
+		t.Fatalf("expected empty cache to miss")
+	}
+
+	jobs := []Job{{ID: "1", State: "RUNNING"}}
+	c.set("", jobs, now)
+	jobs[0].State = "FAILED"
+
+	got, ok := c.get("", time.Second, now.Add(500*time.Millisecond))
+	if !ok || len(got) != 1 || got[0].State != "RUNNING" {
+		t.Fatalf("expected cached copy within ttl, got %#v (hit=%v)", got, ok)
+	}
+	got[0].State = "CANCELLED"
+	if again, _ := c.get("", time.Second, now); again[0].State != "RUNNING" {
+		t.Fatalf("cache must not share slices with callers")
+	}
+	if _, ok := c.get("", time.Second, now.Add(time.Second)); ok {
+		t.Fatalf("expected cache to expire after ttl")
+	}
+}
+
+func TestParseDependency(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []Dependency
+	}{
+		{"", nil},
+		{"(null)", nil},
+		{"after:10", []Dependency{{"after", "10"}}},
+		{"afterok:123:456", []Dependency{{"afterok", "123"}, {"afterok", "456"}}},
+		{"afternotok:7", []Dependency{{"afternotok", "7"}}},
+		{"afterany:789", []Dependency{{"afterany", "789"}}},
+		{"aftercorr:55", []Dependency{{"aftercorr", "55"}}},
+		{"singleton", []Dependency{{"singleton", ""}}},
+		{"afterok:123:456,afterany:789", []Dependency{{"afterok", "123"}, {"afterok", "456"}, {"afterany", "789"}}},
+		{"afterok:1(unfulfilled)", []Dependency{{"afterok", "1"}}},
+		{"after:5+30?afterany:6", []Dependency{{"after", "5"}, {"afterany", "6"}}},
+	}
+	for _, tc := range cases {
+		got := parseDependency(tc.in)
+		if len(got) != len(tc.want) {
+			t.Fatalf("parseDependency(%q) = %#v, want %#v", tc.in, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("parseDependency(%q)[%d] = %#v, want %#v", tc.in, i, got[i], tc.want[i])
+			}
+		}
+	}
+
+	lines := describeDependencies(parseDependency("afterok:123:456,afterany:789,singleton"))
+	want := []string{
+		"Requires jobs 123, 456 to succeed",
+		"Waits for job 789 to finish",
+		"Waits for earlier jobs with the same name and user to finish",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("describeDependencies = %q, want %q", lines, want)
+	}
+}
+
+func TestParseSlurmDuration(t *testing.T) {
+	cases := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"0:42", 42 * time.Second},
+		{"5:07", 5*time.Minute + 7*time.Second},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"12:00:00", 12 * time.Hour},
+		{"1-02:03:04", 26*time.Hour + 3*time.Minute + 4*time.Second},
+		{"14-00:00:00", 14 * 24 * time.Hour},
+		{"2-3", 51 * time.Hour},
+		{"2-3:4", 51*time.Hour + 4*time.Minute},
+		{"90", 90 * time.Minute},
+		{"UNLIMITED", time.Duration(math.MaxInt64)},
+		{"INFINITE", unlimitedDuration},
+		{"0:00", 0},
+		{"0-00:00:00", 0},
+	}
+	for _, tc := range cases {
+		got, err := parseSlurmDuration(tc.in)
+		if err != nil || got != tc.want {
+			t.Fatalf("parseSlurmDuration(%q) = %s, %v; want %s", tc.in, got, err, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"", "N/A", "NOT_SET", "abc", "1:2:3:4", "1::2", "-1:00", "1:60", "1:99:00", "1:00x", "1-2-3"} {
+		if got, err := parseSlurmDuration(bad); err == nil {
+			t.Fatalf("parseSlurmDuration(%q) = %s, want error", bad, got)
+		}
+	}
+
+	for _, s := range []string{"0:42", "1:02:03", "1-02:03:04", "UNLIMITED"} {
+		d, _ := parseSlurmDuration(s)
+		if got := formatSlurmDuration(d); got != s {
+			t.Fatalf("formatSlurmDuration(%s) = %q, want %q", d, got, s)
+		}
+	}
+}
+
+func TestCheckSlurmWithCluster(t *testing.T) {
+	args := squeueArgs(Config{Cluster: "hpc-cluster-2"})
+	if len(args) == 0 || args[0] != "--cluster=hpc-cluster-2" {
+		t.Fatalf("expected --cluster as the first squeue argument, got %q", args)
+	}
+	if got := squeueArgs(defaultConfig()); strings.Contains(strings.Join(got, " "), "--cluster") {
+		t.Fatalf("expected no --cluster without a configured cluster, got %q", got)
+	}
+	if got := clusterArgs("hpc-cluster-2", "123"); len(got) != 2 || got[0] != "--cluster=hpc-cluster-2" || got[1] != "123" {
+		t.Fatalf("unexpected scancel args %q", got)
+	}
+
+	var c squeueCache
+	now := time.Now()
+	c.set("a", []Job{{ID: "1"}}, now)
+	if _, ok := c.get("b", time.Minute, now); ok {
+		t.Fatalf("expected cache entries to be scoped to their cluster")
+	}
+}
+
+func TestCancelJobArrayElement(t *testing.T) {
+	args := scancelArgs(arrayElementID("12345", "3-7"), "")
+	if len(args) != 1 || args[0] != "12345_3-7" {
+		t.Fatalf("unexpected scancel args %q", args)
+	}
+	args = scancelArgs(arrayElementID("12345", "3"), "hpc-cluster-2")
+	if strings.Join(args, " ") != "--cluster=hpc-cluster-2 12345_3" {
+		t.Fatalf("unexpected scancel args with cluster %q", args)
+	}
+}
+
+func TestSqueueArgsUserScope(t *testing.T) {
+	cases := []struct {
+		cfg  Config
+		want []string
+	}{
+		{defaultConfig(), []string{"--me"}},
+		{Config{User: "alice"}, []string{"-u", "alice"}},
+		{Config{User: "alice,bob"}, []string{"-u", "alice,bob"}},
+		{Config{AllUsers: true, User: "alice"}, nil},
+		{Config{Cluster: "gpu", User: "bob"}, []string{"--cluster=gpu", "-u", "bob"}},
+	}
+	for _, tc := range cases {
+		args := squeueArgs(tc.cfg)
+		var scope []string
+		for _, arg := range args {
+			if arg == "--noheader" {
+				break
+			}
+			scope = append(scope, arg)
+		}
+		if !reflect.DeepEqual(scope, tc.want) {
+			t.Fatalf("squeueArgs(%+v) scope = %q, want %q (args %q)", tc.cfg, scope, tc.want, args)
+		}
+	}
+
+	jobs := parseSqueueOutput("7|train|RUNNING|1:00|2:00|node01|N/A|alice\n")
+	if len(jobs) != 1 || jobs[0].User != "alice" {
+		t.Fatalf("expected user column to be parsed, got %#v", jobs)
+	}
+}
+
+func TestParseScontrolConfig(t *testing.T) {
+	out := `Configuration data as of 2024-01-15T14:32:05
+AccountingStorageType   = accounting_storage/slurmdbd
+ClusterName             = hpc-cluster-2
+DefaultTime             = 01:00:00
+MaxArraySize            = 1001
+MaxJobCount             = 10000
+MaxTime                 = 7-00:00:00
+SchedulerParameters     = bf_window=4320,bf_resolution=600
+
+Cgroup Support Configuration:
+`
+	config := parseScontrolConfig(out)
+	meta := newClusterMeta(config)
+	if meta.ClusterName != "hpc-cluster-2" || meta.MaxArraySize != "1001" || meta.MaxJobCount != "10000" ||
+		meta.DefaultTime != "01:00:00" || meta.MaxTime != "7-00:00:00" {
+		t.Fatalf("unexpected cluster meta %#v", meta)
+	}
+	if got := config["SchedulerParameters"]; got != "bf_window=4320,bf_resolution=600" {
+		t.Fatalf("expected values containing = to survive, got %q", got)
+	}
+	if _, ok := config["Configuration data as of 2024-01-15T14:32:05"]; ok || len(config) != 7 {
+		t.Fatalf("expected only key/value lines, got %d entries: %v", len(config), config)
+	}
+}
+
+func TestParseSacctOutput(t *testing.T) {
+	out := `4242|train|COMPLETED|01:02:03|04:00:00|node01|
+4242.batch|batch|COMPLETED|01:02:03||node01|
+4242.extern|extern|COMPLETED|01:02:03||node01|
+4300_7|sweep|FAILED|00:00:42|01:00:00|node07|
+4300_7.batch|batch|FAILED|00:00:42||node07|
+4301|eval|CANCELLED by 1000|00:10:00|01:00:00|node02|
+4302_[1-5]|sweep|PENDING|00:00:00|01:00:00|None assigned|
+garbage line
+`
+	jobs := parseSacctOutput(out)
+	if len(jobs) != 4 {
+		t.Fatalf("expected 4 jobs without steps, got %d: %#v", len(jobs), jobs)
+	}
+	if jobs[0].ID != "4242" || jobs[0].Time != "01:02:03" || jobs[0].TimeLimit != "04:00:00" || jobs[0].Nodes != "node01" {
+		t.Fatalf("unexpected first job %#v", jobs[0])
+	}
+	if jobs[1].ArrayJobID != "4300" || jobs[1].ArrayTaskID != "7" || jobs[1].State != "FAILED" {
+		t.Fatalf("unexpected array task %#v", jobs[1])
+	}
+	if jobs[2].State != "CANCELLED" {
+		t.Fatalf("expected cancelled-by suffix to be stripped, got %q", jobs[2].State)
+	}
+	if jobs[3].ArrayTaskID != "[1-5]" || jobs[3].Nodes != "" {
+		t.Fatalf("unexpected pending array %#v", jobs[3])
+	}
+
+	s := NewJobStore()
+	s.ApplySnapshot(jobs, time.Now())
+	if rec, ok := s.Record("4301"); !ok || !rec.Terminal {
+		t.Fatalf("expected sacct jobs to be usable with ApplySnapshot, got %#v", rec)
+	}
+}
+
+func TestParseEfficiency(t *testing.T) {
+	out := `14400|01:00:00.500|4||billing=4,cpu=4,mem=8G,node=1
+14400|00:59:00|4|3500M|cpu=4,mem=8G,node=1
+14400|00:00:00.100|4|1000K|cpu=4,mem=8G,node=1
+`
+	report, err := parseEfficiency(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.CPUEfficiency != 25 {
+		t.Fatalf("expected 1h of CPU over 4h allocated = 25%%, got %.2f", report.CPUEfficiency)
+	}
+	if got := fmt.Sprintf("%.1f", report.MemEfficiency); got != "42.7" {
+		t.Fatalf("expected 3500M of 8G = 42.7%%, got %s", got)
+	}
+	if report.WallTime != "1:00:00" || report.CPUTime != "01:00:00.500" {
+		t.Fatalf("unexpected times %q %q", report.WallTime, report.CPUTime)
+	}
+	if report.String() != "CPU: 25.0% efficient  Mem: 42.7% efficient" {
+		t.Fatalf("unexpected summary %q", report.String())
+	}
+
+	if !report.CPUKnown || !report.MemKnown {
+		t.Fatalf("expected both efficiencies to be known")
+	}
+
+	neverRan, err := parseEfficiency("0|00:00:00|4||cpu=4,mem=8G,node=1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if neverRan.CPUKnown || neverRan.WallTime != "" {
+		t.Fatalf("expected CPU efficiency to be unknown with zero elapsed time, got %+v", neverRan)
+	}
+	noMem, err := parseEfficiency("3600|00:30:00|1|100M|cpu=1,mem=0,node=1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if noMem.MemKnown || noMem.CPUEfficiency != 50 {
+		t.Fatalf("expected unknown memory efficiency with mem=0, got %+v", noMem)
+	}
+	if noMem.String() != "CPU: 50.0% efficient  Mem: n/a" {
+		t.Fatalf("unexpected summary %q", noMem.String())
+	}
+
+	if _, err := parseEfficiency(""); err == nil {
+		t.Fatalf("expected an error without accounting data")
+	}
+	if v, ok := parseSlurmSize("16000", 'M'); !ok || v != 16000<<20 {
+		t.Fatalf("expected unitless sizes to use the default unit, got %d", v)
+	}
+}
+
+func TestFirstNode(t *testing.T) {
+	cases := map[string]string{
+		"":                   "",
+		"node01":             "node01",
+		"node01,node02":      "node01",
+		"gpu[03-05,09]":      "gpu03",
+		"gpu[03-05],cpu01":   "gpu03",
+		"rack[1,4]-n[01-02]": "rack1-n01",
+	}
+	for in, want := range cases {
+		if got := firstNode(in); got != want {
+			t.Fatalf("firstNode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSqueueArgsPartition(t *testing.T) {
+	args := squeueArgs(Config{Partition: "gpu,debug", User: "alice"})
+	want := []string{"-u", "alice", "-p", "gpu,debug", "--noheader"}
+	if !reflect.DeepEqual(args[:len(want)], want) {
+		t.Fatalf("squeueArgs = %q, want prefix %q", args, want)
+	}
+	if got := squeueArgs(defaultConfig()); strings.Contains(strings.Join(got, " "), "-p") {
+		t.Fatalf("expected no partition flag by default, got %q", got)
+	}
+	flags := []string{"--me", "-p", "long"}
+	if got := squeueCommandArgs(flags); !reflect.DeepEqual(got[:3], flags) || got[3] != "--noheader" {
+		t.Fatalf("squeueCommandArgs = %q", got)
+	}
+}
+
+func TestSbatchArgs(t *testing.T) {
+	args, err := sbatchArgs(submitRequest{
+		Script:    "jobs/train.sh",
+		JobName:   "train",
+		Partition: "gpu",
+		NTasks:    "4",
+		Time:      "2:00:00",
+		Mem:       "16G",
+		Extra:     " --gres=gpu:2  --array=0-3 ",
+	}, "hpc2")
+	if err != nil {
+		t.Fatalf("sbatchArgs: %v", err)
+	}
+	want := []string{"--cluster=hpc2", "--job-name=train", "--partition=gpu", "--ntasks=4", "--time=2:00:00", "--mem=16G", "--gres=gpu:2", "--array=0-3", "jobs/train.sh"}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("sbatchArgs = %q, want %q", args, want)
+	}
+
+	if args, _ := sbatchArgs(submitRequest{Script: "run.sh"}, ""); !reflect.DeepEqual(args, []string{"run.sh"}) {
+		t.Fatalf("expected only the script for an otherwise empty form, got %q", args)
+	}
+	if _, err := sbatchArgs(submitRequest{JobName: "x"}, ""); err == nil {
+		t.Fatalf("expected an error without a script")
+	}
+	if _, err := sbatchArgs(submitRequest{Script: "run.sh", NTasks: "four"}, ""); err == nil {
+		t.Fatalf("expected an error for a non-numeric ntasks")
+	}
+}
+
+func TestParseSubmitOutput(t *testing.T) {
+	cases := map[string]string{
+		"Submitted batch job 12345\n":                  "12345",
+		"Submitted batch job 777 on cluster hpc2\n":    "777",
+		"sbatch: warning: x\nSubmitted batch job 42\n": "42",
+	}
+	for in, want := range cases {
+		got, err := parseSubmitOutput(in)
+		if err != nil || got != want {
+			t.Fatalf("parseSubmitOutput(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := parseSubmitOutput("sbatch: error: invalid partition\n"); err == nil {
+		t.Fatalf("expected an error without a job ID")
+	}
+}
+
+func TestScontrolJobActionArgs(t *testing.T) {
+	if got := scontrolJobActionArgs("hold", "123", ""); !reflect.DeepEqual(got, []string{"hold", "123"}) {
+		t.Fatalf("hold args = %q", got)
+	}
+	if got := scontrolJobActionArgs("requeue", "77", ""); !reflect.DeepEqual(got, []string{"requeue", "77"}) {
+		t.Fatalf("requeue args = %q", got)
+	}
+	if got := scontrolJobActionArgs("release", "123_4", "hpc2"); !reflect.DeepEqual(got, []string{"--cluster=hpc2", "release", "123_4"}) {
+		t.Fatalf("release args = %q", got)
+	}
+}
+
+func TestScancelSignalArgs(t *testing.T) {
+	if got := scancelSignalArgs("55", "USR1", ""); !reflect.DeepEqual(got, []string{"--signal=USR1", "55"}) {
+		t.Fatalf("named signal args = %q", got)
+	}
+	if got := scancelSignalArgs("55_2", "10", "hpc2"); !reflect.DeepEqual(got, []string{"--cluster=hpc2", "--signal=10", "55_2"}) {
+		t.Fatalf("numeric signal args = %q", got)
+	}
+	for _, ok := range []string{"USR1", "TERM", "10"} {
+		if err := validateSignal(ok); err != nil {
+			t.Fatalf("validateSignal(%q): %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"", "usr1", "USR1; rm", "-9"} {
+		if err := validateSignal(bad); err == nil {
+			t.Fatalf("expected validateSignal(%q) to fail", bad)
+		}
+	}
+}
+
+func TestParseAccountingOutput(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		want   AccountingInfo
+	}{
+		{
+			"batch job with steps",
+			"8812|COMPLETED|0:0|04:00:12|||\n8812.batch|COMPLETED|0:0|04:00:12|1536M|2.5G\n8812.extern|COMPLETED|0:0|04:00:12|880K|4312K\n",
+			AccountingInfo{ExitCode: "0:0", CPUTime: "04:00:12", MaxRSS: "1536M", MaxVMSize: "2.5G"},
+		},
+		{
+			"failed job",
+			"9001|FAILED|1:0|00:01:30|||\n9001.batch|FAILED|1:0|00:01:30|2048K|10240K\n",
+			AccountingInfo{ExitCode: "1:0", CPUTime: "00:01:30", MaxRSS: "2048K", MaxVMSize: "10240K"},
+		},
+		{
+			"largest step wins",
+			"7|COMPLETED|0:0|10:00|||\n7.0|COMPLETED|0:0|05:00|3G|4G\n7.1|COMPLETED|0:0|05:00|512M|9G\n",
+			AccountingInfo{ExitCode: "0:0", CPUTime: "10:00", MaxRSS: "3G", MaxVMSize: "9G"},
+		},
+		{
+			"no steps yet",
+			"42|CANCELLED by 1000|0:15|00:00:00|||\n",
+			AccountingInfo{ExitCode: "0:15", CPUTime: "00:00:00"},
+		},
+	}
+	for _, tc := range cases {
+		got, err := parseAccountingOutput(tc.output)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+	if _, err := parseAccountingOutput("\n"); err == nil {
+		t.Fatalf("expected an error for empty sacct output")
+	}
+}
+
+func TestParseJobDetail(t *testing.T) {
+	running := `JobId=4821 JobName=train model
+   UserId=alice(1000) GroupId=alice(1000) MCS_label=N/A
+   Priority=4294901723 Nice=0 Account=ml QOS=normal
+   JobState=RUNNING Reason=None Dependency=(null)
+   Requeue=1 Restarts=0 BatchFlag=1 Reboot=0 ExitCode=0:0
+   RunTime=01:02:03 TimeLimit=04:00:00 TimeMin=N/A
+   SubmitTime=2024-01-15T12:00:00 EligibleTime=2024-01-15T12:00:00
+   AccrueTime=2024-01-15T12:00:00
+   StartTime=2024-01-15T13:30:02 EndTime=2024-01-15T17:30:02 Deadline=N/A
+   Partition=gpu AllocNode:Sid=login01:12345
+   NodeList=gpu[01-02]
+   BatchHost=gpu01
+   Command=/home/alice/my project/run.sh
+   WorkDir=/home/alice/my project
+   StdErr=/home/alice/my project/slurm_logs/4821.err
+   StdIn=/dev/null
+   StdOut=/home/alice/my project/slurm_logs/4821.out
+`
+	got, err := parseJobDetail(running)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := JobDetail{
+		JobID:      "4821",
+		Partition:  "gpu",
+		Account:    "ml",
+		WorkDir:    "/home/alice/my project",
+		StdOut:     "/home/alice/my project/slurm_logs/4821.out",
+		StdErr:     "/home/alice/my project/slurm_logs/4821.err",
+		NodeList:   "gpu[01-02]",
+		Reason:     "None",
+		SubmitTime: "2024-01-15T12:00:00",
+		StartTime:  "2024-01-15T13:30:02",
+		EndTime:    "2024-01-15T17:30:02",
+		Command:    "/home/alice/my project/run.sh",
+		Priority:   "4294901723",
+		TimeLimit:  "04:00:00",
+		Dependency: "(null)",
+	}
+	if got != want {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+
+	pending := `JobId=4822 ArrayJobId=4822 ArrayTaskId=1-4 JobName=eval
+   Priority=1000 Nice=0 Account=ml QOS=normal
+   JobState=PENDING Reason=Dependency Dependency=afterok:4821(unfulfilled)
+   RunTime=00:00:00 TimeLimit=1-00:00:00 TimeMin=N/A
+   SubmitTime=2024-01-15T12:05:00 EligibleTime=Unknown
+   StartTime=Unknown EndTime=Unknown Deadline=N/A
+   NodeList=(null)
+   Command=(null)
+   WorkDir=/scratch/eval
+`
+	got, err = parseJobDetail(pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.JobID != "4822" || got.Reason != "Dependency" || got.Dependency != "afterok:4821(unfulfilled)" {
+		t.Fatalf("pending job parsed as %+v", got)
+	}
+	if got.TimeLimit != "1-00:00:00" || got.StartTime != "Unknown" || got.StdOut != "" {
+		t.Fatalf("pending job parsed as %+v", got)
+	}
+
+	if _, err := parseJobDetail("slurm_load_jobs error: Invalid job id specified\n"); err == nil {
+		t.Fatalf("expected an error for unknown job")
+	}
+}
+
+func TestExpandNodeList(t *testing.T) {
+	cases := map[string][]string{
+		"node01":                {"node01"},
+		"node[01-03]":           {"node01", "node02", "node03"},
+		"gpu[1,3-4]":            {"gpu1", "gpu3", "gpu4"},
+		"gpu[08-10],cpu001":     {"gpu08", "gpu09", "gpu10", "cpu001"},
+		"rack[1-2]-n[1-2]":      {"rack1-n1", "rack1-n2", "rack2-n1", "rack2-n2"},
+		"a[1-2],b[7],c":         {"a1", "a2", "b7", "c"},
+		"node[5-3]":             {"node5-3"},
+		"":                      nil,
+		"gpu-[098-101].cluster": {"gpu-098.cluster", "gpu-099.cluster", "gpu-100.cluster", "gpu-101.cluster"},
+	}
+	for in, want := range cases {
+		if got := expandNodeList(in); !reflect.DeepEqual(got, want) {
+			t.Fatalf("expandNodeList(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestParseNodeDetail(t *testing.T) {
+	output := `NodeName=gpu01 Arch=x86_64 CoresPerSocket=16
+   CPUAlloc=8 CPUEfctv=32 CPUTot=32 CPULoad=7.95
+   AvailableFeatures=a100,ib
+   ActiveFeatures=a100,ib
+   Gres=gpu:a100:4(S:0-1)
+   NodeAddr=gpu01 NodeHostName=gpu01 Version=23.02.4
+   OS=Linux 5.14.0-284.11.1.el9_2.x86_64 #1 SMP PREEMPT_DYNAMIC Tue May 9 05:49:00 EDT 2023
+   RealMemory=512000 AllocMem=64000 FreeMem=401234 Sockets=2 Boards=1
+   State=MIXED ThreadsPerCore=1 TmpDisk=0 Weight=1 Owner=N/A MCS_label=N/A
+   Partitions=gpu
+   BootTime=2024-01-02T08:00:00 SlurmdStartTime=2024-01-02T08:01:00
+`
+	got, err := parseNodeDetail(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NodeDetail{
+		NodeName: "gpu01",
+		State:    "MIXED",
+		CPUTot:   "32",
+		CPULoad:  "7.95",
+		AllocMem: "64000",
+		FreeMem:  "401234",
+		OS:       "Linux 5.14.0-284.11.1.el9_2.x86_64 #1 SMP PREEMPT_DYNAMIC Tue May 9 05:49:00 EDT 2023",
+		Features: "a100,ib",
+		Gres:     "gpu:a100:4(S:0-1)",
+	}
+	if got != want {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+
+	old := "NodeName=cpu7 CPUTot=16 CPULoad=N/A Features=(null)\n   Gres=(null)\n   State=DOWN* ThreadsPerCore=1\n"
+	got, err = parseNodeDetail(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Features != "(null)" || got.State != "DOWN*" || got.CPULoad != "N/A" {
+		t.Fatalf("old-format node parsed as %+v", got)
+	}
+
+	if _, err := parseNodeDetail("Node nosuch not found\n"); err == nil {
+		t.Fatalf("expected an error for unknown node")
+	}
+}
+
+func TestScancelBatchArgs(t *testing.T) {
+	if got := scancelBatchArgs([]string{"1", "2_3"}, ""); !reflect.DeepEqual(got, []string{"1", "2_3"}) {
+		t.Fatalf("got %v", got)
+	}
+	if got := scancelBatchArgs([]string{"1", "2"}, "gpu"); !reflect.DeepEqual(got, []string{"--cluster=gpu", "1", "2"}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestParseSqueueOutputPartition(t *testing.T) {
+	jobs := parseSqueueOutput("7|train|RUNNING|1:00|2:00|node01|N/A|alice|gpu\n")
+	if len(jobs) != 1 || jobs[0].Partition != "gpu" || jobs[0].User != "alice" {
+		t.Fatalf("expected the partition field parsed, got %+v", jobs)
+	}
+
+	jobs = parseSqueueOutput("8|eval|PENDING|0:00|1:00|\n")
+	if len(jobs) != 1 || jobs[0].Partition != "" {
+		t.Fatalf("expected no partition without the field, got %+v", jobs)
+	}
+}
+
+func TestParseSqueueOutputReason(t *testing.T) {
+	cases := map[string]string{
+		"11|train|PENDING|0:00|1:00:00||N/A|alice|gpu|(Priority)":                                             "Priority",
+		"12|train|PENDING|0:00|1:00:00||N/A|alice|gpu|(ReqNodeNotAvail, UnavailableNodes:gpu[01-02])":         "ReqNodeNotAvail, UnavailableNodes:gpu[01-02]",
+		"13|train|PENDING|0:00|1:00:00||N/A|alice|gpu|(Nodes required for job are DOWN, DRAINED or reserved)": "Nodes required for job are DOWN, DRAINED or reserved",
+		"14|train|RUNNING|1:00|1:00:00|gpu01|N/A|alice|gpu|gpu01":                                             "",
+		"15|train|PENDING|0:00|1:00:00||N/A|alice|gpu":                                                        "",
+	}
+	for line, want := range cases {
+		jobs := parseSqueueOutput(line + "\n")
+		if len(jobs) != 1 {
+			t.Fatalf("expected one job from %q, got %+v", line, jobs)
+		}
+		if jobs[0].Reason != want {
+			t.Fatalf("reason from %q = %q, want %q", line, jobs[0].Reason, want)
+		}
+	}
+}
+
+func TestParseSqueueOutputAccountQOS(t *testing.T) {
+	jobs := parseSqueueOutput("21|train|RUNNING|1:00|4:00:00|gpu01|gres/gpu:1|alice|gpu|gpu01|ml-lab|high\n" +
+		"22|eval|PENDING|0:00|1:00:00||N/A|bob|cpu|(Priority)\n")
+	if len(jobs) != 2 {
+		t.Fatalf("expected two jobs, got %+v", jobs)
+	}
+	if jobs[0].Account != "ml-lab" || jobs[0].QOS != "high" || jobs[0].Partition != "gpu" {
+		t.Fatalf("expected account and QOS parsed, got %+v", jobs[0])
+	}
+	if jobs[1].Account != "" || jobs[1].QOS != "" || jobs[1].Reason != "Priority" {
+		t.Fatalf("expected no account without the fields, got %+v", jobs[1])
+	}
+}
+
+func TestParseSqueueOutputPriority(t *testing.T) {
+	jobs := parseSqueueOutput("31|eval|PENDING|0:00|1:00:00||N/A|bob|cpu|(Priority)|ml|normal|4294901723\n" +
+		"32|eval|PENDING|0:00|1:00:00||N/A|bob|cpu|(Priority)|ml|normal\n")
+	if len(jobs) != 2 || jobs[0].Priority != 4294901723 || jobs[1].Priority != 0 {
+		t.Fatalf("unexpected priorities %+v", jobs)
+	}
+}
+
+func TestParseSqueueOutputPipeInName(t *testing.T) {
+	jobs := parseSqueueOutput("33|sweep | lr=0.1|b|RUNNING|0:05|1:00:00|node01|N/A|bob|gpu|None|ml|normal|100\n")
+	if len(jobs) != 1 {
+		t.Fatalf("expected the job to be kept, got %+v", jobs)
+	}
+	j := jobs[0]
+	if j.ID != "33" || j.Name != "sweep | lr=0.1|b" || j.State != "RUNNING" || j.Nodes != "node01" || j.Priority != 100 {
+		t.Fatalf("expected the trailing columns to stay anchored, got %+v", j)
+	}
+}
+
+func TestSqueueFlagsAccount(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Account = "ml-lab,physics"
+	if got := squeueFlags(cfg); !reflect.DeepEqual(got, []string{"--me", "-A", "ml-lab,physics"}) {
+		t.Fatalf("unexpected squeue flags %v", got)
+	}
+}
+
+func TestParseGRES(t *testing.T) {
+	cases := []struct {
+		in   string
+		want GRESInfo
+	}{
+		{"gpu:1", GRESInfo{Type: "gpu", Count: 1}},
+		{"gpu:A100:2", GRESInfo{Type: "gpu", Model: "A100", Count: 2}},
+		{"gres/gpu:a100:4(IDX:0-3)", GRESInfo{Type: "gpu", Model: "a100", Count: 4}},
+		{"gres:gpu:2", GRESInfo{Type: "gpu", Count: 2}},
+		{"gres/gpu=2", GRESInfo{Type: "gpu", Count: 2}},
+		{"gres/gpu:v100=1", GRESInfo{Type: "gpu", Model: "v100", Count: 1}},
+		{"gpu", GRESInfo{Type: "gpu", Count: 1}},
+		{"mps:100", GRESInfo{Type: "mps", Count: 100}},
+		{"shard:l40s:3", GRESInfo{Type: "shard", Model: "l40s", Count: 3}},
+		{"cpu=8", GRESInfo{}},
+		{"(null)", GRESInfo{}},
+		{"N/A", GRESInfo{}},
+		{"", GRESInfo{}},
+	}
+	for _, tc := range cases {
+		if got := parseGRES(tc.in); got != tc.want {
+			t.Fatalf("parseGRES(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestTimeUsedFraction(t *testing.T) {
+	cases := []struct {
+		elapsed, limit string
+		want           float64
+	}{
+		{"30:00", "1:00:00", 0.5},
+		{"57:00", "1:00:00", 0.95},
+		{"1-00:00:00", "2-00:00:00", 0.5},
+		{"10:00", "UNLIMITED", 0},
+		{"10:00", "NOT_SET", 0},
+		{"10:00", "0:00", 0},
+		{"bogus", "1:00:00", 0},
+	}
+	for _, tc := range cases {
+		if got := timeUsedFraction(tc.elapsed, tc.limit); math.Abs(got-tc.want) > 1e-9 {
+			t.Fatalf("timeUsedFraction(%q, %q) = %v, want %v", tc.elapsed, tc.limit, got, tc.want)
+		}
+	}
+}
+
+func TestParseSstat(t *testing.T) {
+	output := "00:00:00|1024K|0|0\n" + // extern step
+		"01:30:00|12902716K|2.50M|1.20G\n" + // batch step doing the work
+		"00:10:00|204800K|10K|0\n"
+	stats, err := parseSstat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LiveStats{AveCPU: "01:30:00", MaxRSS: "12902716K", AveDiskRead: "2.50M", AveDiskWrite: "1.20G"}
+	if stats != want {
+		t.Fatalf("got %+v, want %+v", stats, want)
+	}
+	if got := stats.Summary("1:43:27"); got != "CPU: 87% RSS: 12 GB" {
+		t.Fatalf("unexpected summary %q", got)
+	}
+	if got := (LiveStats{AveCPU: "05:12.345", MaxRSS: ""}).Summary("bogus"); got != "CPU: 05:12.345 RSS: " {
+		t.Fatalf("expected raw values when elapsed is unknown, got %q", got)
+	}
+
+	for _, bad := range []string{"", "\n", "00:01:00|1K\n"} {
+		if _, err := parseSstat(bad); err == nil {
+			t.Fatalf("parseSstat(%q): expected an error", bad)
+		}
+	}
+}
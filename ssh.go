@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+type LogReader interface {
+	Size(path string) (int64, error)
+	Read(path string, offset int64) ([]byte, error)
+}
+
+const (
+	sshDialTimeout    = 10 * time.Second
+	sshMinDialBackoff = 2 * time.Second
+	sshMaxDialBackoff = time.Minute
+)
+
+type sshLogReader struct {
+	addr string
+	user string
+
+	mu     sync.Mutex
+	client *ssh.Client
+
+	// After a failed dial the host counts as unavailable until nextDial;
+	// the wait doubles up to sshMaxDialBackoff while dials keep failing.
+	now      func() time.Time
+	dialErr  error
+	nextDial time.Time
+	backoff  time.Duration
+}
+
+func newSSHLogReader(host, user string) *sshLogReader {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "22")
+	}
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	return &sshLogReader{addr: host, user: user, now: time.Now}
+}
+
+func (r *sshLogReader) Size(path string) (int64, error) {
+	out, err := r.run("stat -c %s " + shellQuote(path))
+	if err != nil {
+		if strings.Contains(string(out), "No such file") {
+			return 0, os.ErrNotExist
+		}
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+}
+
+func (r *sshLogReader) Read(path string, offset int64) ([]byte, error) {
+	out, err := r.run(fmt.Sprintf("tail -c +%d %s", offset+1, shellQuote(path)))
+	if err != nil && strings.Contains(string(out), "No such file") {
+		return nil, os.ErrNotExist
+	}
+	return out, err
+}
+
+func (r *sshLogReader) run(cmd string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if r.client == nil {
+			client, err := r.connect()
+			if err != nil {
+				return nil, err
+			}
+			r.client = client
+		}
+		session, err := r.client.NewSession()
+		if err == nil {
+			defer session.Close()
+			var stderr strings.Builder
+			session.Stderr = &stderr
+			out, err := session.Output(cmd)
+			if err != nil {
+				return []byte(stderr.String()), fmt.Errorf("ssh %s: %s: %w", r.addr, cmd, err)
+			}
+			return out, nil
+		}
+		// The connection dropped (io.EOF) or went stale; redial once.
+		r.client.Close()
+		r.client = nil
+		if attempt > 0 || !(errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)) {
+			return nil, fmt.Errorf("ssh %s: %w", r.addr, err)
+		}
+	}
+}
+
+// connect dials unless the host failed recently, in which case it returns
+// the last dial error without touching the network.
+func (r *sshLogReader) connect() (*ssh.Client, error) {
+	now := r.now()
+	if r.dialErr != nil && now.Before(r.nextDial) {
+		return nil, fmt.Errorf("remote unavailable, retrying in %s: %w", r.nextDial.Sub(now).Round(time.Second), r.dialErr)
+	}
+	client, err := r.dial()
+	if err != nil {
+		r.backoff *= 2
+		if r.backoff < sshMinDialBackoff {
+			r.backoff = sshMinDialBackoff
+		} else if r.backoff > sshMaxDialBackoff {
+			r.backoff = sshMaxDialBackoff
+		}
+		r.dialErr, r.nextDial = err, r.now().Add(r.backoff)
+		return nil, err
+	}
+	r.dialErr, r.backoff = nil, 0
+	return client, nil
+}
+
+func (r *sshLogReader) dial() (*ssh.Client, error) {
+	home, _ := os.UserHomeDir()
+	hostKeys, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("ssh known_hosts: %w", err)
+	}
+	auth, agentConn := sshAuthMethods(home)
+	if agentConn != nil {
+		// The agent only signs during the handshake.
+		defer agentConn.Close()
+	}
+	config := &ssh.ClientConfig{
+		User:            r.user,
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+		Timeout:         sshDialTimeout,
+	}
+	client, err := ssh.Dial("tcp", r.addr, config)
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: %w", r.addr, err)
+	}
+	return client, nil
+}
+
+// sshAuthMethods returns the agent and key file auth methods; the caller
+// closes the agent connection, if any, once the handshake is done.
+func sshAuthMethods(home string) ([]ssh.AuthMethod, io.Closer) {
+	var methods []ssh.AuthMethod
+	var agentConn io.Closer
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			agentConn = conn
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	var signers []ssh.Signer
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		key, err := os.ReadFile(filepath.Join(home, ".ssh", name))
+		if err != nil {
+			continue
+		}
+		if signer, err := ssh.ParsePrivateKey(key); err == nil {
+			signers = append(signers, signer)
+		}
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	return methods, agentConn
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
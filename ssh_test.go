@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSHLogReaderDialBackoff(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // no known_hosts, so every dial fails fast
+	t.Setenv("SSH_AUTH_SOCK", "")
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	r := newSSHLogReader("cluster.example", "alice")
+	r.now = func() time.Time { return now }
+
+	if _, err := r.Size("/remote/job.out"); err == nil || strings.Contains(err.Error(), "remote unavailable") {
+		t.Fatalf("expected the first call to dial and fail, got %v", err)
+	}
+	if _, err := r.Size("/remote/job.out"); err == nil || !strings.Contains(err.Error(), "remote unavailable") {
+		t.Fatalf("expected calls during the backoff to skip dialing, got %v", err)
+	}
+	if r.backoff != sshMinDialBackoff {
+		t.Fatalf("expected a %s backoff, got %s", sshMinDialBackoff, r.backoff)
+	}
+
+	now = now.Add(sshMinDialBackoff)
+	if _, err := r.Read("/remote/job.out", 0); err == nil || strings.Contains(err.Error(), "remote unavailable") {
+		t.Fatalf("expected a redial after the backoff, got %v", err)
+	}
+	if r.backoff != 2*sshMinDialBackoff {
+		t.Fatalf("expected the backoff to double, got %s", r.backoff)
+	}
+	for range 10 {
+		now = now.Add(sshMaxDialBackoff)
+		r.Size("/remote/job.out")
+	}
+	if r.backoff != sshMaxDialBackoff {
+		t.Fatalf("expected the backoff to cap at %s, got %s", sshMaxDialBackoff, r.backoff)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var submitFields = []struct {
+	label       string
+	placeholder string
+}{
+	{"script", "path/to/job.sh"},
+	{"job name", "from script"},
+	{"partition", "cluster default"},
+	{"ntasks", "1"},
+	{"time", "e.g. 1:00:00"},
+	{"mem", "e.g. 8G"},
+	{"extra flags", "e.g. --gres=gpu:1 --array=0-9"},
+}
+
+type submitForm struct {
+	inputs []textinput.Model
+	focus  int
+}
+
+func newSubmitForm() *submitForm {
+	f := &submitForm{inputs: make([]textinput.Model, len(submitFields))}
+	for i, field := range submitFields {
+		input := textinput.New()
+		input.Prompt = ""
+		input.Placeholder = field.placeholder
+		input.Cursor.SetMode(cursor.CursorStatic)
+		f.inputs[i] = input
+	}
+	f.inputs[0].Focus()
+	return f
+}
+
+func (f *submitForm) request() submitRequest {
+	value := func(i int) string { return strings.TrimSpace(f.inputs[i].Value()) }
+	return submitRequest{
+		Script:    value(0),
+		JobName:   value(1),
+		Partition: value(2),
+		NTasks:    value(3),
+		Time:      value(4),
+		Mem:       value(5),
+		Extra:     value(6),
+	}
+}
+
+func (f *submitForm) moveFocus(delta int) {
+	f.inputs[f.focus].Blur()
+	f.focus = (f.focus + delta + len(f.inputs)) % len(f.inputs)
+	f.inputs[f.focus].Focus()
+}
+
+func (m *model) openSubmitForm() {
+	m.submit = newSubmitForm()
+}
+
+func (m *model) handleSubmitKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.submit = nil
+		m.setStatus("submission aborted", "244")
+		return nil
+	case "tab", "down":
+		m.submit.moveFocus(1)
+		return nil
+	case "shift+tab", "up":
+		m.submit.moveFocus(-1)
+		return nil
+	case "enter":
+		args, err := sbatchArgs(m.submit.request(), m.cfg.Cluster)
+		if err != nil {
+			m.setError(err.Error())
+			return nil
+		}
+		m.submit = nil
+		m.setStatus("submitting job...", "244")
+		return submitJobCmd(args)
+	}
+	var cmd tea.Cmd
+	m.submit.inputs[m.submit.focus], cmd = m.submit.inputs[m.submit.focus].Update(msg)
+	return cmd
+}
+
+func (m model) renderSubmitForm(base string) string {
+	if m.width <= 0 || m.height <= 0 {
+		return base
+	}
+
+	modalWidth := min(72, max(40, m.width-8))
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Render("Submit Job (sbatch)")
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	focusedLabel := lipgloss.NewStyle().Foreground(lipgloss.Color("69")).Bold(true)
+
+	lines := []string{title, ""}
+	for i, field := range submitFields {
+		style := labelStyle
+		marker := "  "
+		if i == m.submit.focus {
+			style = focusedLabel
+			marker = "> "
+		}
+		label := style.Render(fmt.Sprintf("%s%-12s", marker, field.label))
+		lines = append(lines, label+" "+m.submit.inputs[i].View())
+	}
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("252")).Render("[tab/↑↓] field  [enter] submit  [esc] abort")
+	lines = append(lines, "", hint)
+
+	modal := lipgloss.NewStyle().
+		Width(modalWidth).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("42")).
+		Background(lipgloss.Color("236")).
+		Foreground(lipgloss.Color("255")).
+		Render(strings.Join(lines, "\n"))
+
+	dimmed := lipgloss.NewStyle().Faint(true).Render(base)
+	return centerOverlay(dimmed, modal, m.width, m.height)
+}
@@ -1,10 +1,20 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -14,17 +24,69 @@ import (
 const (
 	uiTickInterval   = 250 * time.Millisecond
 	jobsRefreshEvery = 5 * time.Second
+	statusTTL        = 5 * time.Second
+	errorStatusTTL   = 30 * time.Second
+	flashDuration    = 1 * time.Second
 )
 
+var (
+	selectedRowStyle = lipgloss.NewStyle().Background(lipgloss.Color("238")).Foreground(lipgloss.Color("255"))
+	gpuBadgeStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+	columnSepStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	flashRowStyle    = lipgloss.NewStyle().Background(lipgloss.Color("196")).Foreground(lipgloss.Color("255"))
+	markedRowStyle   = lipgloss.NewStyle().Background(lipgloss.Color("24"))
+)
+
+func applyTheme(name string) {
+	t, ok := themes[name]
+	if !ok {
+		t = themes["default"]
+	}
+	selectedRowStyle = lipgloss.NewStyle().Background(lipgloss.Color(t.selectedBg)).Foreground(lipgloss.Color(t.selectedFg))
+	gpuBadgeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.gpuBadge))
+	columnSepStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.columnSep))
+	flashRowStyle = lipgloss.NewStyle().Background(lipgloss.Color(t.flashBg)).Foreground(lipgloss.Color("255"))
+	markedRowStyle = lipgloss.NewStyle().Background(lipgloss.Color(t.markedBg))
+}
+
 type model struct {
 	width  int
 	height int
 
+	cfg       Config
+	clusterTZ *time.Location
+	now       func() time.Time
+	slurm     slurmClient
+
 	store JobStore
 	jobs  []Job
 
-	selectedIdx int
-	selectedID  string
+	selectedIdx   int
+	selectedID    string
+	jobListOffset int // first job row rendered into vpJobs
+
+	selected map[string]bool // jobs marked with space for batch actions
+
+	filterStates []string
+	filterName   string
+
+	sortField string // one of sortFields, or "" for first-seen order
+	sortAsc   bool
+
+	stateFilter string // cycled with F through stateFilterCycle
+
+	showLineNumbers bool
+	colorizeLog     bool
+
+	logSearchQuery    string
+	logSearchMatches  []int // line indices in the focused log viewport
+	logSearchMatchIdx int
+	logSearchTyping   bool
+	logSearchInput    textinput.Model
+
+	jobSearch       string // highlights matching jobs, dims the rest
+	jobSearchActive bool   // the / input has focus
+	jobSearchInput  textinput.Model
 
 	focusArea int // 0 jobs, 1 stdout, 2 stderr/merged
 
@@ -36,21 +98,72 @@ type model struct {
 
 	outFollower *logFollower
 	errFollower *logFollower
+	logReader   LogReader
 	mergedBuf   mergedBuffer
+	logOutPath  string
+	logErrPath  string
+	logOutSize  int64
+	logErrSize  int64
+	logOutLines int
+	logErrLines int
+
+	remotePolling      bool // a remoteLogsCmd is in flight
+	checkpointsResumed bool
+
+	unreadOutLines int
+	unreadErrLines int
 
-	mergedMode bool
-	follow     bool
+	numBuf   string
+	pendingG bool
 
-	lastJobFetch       time.Time
-	statusText         string
-	statusColor        string
-	err                error
-	cancelConfirm      bool
-	cancelConfirmJobID string
+	cpuSamples []float64
+	memSamples []float64
+
+	blinkTicks int
+	blinkState bool
+
+	mergedMode   bool
+	followOut    bool
+	followErr    bool
+	followMerged bool
+
+	jobsRefreshEvery time.Duration
+	hiddenPartition  string // --partition filter while toggled off with p
+
+	lastJobFetch time.Time
+	isRefreshing bool
+	flashUntil   time.Time
+	flashJobID   string
+	statusQueue  []statusEntry
+	err          error
+	clusterMeta  ClusterMeta
+	modal        *infoModal
+	submit       *submitForm
+	signal       *signalPrompt
+
+	efficiencyRequested map[string]bool
+	sstatPolledAt       map[string]time.Time
+	logPathsRequested   map[string]bool
+
+	sinfoFetching  bool
+	lastSinfoFetch time.Time
+
+	pending *pendingAction // job action awaiting y/N confirmation
 
 	outContentCache    string
 	errContentCache    string
 	mergedContentCache string
+
+	globalSearch        bool
+	globalSearchInput   textinput.Model
+	globalSearchQuery   string
+	globalSearchResults []SearchResult
+	globalSearchIdx     int
+	globalSearchOffset  int // first result row shown
+	pendingJump         *SearchResult
+
+	splitRatio    float64 // share of the body height given to the jobs pane
+	fullscreenLog bool    // the log panes take the whole body; Z toggles
 }
 
 type jobMsg []Job
@@ -61,13 +174,88 @@ type statusMsg struct {
 	color string
 }
 
-func initialModel() model {
-	return model{
-		store:       NewJobStore(),
-		selectedIdx: 0,
-		focusArea:   0,
-		follow:      true,
-		mergedBuf:   newMergedBuffer(renderLineLimit),
+type SearchResult struct {
+	JobID   string
+	Stream  streamLabel
+	LineIdx int
+	Preview string
+}
+
+type globalSearchMsg struct {
+	query   string
+	results []SearchResult
+}
+
+type statusEntry struct {
+	text      string
+	color     string
+	expiresAt time.Time
+}
+
+func initialModel(cfg Config) model {
+	input := textinput.New()
+	input.Prompt = "search logs: "
+	input.Placeholder = "text to find in stdout/stderr of all jobs"
+	input.Cursor.SetMode(cursor.CursorStatic)
+
+	m := model{
+		cfg:               cfg,
+		clusterTZ:         time.Local,
+		now:               time.Now,
+		slurm:             execSlurmClient{cluster: cfg.Cluster},
+		store:             NewJobStore(),
+		filterStates:      cfg.FilterStates,
+		filterName:        cfg.FilterName,
+		selectedIdx:       0,
+		selected:          make(map[string]bool),
+		focusArea:         max(0, min(cfg.InitialFocusArea, 2)),
+		mergedMode:        cfg.InitialMergedMode,
+		followOut:         cfg.InitialFollow,
+		followErr:         cfg.InitialFollow,
+		followMerged:      cfg.InitialFollow,
+		jobsRefreshEvery:  cfg.RefreshInterval,
+		isRefreshing:      true,
+		mergedBuf:         newMergedBuffer(renderLineLimit),
+		globalSearchInput: input,
+		splitRatio:        defaultSplitRatio,
+	}
+	if m.jobsRefreshEvery <= 0 {
+		m.jobsRefreshEvery = jobsRefreshEvery
+	}
+	applyTheme(cfg.Theme)
+	if cfg.RemoteLogHost != "" {
+		m.logReader = newSSHLogReader(cfg.RemoteLogHost, cfg.RemoteLogUser)
+	}
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			m.setError(fmt.Sprintf("unknown timezone %q, using local time", cfg.Timezone))
+		} else {
+			m.clusterTZ = loc
+		}
+	}
+	if cfg.StorePath != "" {
+		if err := m.store.Load(cfg.StorePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			m.setError(fmt.Sprintf("load job store: %v", err))
+		}
+	}
+	return m
+}
+
+// saveStoreCmd persists the job store; it runs before tea.Quit so dismissed
+// jobs stay dismissed next session. The records are copied here because
+// Update keeps writing the store's maps while the command runs.
+func (m *model) saveStoreCmd() tea.Cmd {
+	if m.cfg.StorePath == "" {
+		return nil
+	}
+	m.store.PruneDismissed(m.now(), dismissedStoreRetention)
+	records, path := m.store.AllRecords(), m.cfg.StorePath
+	return func() tea.Msg {
+		if err := saveRecords(path, records); err != nil {
+			return statusMsg{text: fmt.Sprintf("save job store: %v", err), color: "196"}
+		}
+		return nil
 	}
 }
 
@@ -77,9 +265,9 @@ func waitForTick() tea.Cmd {
 	})
 }
 
-func fetchJobsCmd() tea.Cmd {
+func fetchJobsCmd(cfg Config) tea.Cmd {
 	return func() tea.Msg {
-		jobs, err := checkSlurm()
+		jobs, err := checkSlurmCached(cfg.RefreshInterval/2, cfg)
 		if err != nil {
 			return errMsg(err)
 		}
@@ -87,8 +275,203 @@ func fetchJobsCmd() tea.Cmd {
 	}
 }
 
+type efficiencyMsg struct {
+	jobID  string
+	report EfficiencyReport
+	err    error
+}
+
+func fetchEfficiencyCmd(jobID, cluster string) tea.Cmd {
+	return func() tea.Msg {
+		report, err := computeEfficiency(jobID, cluster)
+		return efficiencyMsg{jobID: jobID, report: report, err: err}
+	}
+}
+
+func efficiencyColor(pct float64) lipgloss.Color {
+	switch {
+	case pct >= 80:
+		return lipgloss.Color("42")
+	case pct >= 50:
+		return lipgloss.Color("220")
+	default:
+		return lipgloss.Color("196")
+	}
+}
+
+func renderEfficiency(r EfficiencyReport) string {
+	label := lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	value := func(known bool, pct float64, text string) string {
+		if !known {
+			return label.Render(text)
+		}
+		return lipgloss.NewStyle().Foreground(efficiencyColor(pct)).Render(text)
+	}
+	return label.Render("CPU: ") + value(r.CPUKnown, r.CPUEfficiency, r.cpuText()) +
+		label.Render("  Mem: ") + value(r.MemKnown, r.MemEfficiency, r.memText())
+}
+
+func (m *model) maybeFetchEfficiency() tea.Cmd {
+	job, ok := m.selectedJob()
+	if !ok || !isTerminalState(job.State) || m.efficiencyRequested[job.ID] {
+		return nil
+	}
+	if rec, ok := m.store.Record(job.ID); !ok || rec.Efficiency != nil {
+		return nil
+	}
+	return m.requestEfficiency(job.ID)
+}
+
+func (m *model) requestEfficiency(jobID string) tea.Cmd {
+	if m.efficiencyRequested == nil {
+		m.efficiencyRequested = make(map[string]bool)
+	}
+	m.efficiencyRequested[jobID] = true
+	return fetchEfficiencyCmd(jobID, m.cfg.Cluster)
+}
+
+const sstatInterval = 30 * time.Second
+
+// maybeFetchSstat polls live usage of the selected running job at most once
+// per sstatInterval.
+func (m *model) maybeFetchSstat() tea.Cmd {
+	job, ok := m.selectedJob()
+	if !ok || job.State != "RUNNING" || job.IsArrayGroup() {
+		return nil
+	}
+	now := m.now()
+	if last, ok := m.sstatPolledAt[job.ID]; ok && now.Sub(last) < sstatInterval {
+		return nil
+	}
+	if m.sstatPolledAt == nil {
+		m.sstatPolledAt = make(map[string]time.Time)
+	}
+	m.sstatPolledAt[job.ID] = now
+	return fetchSstatCmd(job.ID, m.cfg.Cluster)
+}
+
+type clusterMetaMsg struct {
+	meta ClusterMeta
+	err  error
+}
+
+func fetchClusterMetaCmd(cluster string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), scontrolTimeout)
+		defer cancel()
+		config, err := scontrolShowConfig(ctx, cluster)
+		if err != nil {
+			return clusterMetaMsg{err: err}
+		}
+		return clusterMetaMsg{meta: newClusterMeta(config)}
+	}
+}
+
+func (m *model) startRefresh() tea.Cmd {
+	m.isRefreshing = true
+	return fetchJobsCmd(m.cfg)
+}
+
+// forceRefresh skips the squeue cache so manual refreshes and the ones
+// after job actions see the change right away.
+func (m *model) forceRefresh() tea.Cmd {
+	jobsCache.invalidate()
+	return m.startRefresh()
+}
+
+func (m model) timeUntilRefresh() time.Duration {
+	left := m.jobsRefreshEvery - m.now().Sub(m.lastJobFetch)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+func (m model) refreshIndicator() string {
+	if m.isRefreshing {
+		frame := spinnerFrames[int(m.now().UnixMilli()/100)%len(spinnerFrames)]
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Render(frame + " Refreshing...")
+	}
+	every := "/" + shortDuration(m.jobsRefreshEvery)
+	left := m.timeUntilRefresh()
+	if left < time.Second {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("220")).Render("Next: <1s" + every)
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(fmt.Sprintf("Next: %ds%s", int(left.Round(time.Second)/time.Second), every))
+}
+
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if d%time.Minute == 0 {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if d%time.Hour == 0 {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// globalSearchCmd searches the logs of jobs. paths holds each job's
+// resolved stdout/stderr paths; jobs missing from it use the configured
+// pattern.
+func globalSearchCmd(query string, jobs []Job, paths map[string][2]string, cfg Config, reader LogReader) tea.Cmd {
+	return func() tea.Msg {
+		needle := strings.ToLower(query)
+		var results []SearchResult
+		for _, job := range jobs {
+			outPath, errPath := logPaths(job, cfg)
+			if p, ok := paths[job.ID]; ok {
+				outPath, errPath = p[0], p[1]
+			}
+			streams := []struct {
+				label streamLabel
+				path  string
+			}{
+				{streamOut, outPath},
+				{streamErr, errPath},
+			}
+			for _, stream := range streams {
+				f := newLogFollowerWithReader(stream.path, reader)
+				f.tailBytes = cfg.InitialTailBytes
+				f.tailLines = cfg.InitialTailLines
+				f.renderer.tabWidth = cfg.TabWidth
+				chunk, err := f.poll(stream.label)
+				if err != nil || chunk.Missing {
+					continue
+				}
+				for i, line := range strings.Split(f.content(0), "\n") {
+					if strings.Contains(strings.ToLower(line), needle) {
+						results = append(results, SearchResult{
+							JobID:   job.ID,
+							Stream:  stream.label,
+							LineIdx: i,
+							Preview: strings.TrimSpace(line),
+						})
+					}
+				}
+			}
+		}
+		return globalSearchMsg{query: query, results: results}
+	}
+}
+
 func (m model) Init() tea.Cmd {
-	return tea.Batch(fetchJobsCmd(), waitForTick())
+	return tea.Batch(fetchJobsCmd(m.cfg), fetchClusterMetaCmd(m.cfg.Cluster), waitForTick())
+}
+
+func isAlertState(state string) bool {
+	switch state {
+	case "FAILED", "CANCELLED", "TIMEOUT", "OUT_OF_MEMORY":
+		return true
+	default:
+		return false
+	}
+}
+
+func (m model) flashing() bool {
+	return m.flashJobID != "" && m.now().Before(m.flashUntil)
 }
 
 func getJobColor(state string) lipgloss.Color {
@@ -106,6 +489,71 @@ func getJobColor(state string) lipgloss.Color {
 	}
 }
 
+func (m *model) setFocus(area int) {
+	m.focusArea = area
+	switch area {
+	case 1:
+		m.unreadOutLines = 0
+	case 2:
+		m.unreadErrLines = 0
+	}
+}
+
+func (m *model) setFollow(on bool) {
+	m.followOut = on
+	m.followErr = on
+	m.followMerged = on
+}
+
+func followWord(on bool) string {
+	if on {
+		return "FOLLOW"
+	}
+	return "PAUSED"
+}
+
+func (m model) paneFollowIndicator() string {
+	style := func(text string, on bool) string {
+		color := lipgloss.Color("220")
+		if on {
+			color = lipgloss.Color("42")
+		}
+		return lipgloss.NewStyle().Foreground(color).Render(text)
+	}
+	if m.mergedMode {
+		return style("MERGED:"+followWord(m.followMerged), m.followMerged)
+	}
+	if m.followOut == m.followErr {
+		return style("ALL:"+followWord(m.followOut), m.followOut)
+	}
+	return style("OUT:"+followWord(m.followOut), m.followOut) + " " + style("ERR:"+followWord(m.followErr), m.followErr)
+}
+
+// following reports whether the focused log pane follows; with the jobs
+// pane focused both visible log panes have to.
+func (m model) following() bool {
+	switch {
+	case m.mergedMode:
+		return m.followMerged
+	case m.focusArea == 1:
+		return m.followOut
+	case m.focusArea == 2:
+		return m.followErr
+	}
+	return m.followOut && m.followErr
+}
+
+func (m model) followDot() string {
+	if !m.following() {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render("○")
+	}
+	dot := "○"
+	if m.blinkState {
+		dot = "●"
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render(dot)
+}
+
 func updateViewportContent(vp *viewport.Model, content string, cache *string, follow bool) {
 	if *cache == content {
 		return
@@ -135,6 +583,12 @@ func (m *model) selectedJob() (Job, bool) {
 }
 
 func (m *model) ensureSelectionByID() {
+	prev := m.selectedIdx
+	defer func() {
+		if m.selectedIdx != prev {
+			m.keepSelectionVisible()
+		}
+	}()
 	if m.selectedID == "" {
 		if job, ok := m.selectedJob(); ok {
 			m.selectedID = job.ID
@@ -158,189 +612,1649 @@ func (m *model) ensureSelectionByID() {
 	m.selectedID = m.jobs[m.selectedIdx].ID
 }
 
-func (m *model) switchToJob(job Job) {
-	outPath := fmt.Sprintf("slurm_logs/%s.out", job.ID)
-	errPath := fmt.Sprintf("slurm_logs/%s.err", job.ID)
-
-	if m.outFollower == nil {
-		m.outFollower = newLogFollower(outPath)
-	} else {
-		m.outFollower.reset(outPath)
+func logPaths(job Job, cfg Config) (outPath, errPath string) {
+	user := job.User
+	if user == "" {
+		user = os.Getenv("USER")
 	}
-	if m.errFollower == nil {
-		m.errFollower = newLogFollower(errPath)
-	} else {
-		m.errFollower.reset(errPath)
+	base := expandLogPattern(filepath.Join(cfg.LogDir, cfg.LogPattern), job, user)
+	outPath, errPath = base+".out", base+".err"
+	if cfg.LogOutPattern != "" {
+		outPath = expandLogPattern(cfg.LogOutPattern, job, user)
 	}
-	m.mergedBuf.reset()
-	m.follow = true
-
-	if m.vpReady {
-		m.outContentCache = "\x00"
-		m.errContentCache = "\x00"
-		m.mergedContentCache = "\x00"
-		updateViewportContent(&m.vpOut, "", &m.outContentCache, true)
-		updateViewportContent(&m.vpErr, "", &m.errContentCache, true)
-		updateViewportContent(&m.vpMerged, "", &m.mergedContentCache, true)
+	if cfg.LogErrPattern != "" {
+		errPath = expandLogPattern(cfg.LogErrPattern, job, user)
 	}
+	return outPath, errPath
 }
 
-func (m *model) armCancelConfirm(jobID string) {
-	m.cancelConfirm = true
-	m.cancelConfirmJobID = jobID
-	m.statusText = fmt.Sprintf("cancel %s? [y/N]", jobID)
-	m.statusColor = "220"
+func (m *model) visibleJobs() []Job {
+	jobs := m.store.FilteredVisibleJobs(m.filterStates, m.filterName)
+	applyQueuePositions(jobs)
+	jobs = filterJobsByState(jobs, m.stateFilter)
+	jobs = filterJobsByPartition(jobs, m.cfg.Partition)
+	jobs = filterJobsByAccount(jobs, m.cfg.Account)
+	jobs = filterJobsByGRES(jobs, m.cfg.GRES)
+	jobs = sortJobs(jobs, m.sortField, m.sortAsc)
+	if m.cfg.MaxJobs > 0 && len(jobs) > m.cfg.MaxJobs {
+		jobs = jobs[:m.cfg.MaxJobs]
+	}
+	return jobs
 }
 
-func (m *model) clearCancelConfirm() {
-	m.cancelConfirm = false
-	m.cancelConfirmJobID = ""
-}
+var stateFilterCycle = []string{"", "RUNNING", "PENDING", "COMPLETED", "FAILED"}
 
-func (m *model) handleCancelConfirmKey(key string) (tea.Cmd, bool) {
-	switch key {
-	case "y", "Y", "enter":
-		jobID := m.cancelConfirmJobID
-		m.clearCancelConfirm()
-		if err := cancelJob(jobID); err != nil {
-			m.statusText = err.Error()
-			m.statusColor = "196"
-			return nil, true
+func (m *model) cycleStateFilter() {
+	next := 0
+	for i, s := range stateFilterCycle {
+		if s == m.stateFilter {
+			next = (i + 1) % len(stateFilterCycle)
 		}
-		m.statusText = fmt.Sprintf("cancel signal sent for %s", jobID)
-		m.statusColor = "42"
-		return fetchJobsCmd(), true
-	case "n", "N", "esc", "c":
-		jobID := m.cancelConfirmJobID
-		m.clearCancelConfirm()
-		m.statusText = fmt.Sprintf("cancel aborted for %s", jobID)
-		m.statusColor = "244"
-		return nil, true
-	default:
-		m.statusText = "cancel pending: press y to confirm or n/esc to abort"
-		m.statusColor = "220"
-		return nil, true
 	}
+	m.stateFilter = stateFilterCycle[next]
+	m.jobs = m.visibleJobs()
+	hidden := true
+	for _, j := range m.jobs {
+		if j.ID == m.selectedID {
+			hidden = false
+		}
+	}
+	if hidden && len(m.jobs) > 0 {
+		m.selectedIdx = 0
+		m.selectedID = m.jobs[0].ID
+		m.keepSelectionVisible()
+		m.switchToJob(m.jobs[0])
+	} else {
+		m.ensureSelectionByID()
+	}
+	m.renderJobsViewport()
 }
 
-func padOrTrimToWidth(s string, width int) string {
-	if width <= 0 {
-		return ""
+// cycleSort steps through each sort field ascending then descending and
+// finally back to first-seen order.
+func (m *model) cycleSort() {
+	switch {
+	case m.sortField != "" && m.sortAsc:
+		m.sortAsc = false
+	default:
+		next := 0
+		for i, f := range sortFields {
+			if f == m.sortField {
+				next = i + 1
+			}
+		}
+		m.sortField, m.sortAsc = "", true
+		if next < len(sortFields) {
+			m.sortField = sortFields[next]
+		}
 	}
-	if lipgloss.Width(s) > width {
-		s = ansi.Truncate(s, width, "")
+	m.refreshVisibleJobs()
+	m.renderJobsViewport()
+	if m.sortField == "" {
+		m.setStatus("sort: first seen", "244")
+		return
 	}
-	if pad := width - lipgloss.Width(s); pad > 0 {
-		s += strings.Repeat(" ", pad)
+	dir := "descending"
+	if m.sortAsc {
+		dir = "ascending"
 	}
-	return s
+	m.setStatus(fmt.Sprintf("sort: %s %s", m.sortField, dir), "244")
 }
 
-func centerOverlay(base, overlay string, width, height int) string {
-	if width <= 0 || height <= 0 {
-		return base
+func (m *model) refreshVisibleJobs() {
+	m.jobs = m.visibleJobs()
+	prev := m.selectedID
+	m.ensureSelectionByID()
+	if next, ok := m.selectedJob(); ok && next.ID != prev {
+		m.selectedID = next.ID
+		m.switchToJob(next)
 	}
+}
 
-	baseLines := strings.Split(base, "\n")
-	if len(baseLines) > height {
-		baseLines = baseLines[:height]
-	} else if len(baseLines) < height {
-		baseLines = append(baseLines, make([]string, height-len(baseLines))...)
+func writeJobsJSON(records []JobRecord, dir string, now time.Time) (string, error) {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return "", err
 	}
-	for i := range baseLines {
-		baseLines[i] = padOrTrimToWidth(baseLines[i], width)
+	name := filepath.Join(dir, fmt.Sprintf("slurm-jobs-%s.json", now.UTC().Format(time.RFC3339)))
+	if err := os.WriteFile(name, append(data, '\n'), 0o644); err != nil {
+		return "", err
 	}
+	return name, nil
+}
 
-	overlayLines := strings.Split(overlay, "\n")
-	if len(overlayLines) > height {
-		overlayLines = overlayLines[:height]
-	}
-	top := max(0, (height-len(overlayLines))/2)
+func exportLogName(jobID, stream string, now time.Time) string {
+	return fmt.Sprintf("%s_%s_%s.txt", jobID, stream, now.Format("20060102-150405"))
+}
 
-	for i, line := range overlayLines {
-		if top+i >= len(baseLines) {
-			break
-		}
-		if lipgloss.Width(line) > width {
-			line = ansi.Truncate(line, width, "")
+// exportLogCmd writes content without styling to destPath and reports the
+// result as a status message.
+func exportLogCmd(jobID, stream, destPath, content string) tea.Cmd {
+	return func() tea.Msg {
+		data := ansi.Strip(content)
+		if data != "" && !strings.HasSuffix(data, "\n") {
+			data += "\n"
 		}
-		left := max(0, (width-lipgloss.Width(line))/2)
-		baseLine := baseLines[top+i]
-		prefix := ansi.Cut(baseLine, 0, left)
-		suffixStart := left + lipgloss.Width(line)
-		suffix := ""
-		if suffixStart < width {
-			suffix = ansi.Cut(baseLine, suffixStart, width)
+		if err := os.WriteFile(destPath, []byte(data), 0o644); err != nil {
+			return statusMsg{text: fmt.Sprintf("export %s %s: %v", jobID, stream, err), color: "196"}
 		}
-		baseLines[top+i] = padOrTrimToWidth(prefix+line+suffix, width)
+		return statusMsg{text: "Exported " + stream + " to " + destPath, color: "42"}
 	}
-
-	return strings.Join(baseLines, "\n")
 }
 
-func (m model) renderCancelModal(base string) string {
-	if m.width <= 0 || m.height <= 0 {
-		return base
+func (m *model) exportFocusedLog() tea.Cmd {
+	job, ok := m.selectedJob()
+	if !ok || m.outFollower == nil || m.errFollower == nil {
+		return nil
 	}
-
-	modalWidth := min(68, max(40, m.width-8))
-	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Render("Cancel Job")
-	message := fmt.Sprintf("Send cancel signal to job %s?", m.cancelConfirmJobID)
-	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("252")).Render("[y/enter] confirm    [n/esc] abort")
-
-	body := strings.Join([]string{title, "", message, "", hint}, "\n")
-	modal := lipgloss.NewStyle().
-		Width(modalWidth).
-		Padding(1, 2).
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("214")).
-		Background(lipgloss.Color("236")).
-		Foreground(lipgloss.Color("255")).
-		Render(body)
-
-	dimmed := lipgloss.NewStyle().Faint(true).Render(base)
-	return centerOverlay(dimmed, modal, m.width, m.height)
+	var stream, content string
+	switch {
+	case m.mergedMode && m.focusArea != 0:
+		stream, content = "merged", m.mergedBuf.content()
+	case m.focusArea == 2:
+		stream, content = "stderr", m.errFollower.content(0)
+	default:
+		stream, content = "stdout", m.outFollower.content(0)
+	}
+	return exportLogCmd(job.ID, stream, exportLogName(job.ID, stream, m.now()), content)
 }
 
-func (m *model) pollSelectedLogs() {
+func (m *model) toggleArrayExpansion() {
 	job, ok := m.selectedJob()
-	if !ok {
+	if !ok || job.ArrayJobID == "" {
 		return
 	}
-	if m.outFollower == nil || m.errFollower == nil {
-		m.switchToJob(job)
+	parent := job.ArrayJobID
+	target := parent
+	if m.store.ToggleArrayExpansion(parent) {
+		if tasks := m.store.arrayTasks(parent); len(tasks) > 0 {
+			target = tasks[0].ID
+		}
+	}
+	m.jobs = m.visibleJobs()
+	m.selectedID = target
+	m.ensureSelectionByID()
+	if next, ok := m.selectedJob(); ok {
+		m.selectedID = next.ID
+		m.keepSelectionVisible()
+		m.switchToJob(next)
 	}
+}
 
-	outChunk, outErr := m.outFollower.poll(streamOut)
-	if outErr != nil {
-		m.statusText = fmt.Sprintf("log read error (stdout): %v", outErr)
-		m.statusColor = "196"
+func (m *model) applyPastedFilter(text string) {
+	text = strings.TrimPrefix(text, "\x1b[200~")
+	text = strings.TrimSuffix(text, "\x1b[201~")
+	text = strings.TrimSpace(strings.SplitN(text, "\n", 2)[0])
+	if text == "" {
+		return
 	}
+	m.filterName = text
+	m.refreshVisibleJobs()
+	m.setStatus(fmt.Sprintf("filter set from paste: %q", text), "42")
+}
+
+func (m model) filterActive() bool {
+	return len(m.filterStates) > 0 || m.filterName != ""
+}
+
+func (m model) scopeBadge() string {
+	var badge string
+	switch {
+	case m.cfg.AllUsers:
+		badge = "[All users]"
+	case m.cfg.User != "":
+		badge = "[User: " + m.cfg.User + "]"
+	}
+	if m.cfg.Partition != "" {
+		badge += "[Partition: " + m.cfg.Partition + "]"
+	}
+	if m.cfg.Account != "" {
+		badge += "[Account: " + m.cfg.Account + "]"
+	}
+	if m.cfg.GRES != "" {
+		badge += "[GRES: " + m.cfg.GRES + "]"
+	}
+	if badge == "" {
+		return ""
+	}
+	rendered := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("45")).Render(badge)
+	if m.cfg.Partition != "" {
+		rendered += lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(" [p=all]")
+	}
+	return rendered
+}
+
+func (m *model) togglePartitionFilter() tea.Cmd {
+	switch {
+	case m.cfg.Partition != "":
+		m.hiddenPartition, m.cfg.Partition = m.cfg.Partition, ""
+		m.setStatus("showing all partitions", "244")
+	case m.hiddenPartition != "":
+		m.cfg.Partition, m.hiddenPartition = m.hiddenPartition, ""
+		m.setStatus("partition filter: "+m.cfg.Partition, "244")
+	default:
+		return nil
+	}
+	return m.startRefresh()
+}
+
+func (m model) filterBadge() string {
+	if !m.filterActive() {
+		return ""
+	}
+	parts := append([]string{}, m.filterStates...)
+	if m.filterName != "" {
+		parts = append(parts, fmt.Sprintf("%q", m.filterName))
+	}
+	badge := fmt.Sprintf("[Filter: %s]", strings.Join(parts, ", "))
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("220")).Render(badge) +
+		lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(" [x=clear]")
+}
+
+func (m *model) saveCheckpoints() {
+	if m.cfg.CheckpointDir == "" {
+		return
+	}
+	for _, f := range []*logFollower{m.outFollower, m.errFollower} {
+		if f != nil {
+			_ = f.SaveCheckpoint(m.cfg.CheckpointDir)
+		}
+	}
+}
+
+// resolveLogPaths returns the paths scontrol reported for the job, or the
+// configured ones until it has.
+func (m *model) resolveLogPaths(job Job) (outPath, errPath string) {
+	if rec, ok := m.store.Record(job.ID); ok && rec.LogOutPath != "" {
+		return rec.LogOutPath, rec.LogErrPath
+	}
+	return logPaths(job, m.cfg)
+}
+
+type logPathsMsg struct {
+	jobID   string
+	outPath string
+	errPath string
+	err     error
+}
+
+func discoverLogPathsCmd(client slurmClient, jobID string) tea.Cmd {
+	return func() tea.Msg {
+		outPath, errPath, err := client.DiscoverLogPaths(jobID)
+		return logPathsMsg{jobID: jobID, outPath: outPath, errPath: errPath, err: err}
+	}
+}
+
+// maybeDiscoverLogPaths asks scontrol for the log paths of the selected job
+// once, when none are stored or a followed file is missing. Failures are
+// remembered in the store like results.
+func (m *model) maybeDiscoverLogPaths() tea.Cmd {
+	job, ok := m.selectedJob()
+	if !ok || job.IsArrayGroup() || m.logPathsRequested[job.ID] {
+		return nil
+	}
+	rec, ok := m.store.Record(job.ID)
+	if !ok || rec.LogPathsQueried {
+		return nil
+	}
+	missing := m.outFollower != nil && (m.outFollower.missing || m.errFollower.missing)
+	if rec.LogOutPath != "" && !missing {
+		return nil
+	}
+	if m.logPathsRequested == nil {
+		m.logPathsRequested = make(map[string]bool)
+	}
+	m.logPathsRequested[job.ID] = true
+	return discoverLogPathsCmd(m.slurm, job.ID)
+}
+
+// applyLogPaths stores a discovery result and moves the followers of the
+// selected job to the reported paths, reporting whether any moved.
+func (m *model) applyLogPaths(msg logPathsMsg) bool {
+	delete(m.logPathsRequested, msg.jobID)
+	if msg.err != nil {
+		m.store.SetLogPathsQueried(msg.jobID, "", "")
+		return false
+	}
+	m.store.SetLogPathsQueried(msg.jobID, msg.outPath, msg.errPath)
+	job, ok := m.selectedJob()
+	if !ok || job.ID != msg.jobID || m.outFollower == nil || m.errFollower == nil {
+		return false
+	}
+	moved := false
+	if msg.outPath != "" && msg.outPath != m.outFollower.path {
+		m.outFollower.reset(msg.outPath)
+		moved = true
+	}
+	if msg.errPath != "" && msg.errPath != m.errFollower.path {
+		m.errFollower.reset(msg.errPath)
+		moved = true
+	}
+	if moved {
+		m.mergedBuf.reset()
+	}
+	return moved
+}
+
+func (m *model) newLogFollower(path string) *logFollower {
+	f := newLogFollowerWithReader(path, m.logReader)
+	f.tailBytes = m.cfg.InitialTailBytes
+	f.tailLines = m.cfg.InitialTailLines
+	f.renderer.tabWidth = m.cfg.TabWidth
+	return f
+}
+
+func (m *model) switchToJob(job Job) {
+	outPath, errPath := m.resolveLogPaths(job)
+	m.cpuSamples = m.cpuSamples[:0]
+	m.memSamples = m.memSamples[:0]
+
+	if m.outFollower == nil {
+		m.outFollower = m.newLogFollower(outPath)
+	} else {
+		m.outFollower.reset(outPath)
+	}
+	if m.errFollower == nil {
+		m.errFollower = m.newLogFollower(errPath)
+	} else {
+		m.errFollower.reset(errPath)
+	}
+	m.outFollower.checkpointID = job.ID
+	m.errFollower.checkpointID = job.ID + ".err"
+	if dir := m.cfg.CheckpointDir; dir != "" {
+		// Only the first job shown after startup continues where the last
+		// session stopped; checkpoints of any later job are stale.
+		if !m.checkpointsResumed {
+			m.outFollower.resumeFrom(dir)
+			m.errFollower.resumeFrom(dir)
+		} else {
+			m.outFollower.discardCheckpoint(dir)
+			m.errFollower.discardCheckpoint(dir)
+		}
+		m.checkpointsResumed = true
+	}
+	m.mergedBuf.reset()
+	m.setFollow(m.cfg.InitialFollow)
+	m.unreadOutLines = 0
+	m.unreadErrLines = 0
+
+	if m.vpReady {
+		m.outContentCache = "\x00"
+		m.errContentCache = "\x00"
+		m.mergedContentCache = "\x00"
+		updateViewportContent(&m.vpOut, "", &m.outContentCache, true)
+		updateViewportContent(&m.vpErr, "", &m.errContentCache, true)
+		updateViewportContent(&m.vpMerged, "", &m.mergedContentCache, true)
+	}
+}
+
+func (m *model) pushStatus(text, color string, ttl time.Duration) {
+	expiresAt := m.now().Add(ttl)
+	if len(m.statusQueue) > 0 && m.statusQueue[0].text == text && m.statusQueue[0].color == color {
+		m.statusQueue[0].expiresAt = expiresAt
+		return
+	}
+	entry := statusEntry{text: text, color: color, expiresAt: expiresAt}
+	m.statusQueue = append([]statusEntry{entry}, m.statusQueue...)
+}
+
+func (m *model) setStatus(text, color string) {
+	m.pushStatus(text, color, statusTTL)
+}
+
+func (m *model) setError(text string) {
+	m.pushStatus(text, "196", errorStatusTTL)
+}
+
+func (m *model) expireStatus(now time.Time) {
+	kept := m.statusQueue[:0]
+	for _, e := range m.statusQueue {
+		if now.After(e.expiresAt) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	m.statusQueue = kept
+}
+
+func (m model) currentStatus(now time.Time) (statusEntry, int, bool) {
+	var current statusEntry
+	found := false
+	count := 0
+	for _, e := range m.statusQueue {
+		if now.After(e.expiresAt) {
+			continue
+		}
+		if !found {
+			current = e
+			found = true
+		}
+		count++
+	}
+	return current, count, found
+}
+
+type pendingAction struct {
+	kind   string // cancel, hold, release or requeue
+	jobID  string
+	jobIDs []string // batch cancel of the marked jobs
+}
+
+func (m model) confirmJob() (Job, bool) {
+	if m.pending == nil {
+		return Job{}, false
+	}
+	rec, ok := m.store.Record(m.pending.jobID)
+	return rec.Job, ok
+}
+
+func (m *model) armConfirm(kind, jobID string) {
+	m.pending = &pendingAction{kind: kind, jobID: jobID}
+	m.setStatus(fmt.Sprintf("%s %s? [y/N]", kind, jobID), "220")
+}
+
+func (m *model) clearConfirm() {
+	m.pending = nil
+}
+
+func (m *model) toggleMarked() {
+	job, ok := m.selectedJob()
+	if !ok {
+		return
+	}
+	if m.selected[job.ID] {
+		delete(m.selected, job.ID)
+	} else {
+		m.selected[job.ID] = true
+	}
+	m.renderJobsViewport()
+}
+
+// markedJobs returns the visible marked jobs accepted by keep, in list order.
+func (m model) markedJobs(keep func(Job) bool) []string {
+	var ids []string
+	for _, job := range m.jobs {
+		if m.selected[job.ID] && keep(job) {
+			ids = append(ids, job.ID)
+		}
+	}
+	return ids
+}
+
+func (m *model) clearMarked() {
+	m.selected = make(map[string]bool)
+}
+
+func (m *model) armBatchCancel() {
+	ids := m.markedJobs(func(j Job) bool { return isActiveState(j.State) })
+	if len(ids) == 0 {
+		m.setStatus("none of the marked jobs are RUNNING/PENDING", "220")
+		return
+	}
+	m.pending = &pendingAction{kind: "cancel", jobIDs: ids}
+	m.setStatus(fmt.Sprintf("cancel %d jobs? [y/N]", len(ids)), "220")
+}
+
+func (m *model) batchDismiss() {
+	ids := m.markedJobs(func(j Job) bool { return true })
+	dismissed := 0
+	for _, id := range ids {
+		if m.store.DismissIfTerminal(id) {
+			dismissed++
+		}
+	}
+	m.clearMarked()
+	m.refreshVisibleJobs()
+	m.setStatus(fmt.Sprintf("dismissed %d of %d marked jobs", dismissed, len(ids)), "244")
+}
+
+func (m *model) handleConfirmKey(key string) (tea.Cmd, bool) {
+	action := *m.pending
+	switch key {
+	case "y", "Y", "enter":
+		m.clearConfirm()
+		if len(action.jobIDs) > 0 {
+			m.clearMarked()
+			m.renderJobsViewport()
+			m.setStatus(fmt.Sprintf("cancelling %d jobs...", len(action.jobIDs)), "244")
+			return batchCancelCmd(action.jobIDs, m.cfg.Cluster), true
+		}
+		client, id := m.slurm, action.jobID
+		run := func() error { return client.CancelJob(id) }
+		switch action.kind {
+		case "hold":
+			run = func() error { return client.HoldJob(id) }
+		case "release":
+			run = func() error { return client.ReleaseJob(id) }
+		case "requeue":
+			run = func() error { return client.RequeueJob(id) }
+		}
+		m.setStatus(confirmPendingText(action), "244")
+		return jobActionCmd(action, run), true
+	case "a", "A":
+		job, ok := m.confirmJob()
+		if action.kind != "cancel" || !ok || !job.IsArrayTask() {
+			m.setStatus(action.kind+" pending: press y to confirm or n/esc to abort", "220")
+			return nil, true
+		}
+		m.clearConfirm()
+		client := m.slurm
+		done := pendingAction{kind: "cancel", jobID: job.ArrayJobID}
+		run := func() error { return client.CancelJob(job.ArrayJobID) }
+		if key == "a" {
+			done.jobID = arrayElementID(job.ArrayJobID, job.ArrayTaskID)
+			run = func() error { return client.CancelArrayElement(job.ArrayJobID, job.ArrayTaskID) }
+		}
+		m.setStatus(confirmPendingText(done), "244")
+		return jobActionCmd(done, run), true
+	case "n", "N", "esc":
+		m.clearConfirm()
+		target := action.jobID
+		if len(action.jobIDs) > 0 {
+			target = fmt.Sprintf("%d jobs", len(action.jobIDs))
+		}
+		m.setStatus(fmt.Sprintf("%s aborted for %s", action.kind, target), "244")
+		return nil, true
+	case "c":
+		if action.kind == "cancel" {
+			return m.handleConfirmKey("n")
+		}
+	}
+	m.setStatus(action.kind+" pending: press y to confirm or n/esc to abort", "220")
+	return nil, true
+}
+
+// jobActionMsg reports a confirmed cancel, hold, release or requeue.
+type jobActionMsg struct {
+	action pendingAction
+	err    error
+}
+
+// jobActionCmd runs a confirmed job action off the UI loop; scontrol and
+// scancel can take up to their timeout.
+func jobActionCmd(action pendingAction, run func() error) tea.Cmd {
+	return func() tea.Msg {
+		return jobActionMsg{action: action, err: run()}
+	}
+}
+
+type signalPrompt struct {
+	jobID string
+	input textinput.Model
+}
+
+func (m *model) openSignalPrompt(jobID string) tea.Cmd {
+	input := textinput.New()
+	input.Prompt = "signal: "
+	input.Placeholder = "USR1, TERM or a number"
+	input.Cursor.SetMode(cursor.CursorStatic)
+	m.signal = &signalPrompt{jobID: jobID, input: input}
+	return m.signal.input.Focus()
+}
+
+func (m *model) handleSignalKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.signal = nil
+		return nil
+	case "enter":
+		signal := strings.ToUpper(strings.TrimSpace(m.signal.input.Value()))
+		signal = strings.TrimPrefix(signal, "SIG")
+		if err := validateSignal(signal); err != nil {
+			m.setError(err.Error())
+			return nil
+		}
+		jobID := m.signal.jobID
+		m.signal = nil
+		m.setStatus(fmt.Sprintf("sending SIG%s to %s...", signal, jobID), "244")
+		return sendSignalCmd(jobID, signal, m.cfg.Cluster)
+	}
+	var cmd tea.Cmd
+	m.signal.input, cmd = m.signal.input.Update(msg)
+	return cmd
+}
+
+func (m model) renderSignalPrompt(base string) string {
+	if m.width <= 0 || m.height <= 0 {
+		return base
+	}
+
+	modalWidth := min(60, max(40, m.width-8))
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Render("Signal Job " + m.signal.jobID)
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("252")).Render("[enter] send    [esc] abort")
+	body := strings.Join([]string{title, "", m.signal.input.View(), "", hint}, "\n")
+	modal := lipgloss.NewStyle().
+		Width(modalWidth).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Background(lipgloss.Color("236")).
+		Foreground(lipgloss.Color("255")).
+		Render(body)
+
+	dimmed := lipgloss.NewStyle().Faint(true).Render(base)
+	return centerOverlay(dimmed, modal, m.width, m.height)
+}
+
+func confirmPendingText(action pendingAction) string {
+	switch action.kind {
+	case "hold":
+		return fmt.Sprintf("holding %s...", action.jobID)
+	case "release":
+		return fmt.Sprintf("releasing %s...", action.jobID)
+	case "requeue":
+		return fmt.Sprintf("requeueing %s...", action.jobID)
+	default:
+		return fmt.Sprintf("cancelling %s...", action.jobID)
+	}
+}
+
+func confirmDoneText(action pendingAction) string {
+	switch action.kind {
+	case "hold":
+		return fmt.Sprintf("job %s held", action.jobID)
+	case "release":
+		return fmt.Sprintf("job %s released", action.jobID)
+	case "requeue":
+		return fmt.Sprintf("job %s requeued", action.jobID)
+	default:
+		return fmt.Sprintf("cancel signal sent for %s", action.jobID)
+	}
+}
+
+func (m *model) openGlobalSearch() tea.Cmd {
+	m.globalSearch = true
+	m.globalSearchIdx, m.globalSearchOffset = 0, 0
+	return m.globalSearchInput.Focus()
+}
+
+func (m *model) closeGlobalSearch() {
+	m.globalSearch = false
+	m.globalSearchInput.Blur()
+	m.globalSearchInput.Reset()
+	m.globalSearchQuery = ""
+	m.globalSearchResults = nil
+	m.globalSearchIdx, m.globalSearchOffset = 0, 0
+}
+
+func (m *model) handleGlobalSearchKey(msg tea.KeyMsg) tea.Cmd {
+	key := msg.String()
+	if key == "esc" {
+		m.closeGlobalSearch()
+		return nil
+	}
+
+	if m.globalSearchInput.Focused() {
+		if key == "enter" {
+			query := strings.TrimSpace(m.globalSearchInput.Value())
+			if query == "" {
+				return nil
+			}
+			m.globalSearchInput.Blur()
+			m.setStatus(fmt.Sprintf("searching logs for %q...", query), "244")
+			paths := make(map[string][2]string, len(m.jobs))
+			for _, job := range m.jobs {
+				outPath, errPath := m.resolveLogPaths(job)
+				paths[job.ID] = [2]string{outPath, errPath}
+			}
+			return globalSearchCmd(query, m.jobs, paths, m.cfg, m.logReader)
+		}
+		var cmd tea.Cmd
+		m.globalSearchInput, cmd = m.globalSearchInput.Update(msg)
+		return cmd
+	}
+
+	switch key {
+	case "up", "k":
+		if m.globalSearchIdx > 0 {
+			m.globalSearchIdx--
+		}
+	case "down", "j":
+		if m.globalSearchIdx < len(m.globalSearchResults)-1 {
+			m.globalSearchIdx++
+		}
+	case "ctrl+g", "/":
+		return m.globalSearchInput.Focus()
+	case "enter":
+		m.openSearchResult()
+	}
+	return nil
+}
+
+func (m *model) openSearchResult() {
+	if m.globalSearchIdx < 0 || m.globalSearchIdx >= len(m.globalSearchResults) {
+		return
+	}
+	result := m.globalSearchResults[m.globalSearchIdx]
+	m.closeGlobalSearch()
+
+	for i, job := range m.jobs {
+		if job.ID != result.JobID {
+			continue
+		}
+		m.selectedIdx = i
+		m.selectedID = job.ID
+		m.keepSelectionVisible()
+		m.switchToJob(job)
+		m.mergedMode = false
+		m.setFollow(false)
+		if result.Stream == streamErr {
+			m.setFocus(2)
+		} else {
+			m.setFocus(1)
+		}
+		m.pendingJump = &result
+		return
+	}
+	m.setStatus(fmt.Sprintf("job %s is no longer listed", result.JobID), "220")
+}
+
+func (m *model) applyPendingJump() {
+	if m.pendingJump == nil {
+		return
+	}
+	jump := m.pendingJump
+	follower, vp := m.outFollower, &m.vpOut
+	if jump.Stream == streamErr {
+		follower, vp = m.errFollower, &m.vpErr
+	}
+	if follower == nil || !follower.initialized {
+		if follower != nil && follower.missing {
+			m.pendingJump = nil
+		}
+		return
+	}
+	vp.SetYOffset(follower.wrappedOffset(jump.LineIdx, m.logWrapWidth(vp.Width)))
+	m.pendingJump = nil
+}
+
+func padOrTrimToWidth(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if lipgloss.Width(s) > width {
+		s = ansi.Truncate(s, width, "")
+	}
+	if pad := width - lipgloss.Width(s); pad > 0 {
+		s += strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+func centerOverlay(base, overlay string, width, height int) string {
+	if width <= 0 || height <= 0 {
+		return base
+	}
+
+	baseLines := strings.Split(base, "\n")
+	if len(baseLines) > height {
+		baseLines = baseLines[:height]
+	} else if len(baseLines) < height {
+		baseLines = append(baseLines, make([]string, height-len(baseLines))...)
+	}
+	for i := range baseLines {
+		baseLines[i] = padOrTrimToWidth(baseLines[i], width)
+	}
+
+	overlayLines := strings.Split(overlay, "\n")
+	if len(overlayLines) > height {
+		overlayLines = overlayLines[:height]
+	}
+	top := max(0, (height-len(overlayLines))/2)
+
+	for i, line := range overlayLines {
+		if top+i >= len(baseLines) {
+			break
+		}
+		if lipgloss.Width(line) > width {
+			line = ansi.Truncate(line, width, "")
+		}
+		left := max(0, (width-lipgloss.Width(line))/2)
+		baseLine := baseLines[top+i]
+		prefix := ansi.Cut(baseLine, 0, left)
+		suffixStart := left + lipgloss.Width(line)
+		suffix := ""
+		if suffixStart < width {
+			suffix = ansi.Cut(baseLine, suffixStart, width)
+		}
+		baseLines[top+i] = padOrTrimToWidth(prefix+line+suffix, width)
+	}
+
+	return strings.Join(baseLines, "\n")
+}
+
+type infoModal struct {
+	kind   string // "sinfo" for the auto-refreshing partition overview, "node" for node details, "script" for a batch script, "help" for the keymap
+	title  string
+	lines  []string
+	offset int
+
+	nodes   []string // hosts of the job, for the node picker
+	nodeIdx int
+
+	jobID string // job whose batch script is shown
+}
+
+func (m *model) openModal(title string, lines []string) {
+	m.modal = &infoModal{title: title, lines: lines}
+}
+
+func (m *model) openSinfo() tea.Cmd {
+	m.openModal("Partitions", []string{"loading sinfo..."})
+	m.modal.kind = "sinfo"
+	return m.refreshSinfo()
+}
+
+func (m *model) refreshSinfo() tea.Cmd {
+	m.sinfoFetching = true
+	m.lastSinfoFetch = m.now()
+	return fetchSinfoCmd(m.cfg.Cluster)
+}
+
+func (m *model) openNodeDetail(job Job) tea.Cmd {
+	nodes := expandNodeList(job.Nodes)
+	if len(nodes) == 0 {
+		m.setStatus(fmt.Sprintf("job %s has no nodes assigned", job.ID), "220")
+		return nil
+	}
+	m.openModal("Nodes of job "+job.ID, nil)
+	m.modal.kind = "node"
+	m.modal.nodes = nodes
+	return m.selectNode(0)
+}
+
+func (m *model) selectNode(idx int) tea.Cmd {
+	m.modal.nodeIdx = idx
+	m.modal.offset = 0
+	m.modal.lines = append(m.nodePickerLines(), "loading "+m.modal.nodes[idx]+"...")
+	return fetchNodeDetailCmd(m.modal.nodes[idx], m.cfg.Cluster)
+}
+
+func (m *model) openBatchScript(job Job) tea.Cmd {
+	m.openModal("Batch script of job "+job.ID, []string{"loading batch script..."})
+	m.modal.kind = "script"
+	m.modal.jobID = job.ID
+	return fetchBatchScriptCmd(job.ID, m.cfg.Cluster)
+}
+
+var (
+	sbatchDirectiveStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("45"))
+	scriptCommentStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	moduleLoadStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("220"))
+)
+
+// batchScriptLineStyle picks the style of one script line; ok is false for
+// lines shown as is.
+func batchScriptLineStyle(line string) (style lipgloss.Style, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "#SBATCH"):
+		return sbatchDirectiveStyle, true
+	case strings.HasPrefix(trimmed, "#"):
+		return scriptCommentStyle, true
+	case trimmed == "module load" || strings.HasPrefix(trimmed, "module load "):
+		return moduleLoadStyle, true
+	}
+	return lipgloss.Style{}, false
+}
+
+func highlightBatchScript(script string) string {
+	lines := strings.Split(strings.TrimRight(script, "\n"), "\n")
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		if style, ok := batchScriptLineStyle(line); ok {
+			line = style.Render(line)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m model) nodePickerLines() []string {
+	if len(m.modal.nodes) < 2 {
+		return nil
+	}
+	selected := lipgloss.NewStyle().Reverse(true)
+	parts := make([]string, 0, len(m.modal.nodes))
+	for i, node := range m.modal.nodes {
+		if i == m.modal.nodeIdx {
+			node = selected.Render(node)
+		}
+		parts = append(parts, node)
+	}
+	return []string{strings.Join(parts, " "), "[h/l] switch node", ""}
+}
+
+func nodeDetailLines(d NodeDetail) []string {
+	rows := [][2]string{
+		{"NodeName", d.NodeName},
+		{"State", d.State},
+		{"CPUTot", d.CPUTot},
+		{"CPULoad", d.CPULoad},
+		{"AllocMem", d.AllocMem},
+		{"FreeMem", d.FreeMem},
+		{"OS", d.OS},
+		{"Features", d.Features},
+		{"Gres", d.Gres},
+	}
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		value := row[1]
+		if value == "" {
+			value = "-"
+		}
+		lines = append(lines, fmt.Sprintf("%-8s  %s", row[0], value))
+	}
+	return lines
+}
+
+type keybinding struct {
+	key  string
+	desc string
+}
+
+// defaultKeymap documents the main key handlers in Update for the help
+// overlay; keep it in sync when adding a key.
+var defaultKeymap = []keybinding{
+	{"q/ctrl+c", "quit"},
+	{"?", "toggle this help"},
+	{"j/k/down/up", "select job / scroll log (count prefix: 5j)"},
+	{"g g/G/home/end", "first/last job (count prefix: 3G)"},
+	{"pgup/pgdown", "page through jobs or log"},
+	{"tab/shift+tab", "cycle focus between jobs and logs"},
+	{"space", "mark job for batch actions"},
+	{"m", "toggle split/merged logs"},
+	{"f", "toggle follow"},
+	{"c", "cancel job (confirm)"},
+	{"h/H", "hold/release pending job"},
+	{"R", "requeue job"},
+	{"S", "send signal"},
+	{"s", "submit job"},
+	{"i", "job details"},
+	{"P", "batch script"},
+	{"n", "node details / next log match"},
+	{"N", "previous log match"},
+	{"e", "expand/collapse job array"},
+	{"o", "cycle sort"},
+	{"F", "cycle state filter"},
+	{"p", "toggle partition filter"},
+	{"/", "search jobs or log"},
+	{"x", "clear filter"},
+	{"esc", "clear search"},
+	{"d", "dismiss terminal job"},
+	{"D", "clear terminal jobs"},
+	{"r", "refresh now"},
+	{"ctrl+s", "save jobs as json"},
+	{"E", "export focused log"},
+	{"L", "toggle line numbers"},
+	{"C", "toggle log level colors"},
+	{"T", "toggle merged timestamps"},
+	{"ctrl+b", "toggle compact mode"},
+	{"+/-", "grow/shrink the jobs pane"},
+	{"Z", "maximize the focused log pane"},
+	{"I", "partitions (sinfo)"},
+	{"ctrl+o", "cluster config"},
+	{"ctrl+g", "search all logs"},
+}
+
+func helpLines() []string {
+	width := 0
+	for _, kb := range defaultKeymap {
+		width = max(width, len(kb.key))
+	}
+	keyStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229"))
+	lines := make([]string, 0, len(defaultKeymap))
+	for _, kb := range defaultKeymap {
+		lines = append(lines, keyStyle.Render(fmt.Sprintf("%-*s", width, kb.key))+"  "+kb.desc)
+	}
+	return lines
+}
+
+func (m *model) toggleHelp() {
+	if m.helpOpen() {
+		m.modal = nil
+		return
+	}
+	m.openModal("Keybindings", helpLines())
+	m.modal.kind = "help"
+}
+
+func (m model) helpOpen() bool {
+	return m.modal != nil && m.modal.kind == "help"
+}
+
+func (m model) sinfoOpen() bool {
+	return m.modal != nil && m.modal.kind == "sinfo"
+}
+
+func (m model) modalBodyHeight() int {
+	return max(3, m.height-10)
+}
+
+func (m *model) handleModalKey(key string) tea.Cmd {
+	if n := len(m.modal.nodes); n > 1 {
+		switch key {
+		case "left", "h":
+			return m.selectNode((m.modal.nodeIdx + n - 1) % n)
+		case "right", "l":
+			return m.selectNode((m.modal.nodeIdx + 1) % n)
+		}
+	}
+	maxOffset := max(0, len(m.modal.lines)-m.modalBodyHeight())
+	switch key {
+	case "esc", "q", "enter":
+		m.modal = nil
+	case "?":
+		if m.helpOpen() {
+			m.modal = nil
+		}
+	case "down", "j":
+		m.modal.offset = min(m.modal.offset+1, maxOffset)
+	case "up", "k":
+		m.modal.offset = max(m.modal.offset-1, 0)
+	case "pgdown", " ":
+		m.modal.offset = min(m.modal.offset+m.modalBodyHeight(), maxOffset)
+	case "pgup":
+		m.modal.offset = max(m.modal.offset-m.modalBodyHeight(), 0)
+	case "g", "home":
+		m.modal.offset = 0
+	case "G", "end":
+		m.modal.offset = maxOffset
+	}
+	return nil
+}
+
+func (m model) renderInfoModal(base string) string {
+	if m.width <= 0 || m.height <= 0 {
+		return base
+	}
+
+	modalWidth := min(100, max(40, m.width-8))
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Render(m.modal.title)
+	lines := m.modal.lines
+	height := m.modalBodyHeight()
+	start := min(m.modal.offset, len(lines))
+	end := min(start+height, len(lines))
+	visible := lines[start:end]
+	hintText := "[esc] close"
+	if len(lines) > height {
+		hintText = fmt.Sprintf("[j/k] scroll  [esc] close  %d-%d of %d", start+1, end, len(lines))
+	}
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(hintText)
+
+	body := strings.Join(append(append([]string{title, ""}, visible...), "", hint), "\n")
+	modal := lipgloss.NewStyle().
+		Width(modalWidth).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("69")).
+		Background(lipgloss.Color("236")).
+		Foreground(lipgloss.Color("255")).
+		Render(body)
+
+	dimmed := lipgloss.NewStyle().Faint(true).Render(base)
+	return centerOverlay(dimmed, modal, m.width, m.height)
+}
+
+func (m model) clusterName() string {
+	if m.cfg.Cluster != "" {
+		return m.cfg.Cluster
+	}
+	return m.clusterMeta.ClusterName
+}
+
+func (m *model) openClusterConfig() {
+	config := m.clusterMeta.Config
+	if len(config) == 0 {
+		m.setStatus("cluster config not loaded yet", "220")
+		return
+	}
+	keys := make([]string, 0, len(config))
+	width := 0
+	for k := range config {
+		keys = append(keys, k)
+		width = max(width, len(k))
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%-*s  %s", width, k, config[k]))
+	}
+	title := "Cluster configuration"
+	if name := m.clusterName(); name != "" {
+		title += " — " + name
+	}
+	m.openModal(title, lines)
+}
+
+func jobDetailLines(d JobDetail, rec JobRecord, now time.Time, loc *time.Location) []string {
+	slurmTime := func(s string) string {
+		t, err := parseSlurmTimestamp(s, loc)
+		if err != nil {
+			return s
+		}
+		return formatSlurmTimestamp(t)
+	}
+	firstSeen := ""
+	if !rec.FirstSeen.IsZero() {
+		firstSeen = fmt.Sprintf("%s (%s ago)", rec.FirstSeen.Format("2006-01-02 15:04:05"), formatDuration(now.Sub(rec.FirstSeen)))
+	}
+	partition := d.Partition
+	if partition == "" {
+		partition = rec.Job.Partition
+	}
+	account := d.Account
+	if account == "" {
+		account = rec.Job.Account
+	}
+	rows := [][2]string{
+		{"Partition", partition},
+		{"Account", account},
+		{"WorkDir", d.WorkDir},
+		{"Command", d.Command},
+		{"StdOut", d.StdOut},
+		{"StdErr", d.StdErr},
+		{"NodeList", d.NodeList},
+		{"Reason", d.Reason},
+		{"Priority", d.Priority},
+		{"TimeLimit", d.TimeLimit},
+		{"SubmitTime", slurmTime(d.SubmitTime)},
+		{"StartTime", slurmTime(d.StartTime)},
+		{"EndTime", slurmTime(d.EndTime)},
+		{"FirstSeen", firstSeen},
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row[0]))
+	}
+	lines := make([]string, 0, len(rows)+2)
+	for _, row := range rows {
+		value := row[1]
+		if value == "" {
+			value = "-"
+		}
+		lines = append(lines, fmt.Sprintf("%-*s  %s", width, row[0], value))
+	}
+	if deps := describeDependencies(parseDependency(d.Dependency)); len(deps) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, deps...)
+	}
+	return lines
+}
+
+func (m model) renderConfirmModal(base string) string {
+	if m.width <= 0 || m.height <= 0 {
+		return base
+	}
+
+	modalWidth := min(68, max(40, m.width-8))
+	titleText, message := "Cancel Job", fmt.Sprintf("Send cancel signal to job %s?", m.pending.jobID)
+	if ids := m.pending.jobIDs; len(ids) > 0 {
+		titleText = "Cancel Jobs"
+		message = lipgloss.NewStyle().Width(modalWidth - 4).Render(
+			fmt.Sprintf("Send cancel signal to %d jobs?\n\n%s", len(ids), strings.Join(ids, " ")))
+	}
+	switch m.pending.kind {
+	case "hold":
+		titleText, message = "Hold Job", fmt.Sprintf("Hold job %s? It stays pending until released.", m.pending.jobID)
+	case "release":
+		titleText, message = "Release Job", fmt.Sprintf("Release held job %s?", m.pending.jobID)
+	case "requeue":
+		titleText, message = "Requeue Job", fmt.Sprintf("Requeue job %s? [y/N]", m.pending.jobID)
+	}
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("229")).Render(titleText)
+	hintText := "[y/enter] confirm    [n/esc] abort"
+	if job, ok := m.confirmJob(); ok && m.pending.kind == "cancel" && job.IsArrayTask() {
+		hintText = "[a] cancel array element  [A] cancel entire array  [n/esc] abort"
+	}
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("252")).Render(hintText)
+
+	body := strings.Join([]string{title, "", message, "", hint}, "\n")
+	modal := lipgloss.NewStyle().
+		Width(modalWidth).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Background(lipgloss.Color("236")).
+		Foreground(lipgloss.Color("255")).
+		Render(body)
+
+	dimmed := lipgloss.NewStyle().Faint(true).Render(base)
+	return centerOverlay(dimmed, modal, m.width, m.height)
+}
+
+// pollSelectedLogs reads new output of the selected job. Local files are
+// read in place; a remote LogReader is read on a tea.Cmd so slow or
+// unreachable hosts don't block Update.
+func (m *model) pollSelectedLogs() tea.Cmd {
+	job, ok := m.selectedJob()
+	if !ok {
+		return nil
+	}
+	if m.outFollower == nil || m.errFollower == nil {
+		m.switchToJob(job)
+	}
+	if m.logReader != nil {
+		if m.remotePolling {
+			return nil
+		}
+		m.remotePolling = true
+		return remoteLogsCmd(job.ID, m.outFollower.remoteRead(), m.errFollower.remoteRead())
+	}
+
+	outWasInitialized := m.outFollower.initialized
+	errWasInitialized := m.errFollower.initialized
+	outChunk, outErr := m.outFollower.poll(streamOut)
 	errChunk, errErr := m.errFollower.poll(streamErr)
-	if errErr != nil {
-		m.statusText = fmt.Sprintf("log read error (stderr): %v", errErr)
-		m.statusColor = "196"
+	m.applyLogChunks(job, outChunk, errChunk, outErr, errErr, outWasInitialized, errWasInitialized)
+	return nil
+}
+
+type remoteLogsMsg struct {
+	jobID    string
+	out, err remoteRead
+}
+
+func remoteLogsCmd(jobID string, readOut, readErr func() remoteRead) tea.Cmd {
+	return func() tea.Msg {
+		return remoteLogsMsg{jobID: jobID, out: readOut(), err: readErr()}
+	}
+}
+
+func (m *model) applyRemoteLogs(msg remoteLogsMsg) {
+	m.remotePolling = false
+	job, ok := m.selectedJob()
+	if !ok || job.ID != msg.jobID || m.outFollower == nil || m.errFollower == nil {
+		return
 	}
+	outWasInitialized := m.outFollower.initialized
+	errWasInitialized := m.errFollower.initialized
+	outChunk, outErr := m.outFollower.applyRemote(streamOut, msg.out)
+	errChunk, errErr := m.errFollower.applyRemote(streamErr, msg.err)
+	m.applyLogChunks(job, outChunk, errChunk, outErr, errErr, outWasInitialized, errWasInitialized)
+}
 
+func (m *model) applyLogChunks(job Job, outChunk, errChunk streamChunk, outErr, errErr error, outWasInitialized, errWasInitialized bool) {
+	if outErr != nil {
+		m.setError(fmt.Sprintf("log read error (stdout): %v", outErr))
+	}
+	if errErr != nil {
+		m.setError(fmt.Sprintf("log read error (stderr): %v", errErr))
+	}
 	m.mergedBuf.applyChunk(outChunk)
 	m.mergedBuf.applyChunk(errChunk)
 
-	if !m.vpReady {
+	if outWasInitialized && m.focusArea != 1 {
+		m.unreadOutLines += len(outChunk.NewLines)
+	}
+	if errWasInitialized && m.focusArea != 2 {
+		m.unreadErrLines += len(errChunk.NewLines)
+	}
+
+	m.logOutPath, m.logOutSize = m.outFollower.path, m.outFollower.size()
+	m.logErrPath, m.logErrSize = m.errFollower.path, m.errFollower.size()
+	m.logOutLines = len(m.outFollower.renderer.logicalLines())
+	m.logErrLines = len(m.errFollower.renderer.logicalLines())
+
+	m.renderLogViewports(job)
+	m.applyPendingJump()
+}
+
+func (m *model) renderLogViewports(job Job) {
+	if !m.vpReady || m.outFollower == nil || m.errFollower == nil {
 		return
 	}
 
-	outContent := m.outFollower.content(m.vpOut.Width)
-	errContent := m.errFollower.content(m.vpErr.Width)
-	if outChunk.Missing && outContent == "" {
+	outWidth, errWidth := m.logWrapWidth(m.vpOut.Width), m.logWrapWidth(m.vpErr.Width)
+	var outContent, errContent string
+	if m.colorizeLog {
+		// Color before wrapping so continuation segments keep the color.
+		outContent = wrapContent(colorizedContent(m.outFollower.content(0), true), outWidth)
+		errContent = wrapContent(colorizedContent(m.errFollower.content(0), true), errWidth)
+	} else {
+		outContent = m.outFollower.content(outWidth)
+		errContent = m.errFollower.content(errWidth)
+	}
+	mergedWidth := m.logWrapWidth(m.vpMerged.Width)
+	m.mergedBuf.colorize = m.colorizeLog
+	mergedContent := m.mergedBuf.contentWrapped(mergedWidth)
+
+	outContent = m.markLogSearch(outContent, !m.mergedMode && m.focusArea == 1)
+	errContent = m.markLogSearch(errContent, !m.mergedMode && m.focusArea == 2)
+	mergedContent = m.markLogSearch(mergedContent, m.mergedMode)
+
+	if m.showLineNumbers {
+		outContent = prefixLines(outContent, m.outFollower.lineNumberPrefixes(outWidth))
+		errContent = prefixLines(errContent, m.errFollower.lineNumberPrefixes(errWidth))
+		mergedContent = prefixLines(mergedContent, m.mergedBuf.lineNumberPrefixes(mergedWidth))
+	}
+	if m.outFollower.missing && outContent == "" {
 		outContent = fmt.Sprintf("Waiting for output log for job %s...", job.ID)
 	}
-	if errChunk.Missing && errContent == "" {
+	if m.errFollower.missing && errContent == "" {
 		errContent = fmt.Sprintf("Waiting for error log for job %s...", job.ID)
 	}
 
-	updateViewportContent(&m.vpOut, outContent, &m.outContentCache, m.follow)
-	updateViewportContent(&m.vpErr, errContent, &m.errContentCache, m.follow)
-	updateViewportContent(&m.vpMerged, m.mergedBuf.content(), &m.mergedContentCache, m.follow)
+	updateViewportContent(&m.vpOut, outContent, &m.outContentCache, m.followOut)
+	updateViewportContent(&m.vpErr, errContent, &m.errContentCache, m.followErr)
+	updateViewportContent(&m.vpMerged, mergedContent, &m.mergedContentCache, m.followMerged)
+}
+
+// logWrapWidth is the width log lines wrap at inside a pane, leaving room
+// for the line number gutter when it is shown.
+func (m model) logWrapWidth(paneWidth int) int {
+	if m.showLineNumbers && paneWidth > lineNumberWidth {
+		return paneWidth - lineNumberWidth
+	}
+	return paneWidth
+}
+
+func (m *model) toggleLineNumbers() {
+	m.showLineNumbers = !m.showLineNumbers
+	m.outContentCache, m.errContentCache, m.mergedContentCache = "", "", ""
+	if job, ok := m.selectedJob(); ok {
+		m.renderLogViewports(job)
+	}
+}
+
+func (m *model) focusedLogViewport() (*viewport.Model, *bool) {
+	return m.logViewport(m.focusArea)
+}
+
+// logViewport returns the viewport shown for a log focus area and its
+// follow flag.
+func (m *model) logViewport(area int) (*viewport.Model, *bool) {
+	switch {
+	case m.mergedMode:
+		return &m.vpMerged, &m.followMerged
+	case area == 1:
+		return &m.vpOut, &m.followOut
+	default:
+		return &m.vpErr, &m.followErr
+	}
+}
+
+var logSearchLineStyle = lipgloss.NewStyle().Background(lipgloss.Color("226")).Foreground(lipgloss.Color("16"))
+
+// markLogSearch highlights the lines matching the log search. Matches of
+// the focused pane are kept for n/N navigation.
+func (m *model) markLogSearch(content string, focused bool) string {
+	if m.logSearchQuery == "" {
+		return content
+	}
+	matches := searchContent(content, m.logSearchQuery)
+	if focused {
+		m.logSearchMatches = matches
+		if m.logSearchMatchIdx >= len(matches) {
+			m.logSearchMatchIdx = 0
+		}
+	}
+	if len(matches) == 0 {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for _, i := range matches {
+		lines[i] = logSearchLineStyle.Render(ansi.Strip(lines[i]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *model) openLogSearch() tea.Cmd {
+	input := textinput.New()
+	input.Prompt = "/"
+	input.Cursor.SetMode(cursor.CursorStatic)
+	input.SetValue(m.logSearchQuery)
+	m.logSearchInput = input
+	m.logSearchTyping = true
+	return m.logSearchInput.Focus()
+}
+
+func (m *model) clearLogSearch() {
+	m.logSearchQuery = ""
+	m.logSearchMatches = nil
+	m.logSearchMatchIdx = 0
+	m.logSearchTyping = false
+	m.logSearchInput.Blur()
+	if job, ok := m.selectedJob(); ok {
+		m.renderLogViewports(job)
+	}
+}
+
+func (m *model) handleLogSearchKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.clearLogSearch()
+		return nil
+	case "enter":
+		query := strings.TrimSpace(m.logSearchInput.Value())
+		if query == "" {
+			m.clearLogSearch()
+			return nil
+		}
+		m.logSearchTyping = false
+		m.logSearchInput.Blur()
+		m.logSearchQuery = query
+		m.logSearchMatchIdx = 0
+		if job, ok := m.selectedJob(); ok {
+			m.renderLogViewports(job)
+		}
+		m.jumpToLogMatch(0)
+		return nil
+	}
+	var cmd tea.Cmd
+	m.logSearchInput, cmd = m.logSearchInput.Update(msg)
+	return cmd
+}
+
+// jumpToLogMatch moves delta matches from the current one, wrapping around,
+// and scrolls the focused log pane to it.
+func (m *model) jumpToLogMatch(delta int) {
+	n := len(m.logSearchMatches)
+	if n == 0 {
+		m.setStatus(fmt.Sprintf("no matches for /%s", m.logSearchQuery), "220")
+		return
+	}
+	m.logSearchMatchIdx = ((m.logSearchMatchIdx+delta)%n + n) % n
+	vp, follow := m.focusedLogViewport()
+	*follow = false
+	vp.SetYOffset(m.logSearchMatches[m.logSearchMatchIdx])
+	m.setStatus(fmt.Sprintf("match %d/%d for /%s", m.logSearchMatchIdx+1, n, m.logSearchQuery), "244")
+}
+
+func statSize(path string) int64 {
+	st, err := os.Stat(path)
+	if err != nil {
+		return -1
+	}
+	return st.Size()
+}
+
+func humanizeBytes(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	unit := "B"
+	for _, u := range []string{"KB", "MB", "GB"} {
+		if value < 1024 {
+			break
+		}
+		value /= 1024
+		unit = u
+	}
+	if value < 10 {
+		return fmt.Sprintf("%.1f %s", value, unit)
+	}
+	return fmt.Sprintf("%.0f %s", value, unit)
+}
+
+func describeLogFile(path string, size int64) string {
+	if size < 0 {
+		return fmt.Sprintf("%s (not found)", path)
+	}
+	return fmt.Sprintf("%s (%s)", path, humanizeBytes(size))
+}
+
+func formatThousands(n int) string {
+	if n < 0 {
+		return "-" + formatThousands(-n)
+	}
+	digits := fmt.Sprintf("%d", n)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
+
+func paneTitle(label string, unread, lines, width int) string {
+	title := lipgloss.NewStyle().Bold(true).Render(label)
+	if unread > 0 {
+		title = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("220")).Render(fmt.Sprintf("%s (%s)", label, formatThousands(unread)))
+	}
+	count := lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(formatThousands(lines) + " lines")
+	gap := max(1, width-lipgloss.Width(title)-lipgloss.Width(count))
+	return title + strings.Repeat(" ", gap) + count
+}
+
+func isCountDigit(key, buf string) bool {
+	if len(key) != 1 || key[0] < '0' || key[0] > '9' {
+		return false
+	}
+	return key != "0" || buf != ""
+}
+
+func isCountedKey(key string) bool {
+	switch key {
+	case "up", "down", "k", "j", "g", "G":
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *model) takeCount() int {
+	n, err := strconv.Atoi(m.numBuf)
+	m.numBuf = ""
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+func (m *model) selectRow(idx int) {
+	if len(m.jobs) == 0 {
+		return
+	}
+	idx = max(0, min(idx, len(m.jobs)-1))
+	if idx == m.selectedIdx && m.jobs[idx].ID == m.selectedID {
+		return
+	}
+	m.selectedIdx = idx
+	m.selectedID = m.jobs[idx].ID
+	m.keepSelectionVisible()
+	m.switchToJob(m.jobs[idx])
+}
+
+func (m model) jobListRows() int {
+	rows := m.vpJobs.Height - 2 // header and rule rows stay pinned
+	if m.jobSearchBarVisible() {
+		rows--
+	}
+	return max(1, rows)
+}
+
+func (m model) jobSearchBarVisible() bool {
+	return m.jobSearchActive || m.jobSearch != ""
+}
+
+func (m *model) openJobSearch() tea.Cmd {
+	input := textinput.New()
+	input.Prompt = "/"
+	input.Placeholder = "job ID or name"
+	input.Cursor.SetMode(cursor.CursorStatic)
+	input.SetValue(m.jobSearch)
+	m.jobSearchInput = input
+	m.jobSearchActive = true
+	m.keepSelectionVisible()
+	m.renderJobsViewport()
+	return m.jobSearchInput.Focus()
+}
+
+func (m *model) clearJobSearch() {
+	m.jobSearch = ""
+	m.jobSearchActive = false
+	m.jobSearchInput.Blur()
+	m.renderJobsViewport()
+}
+
+func (m *model) handleJobSearchKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.clearJobSearch()
+		return nil
+	case "enter":
+		m.jobSearchActive = false
+		m.jobSearchInput.Blur()
+		if m.jobSearch == "" {
+			m.renderJobsViewport()
+			return nil
+		}
+		if job, ok := m.selectedJob(); !ok || !matchesJobSearch(job, m.jobSearch) {
+			for i, j := range m.jobs {
+				if matchesJobSearch(j, m.jobSearch) {
+					m.selectRow(i)
+					break
+				}
+			}
+		}
+		m.renderJobsViewport()
+		return nil
+	}
+	var cmd tea.Cmd
+	m.jobSearchInput, cmd = m.jobSearchInput.Update(msg)
+	m.jobSearch = strings.TrimSpace(m.jobSearchInput.Value())
+	m.renderJobsViewport()
+	return cmd
+}
+
+var jobSearchMatchStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("220"))
+
+// highlightMatch wraps the first case-insensitive occurrence of query in
+// the match style.
+func highlightMatch(cell, query string) string {
+	if query == "" {
+		return cell
+	}
+	lower := strings.ToLower(cell)
+	i := strings.Index(lower, strings.ToLower(query))
+	if i < 0 || len(lower) != len(cell) { // byte offsets only line up when case folding keeps lengths
+		return cell
+	}
+	end := i + len(query)
+	return cell[:i] + jobSearchMatchStyle.Render(cell[i:end]) + cell[end:]
+}
+
+func (m *model) clampJobListOffset() {
+	m.jobListOffset = max(0, min(m.jobListOffset, len(m.jobs)-m.jobListRows()))
+}
+
+func (m *model) keepSelectionVisible() {
+	rows := m.jobListRows()
+	if m.selectedIdx < m.jobListOffset {
+		m.jobListOffset = m.selectedIdx
+	} else if m.selectedIdx >= m.jobListOffset+rows {
+		m.jobListOffset = m.selectedIdx - rows + 1
+	}
+	m.clampJobListOffset()
+}
+
+func (m *model) scrollJobList(delta int) {
+	m.jobListOffset += delta
+	m.clampJobListOffset()
 }
 
 func isScrollKey(k string) bool {
@@ -352,6 +2266,177 @@ func isScrollKey(k string) bool {
 	}
 }
 
+func (m model) panelStyle() lipgloss.Style {
+	if m.cfg.CompactMode {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+}
+
+const (
+	defaultSplitRatio = 0.33
+	minSplitRatio     = 0.10
+	maxSplitRatio     = 0.90
+	splitRatioStep    = 0.05
+)
+
+// splitHeights divides the body between the jobs and log viewports; the
+// log panes keep one row for their titles.
+func splitHeights(bodyHeight int, ratio float64) (jobsHeight, logsHeight int) {
+	jobsHeight = max(3, int(float64(bodyHeight)*ratio))
+	logsHeight = max(3, bodyHeight-jobsHeight-1)
+	return jobsHeight, logsHeight
+}
+
+// adjustSplitRatio moves the split by delta, rounded to whole percents so
+// repeated steps don't drift.
+func adjustSplitRatio(ratio, delta float64) float64 {
+	ratio = math.Round((ratio+delta)*100) / 100
+	return math.Max(minSplitRatio, math.Min(ratio, maxSplitRatio))
+}
+
+func (m *model) layout() {
+	headerHeight, footerHeight, chrome, halfChrome := 5, 2, 4, 4
+	if m.cfg.CompactMode {
+		headerHeight, footerHeight, chrome, halfChrome = 2, 1, 0, 1 // one column between split panes
+	}
+	bodyHeight := max(8, m.height-headerHeight-footerHeight)
+	jobsHeight, logsHeight := splitHeights(bodyHeight, m.splitRatio)
+	if m.fullscreenLog {
+		// The hidden jobs pane keeps its size for when it comes back.
+		logsHeight = bodyHeight - 1
+		if !m.cfg.CompactMode {
+			logsHeight += 2 // the jobs pane border
+		}
+	}
+
+	if !m.vpReady {
+		m.vpJobs = viewport.New(max(20, m.width-chrome), jobsHeight)
+		m.vpOut = viewport.New(max(20, (m.width/2)-halfChrome), logsHeight)
+		m.vpErr = viewport.New(max(20, (m.width/2)-halfChrome), logsHeight)
+		m.vpMerged = viewport.New(max(20, m.width-chrome), logsHeight)
+		m.vpReady = true
+	} else {
+		if !m.fullscreenLog {
+			m.vpJobs.Width = max(20, m.width-chrome)
+			m.vpJobs.Height = jobsHeight
+		}
+		m.vpOut.Width = max(20, (m.width/2)-halfChrome)
+		m.vpOut.Height = logsHeight
+		m.vpErr.Width = max(20, (m.width/2)-halfChrome)
+		m.vpErr.Height = logsHeight
+		m.vpMerged.Width = max(20, m.width-chrome)
+		m.vpMerged.Height = logsHeight
+	}
+	m.outContentCache = "\x00"
+	m.errContentCache = "\x00"
+	m.mergedContentCache = "\x00"
+}
+
+// panesTop is the first screen row below the header, job and log info
+// lines.
+const panesTop = 3
+
+// jobsPaneTop is the screen row of the jobs viewport.
+func (m model) jobsPaneTop() int {
+	if m.cfg.CompactMode {
+		return panesTop
+	}
+	return panesTop + 1 // pane border
+}
+
+// logsPaneTop is the screen row where the log panes start, border
+// included.
+func (m model) logsPaneTop() int {
+	if m.fullscreenLog {
+		return panesTop
+	}
+	if m.cfg.CompactMode {
+		return m.jobsPaneTop() + m.vpJobs.Height
+	}
+	return m.jobsPaneTop() + m.vpJobs.Height + 1
+}
+
+// hitTestArea maps a screen cell to a focus area: 0 for the jobs pane, 1
+// and 2 for the stdout and stderr panes, -1 outside of them. The merged
+// pane counts as stdout.
+func hitTestArea(x, y int, m model) int {
+	logsBottom := m.logsPaneTop() + m.vpOut.Height + 1 // pane title row
+	if !m.cfg.CompactMode {
+		logsBottom += 2
+	}
+	switch {
+	case y < panesTop || y >= logsBottom || x < 0 || x >= m.width:
+		return -1
+	case y < m.logsPaneTop():
+		return 0
+	case m.mergedMode || x < m.width/2:
+		return 1
+	}
+	return 2
+}
+
+// hitTestJobRow returns the job list index at screen row clickY, or -1 on
+// the pinned header and rule rows.
+func hitTestJobRow(clickY, vpTopY, vpOffset int) int {
+	row := clickY - vpTopY - 2
+	if row < 0 {
+		return -1
+	}
+	return row + vpOffset
+}
+
+func (m *model) toggleFullscreenLog() {
+	if !m.fullscreenLog && m.focusArea == 0 {
+		m.setStatus("focus a log pane to maximize it", "220")
+		return
+	}
+	m.fullscreenLog = !m.fullscreenLog
+	if m.width > 0 {
+		m.layout()
+	}
+}
+
+func (m *model) handleMouse(msg tea.MouseMsg) {
+	if msg.Action != tea.MouseActionPress {
+		return
+	}
+	area := hitTestArea(msg.X, msg.Y, *m)
+	switch msg.Button {
+	case tea.MouseButtonLeft:
+		switch {
+		case area == 0:
+			m.setFocus(0)
+			idx := hitTestJobRow(msg.Y, m.jobsPaneTop(), m.jobListOffset)
+			if idx >= m.jobListOffset && idx < m.jobListOffset+m.jobListRows() && idx < len(m.jobs) {
+				m.selectRow(idx)
+			}
+		case area > 0:
+			m.setFocus(area)
+		}
+	case tea.MouseButtonWheelUp, tea.MouseButtonWheelDown:
+		delta := 3
+		if msg.Button == tea.MouseButtonWheelUp {
+			delta = -delta
+		}
+		switch {
+		case area == 0:
+			m.scrollJobList(delta)
+		case area > 0:
+			vp, follow := m.logViewport(area)
+			if delta < 0 {
+				vp.ScrollUp(-delta)
+				*follow = false
+			} else {
+				vp.ScrollDown(delta)
+			}
+			if !*follow && vp.AtBottom() {
+				*follow = true
+			}
+		}
+	}
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
@@ -359,37 +2444,28 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-
-		headerHeight := 4
-		footerHeight := 2
-		bodyHeight := max(8, m.height-headerHeight-footerHeight)
-		jobsHeight := max(5, bodyHeight/3)
-		logsHeight := max(4, bodyHeight-jobsHeight)
-
-		if !m.vpReady {
-			m.vpJobs = viewport.New(max(20, m.width-4), jobsHeight)
-			m.vpOut = viewport.New(max(20, (m.width/2)-4), logsHeight)
-			m.vpErr = viewport.New(max(20, (m.width/2)-4), logsHeight)
-			m.vpMerged = viewport.New(max(20, m.width-4), logsHeight)
-			m.vpReady = true
-		} else {
-			m.vpJobs.Width = max(20, m.width-4)
-			m.vpJobs.Height = jobsHeight
-			m.vpOut.Width = max(20, (m.width/2)-4)
-			m.vpOut.Height = logsHeight
-			m.vpErr.Width = max(20, (m.width/2)-4)
-			m.vpErr.Height = logsHeight
-			m.vpMerged.Width = max(20, m.width-4)
-			m.vpMerged.Height = logsHeight
-		}
-		m.outContentCache = "\x00"
-		m.errContentCache = "\x00"
-		m.mergedContentCache = "\x00"
+		m.layout()
 
 	case jobMsg:
-		now := time.Now()
-		m.store.ApplySnapshot(msg, now)
-		m.jobs = m.store.VisibleJobs()
+		now := m.now()
+		changes := m.store.ApplySnapshotWithDiff(msg, now)
+		for _, change := range changes {
+			if change.OldState != "" && isTerminalState(change.NewState) {
+				cmds = append(cmds, fetchAccountingCmd(change.JobID, m.cfg.Cluster))
+				if !m.efficiencyRequested[change.JobID] {
+					cmds = append(cmds, m.requestEfficiency(change.JobID))
+				}
+			}
+		}
+		if m.cfg.VisualBell {
+			for _, change := range changes {
+				if isAlertState(change.NewState) {
+					m.flashUntil = now.Add(flashDuration)
+					m.flashJobID = change.JobID
+				}
+			}
+		}
+		m.jobs = m.visibleJobs()
 		m.ensureSelectionByID()
 		if job, ok := m.selectedJob(); ok && job.ID != m.selectedID {
 			m.selectedID = job.ID
@@ -402,30 +2478,189 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		m.lastJobFetch = now
-		m.statusText = fmt.Sprintf("jobs refreshed at %s", now.Format("15:04:05"))
-		m.statusColor = "42"
+		m.isRefreshing = false
 
 	case errMsg:
 		m.err = msg
-		m.statusText = fmt.Sprintf("squeue error: %v", msg)
-		m.statusColor = "196"
+		m.isRefreshing = false
+		m.setError(fmt.Sprintf("squeue error: %v", msg))
+
+	case accountingMsg:
+		if msg.err == nil {
+			m.store.SetAccounting(msg.jobID, msg.info)
+		}
+
+	case efficiencyMsg:
+		if msg.err == nil {
+			m.store.SetEfficiency(msg.jobID, msg.report)
+		}
+
+	case sstatMsg:
+		if msg.err != nil {
+			break
+		}
+		m.store.SetLiveStats(msg.jobID, msg.stats)
+		if job, ok := m.selectedJob(); ok && job.ID == msg.jobID {
+			m.recordLiveStatsSample(job, msg.stats)
+		}
+
+	case sinfoMsg:
+		m.sinfoFetching = false
+		if !m.sinfoOpen() {
+			break
+		}
+		if msg.err != nil {
+			m.modal.lines = []string{msg.err.Error()}
+			break
+		}
+		m.modal.lines = sinfoLines(msg.partitions)
+		m.modal.offset = min(m.modal.offset, max(0, len(m.modal.lines)-m.modalBodyHeight()))
+
+	case nodeDetailMsg:
+		if m.modal == nil || m.modal.kind != "node" || m.modal.nodes[m.modal.nodeIdx] != msg.node {
+			break
+		}
+		if msg.err != nil {
+			m.modal.lines = append(m.nodePickerLines(), msg.err.Error())
+			break
+		}
+		m.modal.lines = append(m.nodePickerLines(), nodeDetailLines(msg.detail)...)
+
+	case batchScriptMsg:
+		if m.modal == nil || m.modal.kind != "script" || m.modal.jobID != msg.jobID {
+			break
+		}
+		if msg.err != nil {
+			m.modal.lines = []string{msg.err.Error(), "", "Slurm only keeps batch scripts while the controller knows the job."}
+			break
+		}
+		if strings.TrimSpace(msg.script) == "" {
+			m.modal.lines = []string{"job " + msg.jobID + " has no batch script"}
+			break
+		}
+		m.modal.lines = strings.Split(highlightBatchScript(msg.script), "\n")
+
+	case jobDetailMsg:
+		if msg.err != nil {
+			m.setError(msg.err.Error())
+			break
+		}
+		rec, _ := m.store.Record(msg.jobID)
+		m.openModal("Job "+msg.jobID, jobDetailLines(msg.detail, rec, m.now(), m.clusterTZ))
+
+	case statusMsg:
+		if msg.color == "196" {
+			m.setError(msg.text)
+		} else {
+			m.setStatus(msg.text, msg.color)
+		}
+
+	case batchCancelMsg:
+		if msg.err != nil {
+			m.setError(msg.err.Error())
+		} else {
+			m.setStatus(fmt.Sprintf("cancel signal sent for %d jobs", len(msg.jobIDs)), "42")
+		}
+		cmds = append(cmds, m.forceRefresh())
+
+	case signalMsg:
+		if msg.err != nil {
+			m.setError(msg.err.Error())
+			break
+		}
+		m.setStatus(fmt.Sprintf("sent SIG%s to %s", msg.signal, msg.jobID), "42")
+
+	case jobActionMsg:
+		if msg.err != nil {
+			m.setError(msg.err.Error())
+			break
+		}
+		m.setStatus(confirmDoneText(msg.action), "42")
+		cmds = append(cmds, m.forceRefresh())
+
+	case submitMsg:
+		if msg.err != nil {
+			m.setError(msg.err.Error())
+			break
+		}
+		m.setStatus(fmt.Sprintf("submitted batch job %s", msg.jobID), "42")
+		cmds = append(cmds, m.forceRefresh())
+
+	case clusterMetaMsg:
+		if msg.err != nil {
+			m.setStatus("cluster config unavailable", "244")
+			break
+		}
+		m.clusterMeta = msg.meta
+
+	case globalSearchMsg:
+		if !m.globalSearch {
+			break
+		}
+		m.globalSearchQuery = msg.query
+		m.globalSearchResults = msg.results
+		m.globalSearchIdx, m.globalSearchOffset = 0, 0
+		m.setStatus(fmt.Sprintf("%d matches for %q", len(msg.results), msg.query), "42")
 
 	case tickMsg:
-		if m.lastJobFetch.IsZero() || time.Since(m.lastJobFetch) >= jobsRefreshEvery {
-			cmds = append(cmds, fetchJobsCmd())
+		m.expireStatus(m.now())
+		if m.following() {
+			m.blinkTicks++
+			if m.blinkTicks%2 == 0 {
+				m.blinkState = !m.blinkState
+			}
+		}
+		dismissed := m.store.AutoDismissExpired(m.now(), m.cfg.TerminalRetentionDuration, m.cfg.AutoDismissStates)
+		if len(m.cfg.AutoDismissStates) > 0 {
+			dismissed = append(dismissed, m.store.DismissExpiredByState(m.cfg.AutoDismissStates, m.now())...)
+		}
+		if len(dismissed) > 0 {
+			m.refreshVisibleJobs()
+		}
+		if !m.isRefreshing && (m.lastJobFetch.IsZero() || m.timeUntilRefresh() == 0) {
+			cmds = append(cmds, m.startRefresh())
+		}
+		if m.sinfoOpen() && !m.sinfoFetching && m.now().Sub(m.lastSinfoFetch) >= m.jobsRefreshEvery {
+			cmds = append(cmds, m.refreshSinfo())
+		}
+		if cmd := m.pollSelectedLogs(); cmd != nil {
+			cmds = append(cmds, cmd)
 		}
-		m.pollSelectedLogs()
 		cmds = append(cmds, waitForTick())
 
+	case remoteLogsMsg:
+		m.applyRemoteLogs(msg)
+
+	case logPathsMsg:
+		if m.applyLogPaths(msg) {
+			if cmd := m.pollSelectedLogs(); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+
+	case tea.MouseMsg:
+		if m.modal != nil || m.pending != nil || m.submit != nil || m.signal != nil || m.globalSearch || !m.vpReady {
+			break
+		}
+		m.handleMouse(msg)
+
 	case tea.KeyMsg:
 		key := msg.String()
 		switch key {
 		case "ctrl+c":
-			return m, tea.Quit
+			m.saveCheckpoints()
+			return m, tea.Sequence(m.saveStoreCmd(), tea.Quit)
+		}
+
+		if m.modal != nil {
+			if cmd := m.handleModalKey(key); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+			break
 		}
 
-		if m.cancelConfirm {
-			if cmd, consumed := m.handleCancelConfirmKey(key); consumed {
+		if m.pending != nil {
+			if cmd, consumed := m.handleConfirmKey(key); consumed {
 				if cmd != nil {
 					cmds = append(cmds, cmd)
 				}
@@ -433,136 +2668,703 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		if m.submit != nil {
+			if cmd := m.handleSubmitKey(msg); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+			break
+		}
+
+		if m.signal != nil {
+			if cmd := m.handleSignalKey(msg); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+			break
+		}
+
+		if m.globalSearch {
+			if cmd := m.handleGlobalSearchKey(msg); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+			break
+		}
+
+		if m.logSearchTyping {
+			if cmd := m.handleLogSearchKey(msg); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+			break
+		}
+
+		if m.jobSearchActive {
+			if cmd := m.handleJobSearchKey(msg); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+			break
+		}
+
+		if msg.Paste {
+			m.applyPastedFilter(string(msg.Runes))
+			break
+		}
+
+		if alias, ok := m.cfg.KeyBindings[key]; ok {
+			key = alias
+		}
+
+		if m.focusArea == 0 && isCountDigit(key, m.numBuf) {
+			m.numBuf += key
+			break
+		}
+		if key != "g" {
+			m.pendingG = false
+		}
+		if !isCountedKey(key) {
+			m.numBuf = ""
+		}
+
 		switch key {
 		case "q":
-			return m, tea.Quit
+			m.saveCheckpoints()
+			return m, tea.Sequence(m.saveStoreCmd(), tea.Quit)
+		case "?":
+			m.toggleHelp()
 		case "r":
-			cmds = append(cmds, fetchJobsCmd())
+			cmds = append(cmds, m.forceRefresh())
+		case "e":
+			if m.focusArea == 0 {
+				m.toggleArrayExpansion()
+			}
+		case "p":
+			cmds = append(cmds, m.togglePartitionFilter())
+		case "o":
+			if m.focusArea == 0 {
+				m.cycleSort()
+			}
+		case "F":
+			m.cycleStateFilter()
+		case "/":
+			if m.focusArea == 0 {
+				cmds = append(cmds, m.openJobSearch())
+			} else {
+				cmds = append(cmds, m.openLogSearch())
+			}
+		case "N":
+			if m.focusArea != 0 && m.logSearchQuery != "" {
+				m.jumpToLogMatch(-1)
+			}
+		case "esc":
+			if m.jobSearch != "" {
+				m.clearJobSearch()
+			} else if m.logSearchQuery != "" {
+				m.clearLogSearch()
+			} else if m.fullscreenLog {
+				m.toggleFullscreenLog()
+			}
+		case "Z":
+			m.toggleFullscreenLog()
+		case "s":
+			m.openSubmitForm()
+		case "n":
+			if m.focusArea != 0 && m.logSearchQuery != "" {
+				m.jumpToLogMatch(1)
+				break
+			}
+			if job, ok := m.selectedJob(); ok {
+				if job.State != "RUNNING" {
+					m.setStatus("node details only work for RUNNING jobs", "220")
+					break
+				}
+				cmds = append(cmds, m.openNodeDetail(job))
+			}
+		case "i":
+			if job, ok := m.selectedJob(); ok {
+				cmds = append(cmds, fetchJobDetailCmd(job.ID, m.cfg.Cluster))
+			}
+		case "I":
+			cmds = append(cmds, m.openSinfo())
+		case "P":
+			if job, ok := m.selectedJob(); ok {
+				cmds = append(cmds, m.openBatchScript(job))
+			}
+		case "ctrl+o":
+			m.openClusterConfig()
+		case "ctrl+b":
+			m.cfg.CompactMode = !m.cfg.CompactMode
+			if m.width > 0 {
+				m.layout()
+			}
+		case "+", "-":
+			delta := splitRatioStep
+			if key == "-" {
+				delta = -delta
+			}
+			m.splitRatio = adjustSplitRatio(m.splitRatio, delta)
+			if m.width > 0 {
+				m.layout()
+			}
+			m.setStatus(fmt.Sprintf("split: %d%%", int(math.Round(m.splitRatio*100))), "244")
+		case "L":
+			m.toggleLineNumbers()
+		case "T":
+			m.mergedBuf.showTimestamps = !m.mergedBuf.showTimestamps
+			m.mergedContentCache = "\x00"
+			if job, ok := m.selectedJob(); ok {
+				m.renderLogViewports(job)
+			}
+		case "C":
+			m.colorizeLog = !m.colorizeLog
+			if job, ok := m.selectedJob(); ok {
+				m.renderLogViewports(job)
+			}
+		case "E":
+			if cmd := m.exportFocusedLog(); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		case "ctrl+s":
+			if name, err := writeJobsJSON(m.store.AllRecords(), ".", m.now()); err != nil {
+				m.setError(fmt.Sprintf("save jobs: %v", err))
+			} else {
+				m.setStatus("Saved to "+name, "42")
+			}
+		case "ctrl+g":
+			cmds = append(cmds, m.openGlobalSearch())
 		case "m":
 			m.mergedMode = !m.mergedMode
 		case "f":
-			m.follow = !m.follow
-			if m.follow && m.vpReady {
+			m.setFollow(!m.following())
+			if m.following() && m.vpReady {
 				m.vpOut.GotoBottom()
 				m.vpErr.GotoBottom()
 				m.vpMerged.GotoBottom()
 			}
-		case "tab":
-			m.focusArea = (m.focusArea + 1) % 3
-		case "shift+tab":
-			m.focusArea = (m.focusArea + 2) % 3
+		case "tab", "shift+tab":
+			switch {
+			case m.fullscreenLog:
+				m.setFocus(3 - m.focusArea) // the jobs pane is hidden
+			case key == "tab":
+				m.setFocus((m.focusArea + 1) % 3)
+			default:
+				m.setFocus((m.focusArea + 2) % 3)
+			}
 		case "up", "k":
 			if m.focusArea == 0 {
-				if m.selectedIdx > 0 {
-					m.selectedIdx--
-					m.selectedID = m.jobs[m.selectedIdx].ID
-					m.switchToJob(m.jobs[m.selectedIdx])
-				}
+				m.selectRow(m.selectedIdx - m.takeCount())
 			}
 		case "down", "j":
 			if m.focusArea == 0 {
-				if m.selectedIdx < len(m.jobs)-1 {
-					m.selectedIdx++
-					m.selectedID = m.jobs[m.selectedIdx].ID
-					m.switchToJob(m.jobs[m.selectedIdx])
+				m.selectRow(m.selectedIdx + m.takeCount())
+			}
+		case "g":
+			if m.focusArea == 0 {
+				if !m.pendingG {
+					m.pendingG = true
+					break
+				}
+				m.pendingG = false
+				explicit := m.numBuf != ""
+				n := m.takeCount()
+				if explicit {
+					m.selectRow(n - 1)
+				} else {
+					m.selectRow(0)
+				}
+			}
+		case "pgup", "pgdown":
+			if m.focusArea == 0 {
+				page := m.jobListRows()
+				if key == "pgup" {
+					page = -page
+				}
+				m.scrollJobList(page)
+			}
+		case "G":
+			if m.focusArea == 0 {
+				explicit := m.numBuf != ""
+				n := m.takeCount()
+				if explicit {
+					m.selectRow(n - 1)
+				} else {
+					m.selectRow(len(m.jobs) - 1)
 				}
 			}
+		case " ":
+			if m.focusArea == 0 {
+				m.toggleMarked()
+			}
 		case "c":
+			if len(m.selected) >= 2 {
+				m.armBatchCancel()
+				break
+			}
 			if job, ok := m.selectedJob(); ok {
 				if !isActiveState(job.State) {
-					m.statusText = "cancel only works for RUNNING/PENDING jobs"
-					m.statusColor = "220"
+					m.setStatus("cancel only works for RUNNING/PENDING jobs", "220")
+					break
+				}
+				m.armConfirm("cancel", job.ID)
+			}
+		case "S":
+			if job, ok := m.selectedJob(); ok {
+				if job.State != "RUNNING" {
+					m.setStatus("signal only works for RUNNING jobs", "220")
+					break
+				}
+				cmds = append(cmds, m.openSignalPrompt(job.ID))
+			}
+		case "R":
+			if job, ok := m.selectedJob(); ok {
+				if job.State == "PENDING" {
+					m.setStatus(fmt.Sprintf("job %s is still pending; requeue only works for running or finished jobs", job.ID), "220")
+					break
+				}
+				m.armConfirm("requeue", job.ID)
+			}
+		case "h", "H":
+			if job, ok := m.selectedJob(); ok {
+				kind := map[string]string{"h": "hold", "H": "release"}[key]
+				if job.State != "PENDING" {
+					m.setStatus(kind+" only works for PENDING jobs", "220")
 					break
 				}
-				m.armCancelConfirm(job.ID)
+				m.armConfirm(kind, job.ID)
 			}
 		case "d":
+			if len(m.selected) >= 2 {
+				m.batchDismiss()
+				break
+			}
 			if job, ok := m.selectedJob(); ok {
 				if m.store.DismissIfTerminal(job.ID) {
-					m.jobs = m.store.VisibleJobs()
-					prev := m.selectedID
-					m.ensureSelectionByID()
-					if next, ok := m.selectedJob(); ok && next.ID != prev {
-						m.selectedID = next.ID
-						m.switchToJob(next)
-					}
-					m.statusText = fmt.Sprintf("dismissed %s", job.ID)
-					m.statusColor = "244"
+					m.refreshVisibleJobs()
+					m.setStatus(fmt.Sprintf("dismissed %s", job.ID), "244")
 				} else {
-					m.statusText = "dismiss only works for terminal jobs"
-					m.statusColor = "220"
+					m.setStatus("dismiss only works for terminal jobs", "220")
 				}
 			}
 		case "D":
 			m.store.ClearDismissedAndTerminal()
-			m.jobs = m.store.VisibleJobs()
-			prev := m.selectedID
-			m.ensureSelectionByID()
-			if next, ok := m.selectedJob(); ok && next.ID != prev {
-				m.selectedID = next.ID
-				m.switchToJob(next)
+			m.refreshVisibleJobs()
+			m.setStatus("cleared terminal jobs", "244")
+		case "x":
+			if m.filterActive() {
+				m.filterStates = nil
+				m.filterName = ""
+				m.refreshVisibleJobs()
+				m.setStatus("filter cleared", "244")
 			}
-			m.statusText = "cleared terminal jobs"
-			m.statusColor = "244"
 		}
 
 		if m.vpReady {
-			if m.focusArea == 0 {
-				m.vpJobs, _ = m.vpJobs.Update(msg)
-			} else if m.mergedMode {
-				m.vpMerged, _ = m.vpMerged.Update(msg)
-			} else if m.focusArea == 1 {
-				m.vpOut, _ = m.vpOut.Update(msg)
-			} else {
-				m.vpErr, _ = m.vpErr.Update(msg)
-			}
-
-			if isScrollKey(key) && m.focusArea != 0 {
-				m.follow = false
-			}
-			if !m.follow {
-				if m.mergedMode && m.vpMerged.AtBottom() {
-					m.follow = true
-				} else if !m.mergedMode && ((m.focusArea == 1 && m.vpOut.AtBottom()) || (m.focusArea == 2 && m.vpErr.AtBottom())) {
-					m.follow = true
+			// The jobs viewport only holds the visible window; job list
+			// navigation is handled above through jobListOffset.
+			if m.focusArea != 0 {
+				vp, follow := m.focusedLogViewport()
+				*vp, _ = vp.Update(msg)
+				if isScrollKey(key) {
+					*follow = false
+				}
+				if !*follow && vp.AtBottom() {
+					*follow = true
 				}
 			}
 		}
 	}
 
+	if cmd := m.maybeFetchEfficiency(); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+	if cmd := m.maybeFetchSstat(); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+	if cmd := m.maybeDiscoverLogPaths(); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
 	m.renderJobsViewport()
 	return m, tea.Batch(cmds...)
 }
 
+type jobColumn struct {
+	title string
+	width int
+	value func(Job) string
+}
+
+var columnSortFields = map[string]string{
+	"JOB ID": "id",
+	"NAME":   "name",
+	"STATE":  "state",
+	"TIME":   "time",
+	"NODE":   "node",
+}
+
+// pendingNote is the "(Reason) Pos: n" suffix shown after PENDING.
+func pendingNote(j Job) string {
+	if j.State != "PENDING" {
+		return ""
+	}
+	var parts []string
+	if j.Reason != "" && j.Reason != "None" {
+		parts = append(parts, "("+j.Reason+")")
+	}
+	if j.Position > 0 {
+		parts = append(parts, fmt.Sprintf("Pos: %d", j.Position))
+	}
+	return strings.Join(parts, " ")
+}
+
+func stateCell(j Job) string {
+	if note := pendingNote(j); note != "" {
+		return j.State + " " + note
+	}
+	return j.State
+}
+
+// stateColumnWidth grows the STATE column only while a visible job carries
+// a pending note, so the common layout keeps room for TIME, NODE and AGE.
+func (m *model) stateColumnWidth() int {
+	width := 11
+	for _, j := range m.jobs {
+		width = max(width, lipgloss.Width(stateCell(j)))
+	}
+	return min(width, 28)
+}
+
+func (m *model) jobColumns() []jobColumn {
+	cols := []jobColumn{
+		{"JOB ID", 9, func(j Job) string { return j.ID }},
+		{"NAME", 16, func(j Job) string { return j.Name }},
+	}
+	if m.cfg.showsOtherUsers() {
+		cols = append(cols, jobColumn{"USER", 10, func(j Job) string { return j.User }})
+	}
+	cols = append(cols, []jobColumn{
+		{"STATE", m.stateColumnWidth(), stateCell},
+	}...)
+	if m.cfg.hasColumn("partition") {
+		cols = append(cols, jobColumn{"PARTITION", 10, func(j Job) string { return j.Partition }})
+	}
+	cols = append(cols, []jobColumn{
+		{"TIME", 10, func(j Job) string { return j.Time }},
+		{"NODE", 12, func(j Job) string { return j.Nodes }},
+		{"AGE", 7, func(j Job) string {
+			if rec, ok := m.store.Record(j.ID); ok {
+				return formatDuration(m.now().Sub(rec.FirstSeen))
+			}
+			return ""
+		}},
+	}...)
+	if m.cfg.hasColumn("account") {
+		cols = append(cols, jobColumn{"ACCOUNT", 10, func(j Job) string { return j.Account }})
+	}
+	if m.cfg.hasColumn("qos") {
+		cols = append(cols, jobColumn{"QOS", 8, func(j Job) string { return j.QOS }})
+	}
+	if m.cfg.hasColumn("gpu") {
+		cols = append(cols, jobColumn{"GPU", 4, func(j Job) string {
+			if n := gpuCount(j.TRES); n > 0 {
+				return fmt.Sprintf("%d", n)
+			}
+			return ""
+		}})
+	}
+	m.fitStateColumn(cols)
+	return cols
+}
+
+// fitStateColumn gives back the pending-note growth of STATE when it would
+// push TIME out of the viewport.
+func (m *model) fitStateColumn(cols []jobColumn) {
+	if m.vpJobs.Width <= 0 {
+		return
+	}
+	state, used := -1, 2
+	for i, col := range cols {
+		if col.title == "STATE" {
+			state = i
+		}
+		used += col.width
+		if col.title == "TIME" {
+			break
+		}
+		used += 3
+	}
+	if state < 0 {
+		return
+	}
+	if over := used - m.vpJobs.Width; over > 0 {
+		cols[state].width = max(11, cols[state].width-over)
+	}
+}
+
+func reasonColor(reason string) lipgloss.Color {
+	switch reason {
+	case "Priority", "Resources":
+		return lipgloss.Color("220")
+	case "Dependency", "DependencyNeverSatisfied", "PartitionTimeLimit":
+		return lipgloss.Color("196")
+	}
+	return lipgloss.Color("244")
+}
+
+// timeLimitColor warns when a running job nears its time limit.
+func timeLimitColor(fraction float64) (lipgloss.Color, bool) {
+	switch {
+	case fraction > 0.95:
+		return lipgloss.Color("196"), true
+	case fraction > 0.8:
+		return lipgloss.Color("208"), true
+	}
+	return "", false
+}
+
+// formatDuration renders d with its two largest units, e.g. 45s, 12m5s,
+// 2h15m or 3d4h.
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Truncate(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%ds", int(d.Minutes()), int(d.Seconds())%60)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
+
+func fitCell(s string, width int) string {
+	if lipgloss.Width(s) > width {
+		s = ansi.Truncate(s, width, "...")
+	}
+	return padOrTrimToWidth(s, width)
+}
+
 func (m *model) renderJobsViewport() {
-	if !m.vpReady {
+	if !m.vpReady || m.fullscreenLog {
+		return
+	}
+	if m.globalSearch {
+		m.renderGlobalSearchResults()
 		return
 	}
 	if len(m.jobs) == 0 {
+		if m.stateFilter != "" {
+			m.vpJobs.SetContent(fmt.Sprintf("No %s jobs. Press [F] to change the filter.", m.stateFilter))
+			return
+		}
 		m.vpJobs.SetContent("No jobs yet. Press [r] to refresh.")
 		return
 	}
 
-	head := fmt.Sprintf("%-2s %-9s %-18s %-11s %-10s %-14s", "", "JOB ID", "NAME", "STATE", "TIME", "NODE")
-	rows := []string{head}
-	for i, j := range m.jobs {
-		marker := " "
-		if i == m.selectedIdx {
-			marker = ">"
+	cols := m.jobColumns()
+	gpuColumn := m.cfg.hasColumn("gpu")
+	sep := columnSepStyle.Render(" │ ")
+
+	titles := make([]string, len(cols))
+	rules := make([]string, len(cols))
+	for c, col := range cols {
+		title := col.title
+		if m.sortField != "" && columnSortFields[title] == m.sortField {
+			title += map[bool]string{true: " ▲", false: " ▼"}[m.sortAsc]
+		}
+		titles[c] = fitCell(title, col.width)
+		rules[c] = strings.Repeat("─", col.width)
+	}
+	rows := []string{
+		"  " + strings.Join(titles, sep),
+		columnSepStyle.Render("──" + strings.Join(rules, "─┼─")),
+	}
+	if m.vpJobs.YOffset != 0 {
+		m.scrollJobList(m.vpJobs.YOffset)
+		m.vpJobs.SetYOffset(0)
+	}
+	m.clampJobListOffset()
+	end := min(m.jobListOffset+m.jobListRows()+1, len(m.jobs))
+	for i := m.jobListOffset; i < end; i++ {
+		j := m.jobs[i]
+		selected := i == m.selectedIdx
+		marked := m.selected[j.ID]
+		marker := "  "
+		rowSep := sep
+		if selected {
+			marker = "> "
+			rowSep = " │ "
+		}
+		if marked {
+			marker = marker[:1] + "*"
+		}
+		searching := m.jobSearch != ""
+		match := searching && matchesJobSearch(j, m.jobSearch)
+		cells := make([]string, len(cols))
+		for c, col := range cols {
+			cells[c] = fitCell(col.value(j), col.width)
+			if match && !selected && (col.title == "JOB ID" || col.title == "NAME") {
+				cells[c] = highlightMatch(cells[c], m.jobSearch)
+			}
+			if note := pendingNote(j); col.title == "STATE" && note != "" && !selected && !marked {
+				styled := j.State + " " + lipgloss.NewStyle().Foreground(reasonColor(j.Reason)).Render(note)
+				cells[c] = fitCell(styled, col.width)
+			}
+			if col.title == "TIME" && !selected && !marked && j.State == "RUNNING" {
+				if color, ok := timeLimitColor(timeUsedFraction(j.Time, j.TimeLimit)); ok {
+					cells[c] = lipgloss.NewStyle().Foreground(color).Render(cells[c])
+				}
+			}
+		}
+		row := marker + strings.Join(cells, rowSep)
+		if gpus := gpuCount(j.TRES); !gpuColumn && gpus > 0 {
+			badge := fmt.Sprintf("[G:%d]", gpus)
+			if !selected {
+				badge = gpuBadgeStyle.Render(badge)
+			}
+			row += " " + badge
+		}
+		if m.flashing() && j.ID == m.flashJobID {
+			row = flashRowStyle.Render(padOrTrimToWidth(ansi.Strip(row), m.vpJobs.Width))
+		} else if selected {
+			row = selectedRowStyle.Render(padOrTrimToWidth(row, m.vpJobs.Width))
+		} else if marked {
+			row = markedRowStyle.Render(padOrTrimToWidth(row, m.vpJobs.Width))
+		} else if searching && !match {
+			row = lipgloss.NewStyle().Faint(true).Render(ansi.Strip(row))
 		}
-		name := j.Name
-		if len(name) > 18 {
-			name = name[:15] + "..."
+		rows = append(rows, row)
+	}
+	if m.jobSearchBarVisible() {
+		rows = rows[:min(len(rows), 2+m.jobListRows())]
+		for len(rows) < 2+m.jobListRows() {
+			rows = append(rows, "")
+		}
+		bar := m.jobSearchInput.View()
+		if !m.jobSearchActive {
+			bar = "/" + m.jobSearch + lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render("  [/] edit  [esc] clear")
+		}
+		rows = append(rows, bar)
+	}
+	m.vpJobs.SetContent(strings.Join(rows, "\n"))
+}
+
+func (m *model) renderGlobalSearchResults() {
+	rows := []string{m.globalSearchInput.View(), ""}
+	switch {
+	case m.globalSearchInput.Focused():
+		rows = append(rows, "[enter] search  [esc] close")
+	case len(m.globalSearchResults) == 0:
+		rows = append(rows, fmt.Sprintf("No matches for %q. [/] edit query  [esc] close", m.globalSearchQuery))
+	default:
+		m.keepSearchResultVisible(m.vpJobs.Height - len(rows))
+		end := min(m.globalSearchOffset+max(1, m.vpJobs.Height-len(rows)), len(m.globalSearchResults))
+		for i := m.globalSearchOffset; i < end; i++ {
+			r := m.globalSearchResults[i]
+			marker := " "
+			if i == m.globalSearchIdx {
+				marker = ">"
+			}
+			rows = append(rows, fmt.Sprintf("%-2s %-9s %-4s %-7s %s", marker, r.JobID, r.Stream, fmt.Sprintf("L%d", r.LineIdx+1), r.Preview))
 		}
-		rows = append(rows, fmt.Sprintf("%-2s %-9s %-18s %-11s %-10s %-14s", marker, j.ID, name, j.State, j.Time, j.Nodes))
 	}
 	m.vpJobs.SetContent(strings.Join(rows, "\n"))
+	m.vpJobs.SetYOffset(0)
+}
+
+// keepSearchResultVisible scrolls the result list so the selected result is
+// one of the rows shown below the query.
+func (m *model) keepSearchResultVisible(rows int) {
+	rows = max(1, rows)
+	if m.globalSearchIdx < m.globalSearchOffset {
+		m.globalSearchOffset = m.globalSearchIdx
+	} else if m.globalSearchIdx >= m.globalSearchOffset+rows {
+		m.globalSearchOffset = m.globalSearchIdx - rows + 1
+	}
+	m.globalSearchOffset = max(0, min(m.globalSearchOffset, len(m.globalSearchResults)-rows))
+}
+
+const usageSampleCap = 60
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+func pushSample(samples []float64, v float64) []float64 {
+	if len(samples) == usageSampleCap {
+		copy(samples, samples[1:])
+		samples[len(samples)-1] = v
+		return samples
+	}
+	if samples == nil {
+		samples = make([]float64, 0, usageSampleCap)
+	}
+	return append(samples, v)
+}
+
+func (m *model) recordUsageSample(cpu, mem float64) {
+	m.cpuSamples = pushSample(m.cpuSamples, cpu)
+	m.memSamples = pushSample(m.memSamples, mem)
+}
+
+func renderSparkline(samples []float64, width int) string {
+	if width <= 0 || len(samples) == 0 {
+		return ""
+	}
+	if len(samples) > width {
+		samples = samples[len(samples)-width:]
+	}
+	lo, hi := samples[0], samples[0]
+	for _, v := range samples {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	var b strings.Builder
+	for _, v := range samples {
+		idx := 0
+		if hi > lo {
+			idx = int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
+
+// recordLiveStatsSample feeds sstat results into the usage sparklines as
+// CPU seconds per wall second and resident memory in bytes.
+func (m *model) recordLiveStatsSample(job Job, stats LiveStats) {
+	raw, _, _ := strings.Cut(stats.AveCPU, ".")
+	used, err := parseSlurmDuration(raw)
+	if err != nil {
+		return
+	}
+	wall, err := parseSlurmDuration(job.Time)
+	if err != nil || wall <= 0 {
+		return
+	}
+	rss, _ := parseSlurmSize(stats.MaxRSS, 'K')
+	m.recordUsageSample(used.Seconds()/wall.Seconds(), float64(rss))
+}
+
+func (m model) usageSparklines() string {
+	if len(m.cpuSamples) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("CPU: %s  MEM: %s", renderSparkline(m.cpuSamples, 20), renderSparkline(m.memSamples, 20))
 }
 
 func (m model) View() string {
-	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("69")).Render("slurm-tui")
+	titleText := "slurm-tui"
+	if name := m.clusterName(); name != "" {
+		titleText += " [" + name + "]"
+	}
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("69")).Render(titleText)
 	subtitle := "Queue + logs monitor"
 	header := title + "  " + subtitle
+	if scope := m.scopeBadge(); scope != "" {
+		header += "  " + scope
+	}
+	if badge := m.filterBadge(); badge != "" {
+		header += "  " + badge
+	}
 
 	if m.err != nil {
 		header += lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("  (degraded: squeue unavailable)")
@@ -572,8 +3374,10 @@ func (m model) View() string {
 		return header + "\n\nInitializing..."
 	}
 
-	jobsBorder := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
-	if m.focusArea == 0 {
+	jobsBorder := m.panelStyle()
+	if m.flashing() {
+		jobsBorder = jobsBorder.BorderForeground(lipgloss.Color("196"))
+	} else if m.focusArea == 0 {
 		jobsBorder = jobsBorder.BorderForeground(lipgloss.Color("69"))
 	} else {
 		jobsBorder = jobsBorder.BorderForeground(lipgloss.Color("240"))
@@ -585,20 +3389,41 @@ func (m model) View() string {
 	if job, ok := m.selectedJob(); ok {
 		state := lipgloss.NewStyle().Foreground(getJobColor(job.State)).Render(job.State)
 		jobInfo = fmt.Sprintf("Job %s  %s  Node:%s", job.ID, state, job.Nodes)
+		if spark := m.usageSparklines(); spark != "" && job.State == "RUNNING" {
+			jobInfo += "  " + lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render(spark)
+		}
+		if rec, ok := m.store.Record(job.ID); ok && rec.LiveStats != nil && job.State == "RUNNING" {
+			jobInfo += "  " + lipgloss.NewStyle().Faint(true).Render(rec.LiveStats.Summary(job.Time))
+		}
+		if rec, ok := m.store.Record(job.ID); ok && rec.Efficiency != nil {
+			jobInfo += "  " + renderEfficiency(*rec.Efficiency)
+		}
+		if rec, ok := m.store.Record(job.ID); ok && rec.Accounting != nil {
+			jobInfo += "  " + lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(rec.Accounting.String())
+		}
+	}
+	logInfo := ""
+	if m.logOutPath != "" || m.logErrPath != "" {
+		logInfo = lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(fmt.Sprintf(
+			"stdout: %s  stderr: %s",
+			describeLogFile(m.logOutPath, m.logOutSize),
+			describeLogFile(m.logErrPath, m.logErrSize),
+		))
 	}
 
 	var logsPanel string
 	if m.mergedMode {
-		border := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+		border := m.panelStyle()
 		if m.focusArea != 0 {
 			border = border.BorderForeground(lipgloss.Color("69"))
 		} else {
 			border = border.BorderForeground(lipgloss.Color("240"))
 		}
-		logsPanel = border.Render(m.vpMerged.View())
+		title := paneTitle("MERGED", 0, len(m.mergedBuf.lines), m.vpMerged.Width)
+		logsPanel = border.Render(title + "\n" + m.vpMerged.View())
 	} else {
-		left := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
-		right := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+		left := m.panelStyle()
+		right := m.panelStyle()
 		if m.focusArea == 1 {
 			left = left.BorderForeground(lipgloss.Color("69"))
 		} else {
@@ -609,14 +3434,15 @@ func (m model) View() string {
 		} else {
 			right = right.BorderForeground(lipgloss.Color("240"))
 		}
-		logsPanel = lipgloss.JoinHorizontal(lipgloss.Top, left.Render(m.vpOut.View()), right.Render(m.vpErr.View()))
-	}
-
-	follow := "ON"
-	followColor := lipgloss.Color("42")
-	if !m.follow {
-		follow = "PAUSED"
-		followColor = lipgloss.Color("220")
+		if m.cfg.CompactMode {
+			left = left.PaddingRight(1)
+		}
+		outTitle := paneTitle("STDOUT", m.unreadOutLines, m.logOutLines, m.vpOut.Width)
+		errTitle := paneTitle("STDERR", m.unreadErrLines, m.logErrLines, m.vpErr.Width)
+		logsPanel = lipgloss.JoinHorizontal(lipgloss.Top,
+			left.Render(outTitle+"\n"+m.vpOut.View()),
+			right.Render(errTitle+"\n"+m.vpErr.View()),
+		)
 	}
 
 	mode := "split"
@@ -625,26 +3451,59 @@ func (m model) View() string {
 	}
 
 	statusLine := lipgloss.NewStyle().Foreground(lipgloss.Color("252")).Render(
-		fmt.Sprintf("Focus:%s  Mode:%s  Follow:%s", []string{"jobs", "stdout", "stderr"}[m.focusArea], mode, lipgloss.NewStyle().Foreground(followColor).Render(follow)),
+		fmt.Sprintf("Focus:%s  Mode:%s  %s  Follow:%s", []string{"jobs", "stdout", "stderr"}[m.focusArea], mode, m.paneFollowIndicator(), m.followDot()),
 	)
-	actions := "[j/k] select  [tab] focus  [m] split/merged  [f] follow  [c] cancel (confirm)  [d] dismiss terminal  [D] clear terminal  [r] refresh  [q] quit"
+	if m.stateFilter != "" {
+		statusLine += "  " + lipgloss.NewStyle().Foreground(lipgloss.Color("220")).Render("Filter: "+m.stateFilter)
+	}
+	if m.logSearchTyping {
+		statusLine += "  " + m.logSearchInput.View()
+	} else if m.logSearchQuery != "" {
+		statusLine += "  " + lipgloss.NewStyle().Foreground(lipgloss.Color("226")).Render(
+			fmt.Sprintf("/%s [%d/%d]", m.logSearchQuery, min(m.logSearchMatchIdx+1, len(m.logSearchMatches)), len(m.logSearchMatches)))
+	}
+	if m.numBuf != "" {
+		statusLine += "  " + lipgloss.NewStyle().Foreground(lipgloss.Color("220")).Render(m.numBuf)
+	}
+	clock := m.refreshIndicator() + "  " + lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(m.now().Format("15:04:05"))
+	if gap := m.width - lipgloss.Width(statusLine) - lipgloss.Width(clock); gap > 0 {
+		statusLine += strings.Repeat(" ", gap) + clock
+	} else {
+		statusLine += "  " + clock
+	}
+	// The full list lives in the ? overlay; keep the footer to one row.
+	actions := "[?] help  [j/k] select  [tab] focus  [m] split/merged  [f] follow  [c] cancel  [/] search  [r] refresh  [q] quit"
+	if m.width > 0 {
+		actions = ansi.Truncate(actions, m.width, "")
+	}
 	statusMsg := ""
-	if m.statusText != "" {
-		statusMsg = lipgloss.NewStyle().Foreground(lipgloss.Color(m.statusColor)).Render(m.statusText)
-	}
-
-	base := strings.Join([]string{
-		header,
-		jobInfo,
-		jobsPanel,
-		logsPanel,
-		statusLine,
-		actions,
-		statusMsg,
-	}, "\n")
-
-	if m.cancelConfirm {
-		return m.renderCancelModal(base)
+	if entry, count, ok := m.currentStatus(m.now()); ok {
+		statusMsg = lipgloss.NewStyle().Foreground(lipgloss.Color(entry.color)).Render(entry.text)
+		if count > 1 {
+			statusMsg += lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(fmt.Sprintf(" (+%d)", count-1))
+		}
+	}
+
+	lines := []string{header, jobInfo, logInfo, jobsPanel, logsPanel, statusLine}
+	if m.fullscreenLog {
+		lines = []string{header, jobInfo, logInfo, logsPanel, statusLine}
+	}
+	if !m.cfg.CompactMode {
+		lines = append(lines, actions)
+	}
+	base := strings.Join(append(lines, statusMsg), "\n")
+
+	if m.pending != nil {
+		return m.renderConfirmModal(base)
+	}
+	if m.modal != nil {
+		return m.renderInfoModal(base)
+	}
+	if m.submit != nil {
+		return m.renderSubmitForm(base)
+	}
+	if m.signal != nil {
+		return m.renderSignalPrompt(base)
 	}
 	return base
 }
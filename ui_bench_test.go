@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+)
+
+func BenchmarkRenderJobsViewport(b *testing.B) {
+	states := []string{"RUNNING", "PENDING", "COMPLETED", "FAILED", "CANCELLED"}
+	rng := rand.New(rand.NewSource(1))
+
+	m := initialModel(defaultConfig())
+	m.vpJobs = viewport.New(120, 30)
+	m.vpReady = true
+	for i := 0; i < 500; i++ {
+		m.jobs = append(m.jobs, Job{
+			ID:        fmt.Sprintf("%d", 100000+i),
+			Name:      fmt.Sprintf("train-model-%03d", i),
+			State:     states[rng.Intn(len(states))],
+			Time:      "1:02:03",
+			TimeLimit: "4:00:00",
+			Nodes:     fmt.Sprintf("node%02d", rng.Intn(64)),
+		})
+	}
+	m.selectedIdx = 250
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.renderJobsViewport()
+	}
+}
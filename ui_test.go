@@ -0,0 +1,2146 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func updateModel(t *testing.T, m model, msg tea.Msg) (model, tea.Cmd) {
+	t.Helper()
+	next, cmd := m.Update(msg)
+	return next.(model), cmd
+}
+
+func TestModelWindowResize(t *testing.T) {
+	m := initialModel(defaultConfig())
+
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	if !m.vpReady {
+		t.Fatalf("expected viewports to be ready after first resize")
+	}
+	if m.vpJobs.Width != 96 || m.vpOut.Width != 46 || m.vpErr.Width != 46 || m.vpMerged.Width != 96 {
+		t.Fatalf("unexpected widths: jobs=%d out=%d err=%d merged=%d", m.vpJobs.Width, m.vpOut.Width, m.vpErr.Width, m.vpMerged.Width)
+	}
+	if m.outContentCache != "\x00" || m.errContentCache != "\x00" || m.mergedContentCache != "\x00" {
+		t.Fatalf("expected content caches to be invalidated on resize")
+	}
+	firstLogsHeight := m.vpOut.Height
+
+	m.outContentCache = "stale"
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 200, Height: 50})
+	if !m.vpReady {
+		t.Fatalf("expected viewports to stay ready after second resize")
+	}
+	if m.vpJobs.Width != 196 || m.vpOut.Width != 96 || m.vpErr.Width != 96 || m.vpMerged.Width != 196 {
+		t.Fatalf("unexpected widths after resize: jobs=%d out=%d err=%d merged=%d", m.vpJobs.Width, m.vpOut.Width, m.vpErr.Width, m.vpMerged.Width)
+	}
+	if m.vpOut.Height <= firstLogsHeight || m.vpErr.Height != m.vpOut.Height || m.vpMerged.Height != m.vpOut.Height {
+		t.Fatalf("expected taller log viewports, got out=%d err=%d merged=%d (was %d)", m.vpOut.Height, m.vpErr.Height, m.vpMerged.Height, firstLogsHeight)
+	}
+	if m.outContentCache != "\x00" {
+		t.Fatalf("expected stdout cache reset on resize, got %q", m.outContentCache)
+	}
+}
+
+func TestModelKeepsSelectionOnRefresh(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "101", Name: "a", State: "RUNNING"},
+		{ID: "102", Name: "b", State: "RUNNING"},
+		{ID: "103", Name: "c", State: "RUNNING"},
+	})
+	m, _ = updateModel(t, m, keyMsg("j"))
+	if m.selectedID != "102" {
+		t.Fatalf("expected 102 selected, got %q", m.selectedID)
+	}
+
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "103", Name: "c", State: "RUNNING"},
+		{ID: "102", Name: "b", State: "RUNNING"},
+		{ID: "101", Name: "a", State: "RUNNING"},
+	})
+	if m.selectedID != "102" || m.jobs[m.selectedIdx].ID != "102" || m.selectedIdx != 1 {
+		t.Fatalf("expected selection to stay on 102, got id=%q idx=%d", m.selectedID, m.selectedIdx)
+	}
+
+	// A job that leaves the queue stays listed as terminal until dismissed.
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "103", Name: "c", State: "RUNNING"},
+		{ID: "101", Name: "a", State: "RUNNING"},
+	})
+	if m.selectedID != "102" {
+		t.Fatalf("expected finished job to stay selected, got %q", m.selectedID)
+	}
+	m, _ = updateModel(t, m, keyMsg("d"))
+	if m.selectedID == "102" || m.selectedID == "" {
+		t.Fatalf("expected selection to move off dismissed job, got %q", m.selectedID)
+	}
+	if m.selectedIdx < 0 || m.selectedIdx >= len(m.jobs) || m.jobs[m.selectedIdx].ID != m.selectedID {
+		t.Fatalf("selection out of sync: idx=%d id=%q jobs=%+v", m.selectedIdx, m.selectedID, m.jobs)
+	}
+}
+
+type mockSlurmClient struct {
+	cancelled []string
+	held      []string
+	released  []string
+	requeued  []string
+
+	logPaths   map[string][2]string
+	discovered []string
+}
+
+func (c *mockSlurmClient) CancelJob(jobID string) error {
+	c.cancelled = append(c.cancelled, jobID)
+	return nil
+}
+
+func (c *mockSlurmClient) CancelArrayElement(arrayJobID, indices string) error {
+	c.cancelled = append(c.cancelled, arrayElementID(arrayJobID, indices))
+	return nil
+}
+
+func (c *mockSlurmClient) HoldJob(jobID string) error {
+	c.held = append(c.held, jobID)
+	return nil
+}
+
+func (c *mockSlurmClient) ReleaseJob(jobID string) error {
+	c.released = append(c.released, jobID)
+	return nil
+}
+
+func (c *mockSlurmClient) RequeueJob(jobID string) error {
+	c.requeued = append(c.requeued, jobID)
+	return nil
+}
+
+func (c *mockSlurmClient) DiscoverLogPaths(jobID string) (string, string, error) {
+	c.discovered = append(c.discovered, jobID)
+	paths, ok := c.logPaths[jobID]
+	if !ok {
+		return "", "", fmt.Errorf("scontrol show job %s: Invalid job id specified", jobID)
+	}
+	return paths[0], paths[1], nil
+}
+
+func keyMsg(key string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+// runJobAction runs the command of a confirmed job action and applies its
+// result.
+func runJobAction(t *testing.T, m model, cmd tea.Cmd) (model, tea.Cmd) {
+	t.Helper()
+	if cmd == nil {
+		t.Fatalf("expected a job action command")
+	}
+	msg, ok := cmd().(jobActionMsg)
+	if !ok {
+		t.Fatalf("expected a jobActionMsg")
+	}
+	return updateModel(t, m, msg)
+}
+
+func TestModelCancelConfirmFlow(t *testing.T) {
+	client := &mockSlurmClient{}
+	m := initialModel(defaultConfig())
+	m.slurm = client
+	m, _ = updateModel(t, m, jobMsg{{ID: "101", Name: "train", State: "RUNNING"}})
+
+	m, _ = updateModel(t, m, keyMsg("c"))
+	if m.pending == nil || m.pending.kind != "cancel" || m.pending.jobID != "101" {
+		t.Fatalf("expected cancel confirm armed for 101, got %+v", m.pending)
+	}
+	m, _ = updateModel(t, m, keyMsg("n"))
+	if m.pending != nil {
+		t.Fatalf("expected confirm cleared after n")
+	}
+	if len(client.cancelled) != 0 {
+		t.Fatalf("expected no cancel calls, got %v", client.cancelled)
+	}
+
+	m, _ = updateModel(t, m, keyMsg("c"))
+	m, cmd := updateModel(t, m, keyMsg("y"))
+	if m.pending != nil {
+		t.Fatalf("expected confirm cleared after y")
+	}
+	if len(client.cancelled) != 0 {
+		t.Fatalf("expected scancel to wait for the returned command, got %v", client.cancelled)
+	}
+	m.isRefreshing = false
+	m, cmd = runJobAction(t, m, cmd)
+	if len(client.cancelled) != 1 || client.cancelled[0] != "101" {
+		t.Fatalf("expected one cancel for 101, got %v", client.cancelled)
+	}
+	if !m.isRefreshing || cmd == nil {
+		t.Fatalf("expected a job refresh to be scheduled after cancel")
+	}
+}
+
+func TestModelNumericPrefix(t *testing.T) {
+	m := initialModel(defaultConfig())
+	var jobs jobMsg
+	for i := 0; i < 20; i++ {
+		jobs = append(jobs, Job{ID: strconv.Itoa(100 + i), State: "RUNNING"})
+	}
+	m, _ = updateModel(t, m, jobs)
+
+	for _, k := range []string{"1", "2", "j"} {
+		m, _ = updateModel(t, m, keyMsg(k))
+	}
+	if m.selectedIdx != 12 || m.numBuf != "" {
+		t.Fatalf("expected 12j to select row 12, got %d (buf %q)", m.selectedIdx, m.numBuf)
+	}
+
+	for _, k := range []string{"3", "k"} {
+		m, _ = updateModel(t, m, keyMsg(k))
+	}
+	if m.selectedIdx != 9 {
+		t.Fatalf("expected 3k to select row 9, got %d", m.selectedIdx)
+	}
+
+	m, _ = updateModel(t, m, keyMsg("G"))
+	if m.selectedIdx != 19 {
+		t.Fatalf("expected G to select last row, got %d", m.selectedIdx)
+	}
+	for _, k := range []string{"g", "g"} {
+		m, _ = updateModel(t, m, keyMsg(k))
+	}
+	if m.selectedIdx != 0 {
+		t.Fatalf("expected gg to select first row, got %d", m.selectedIdx)
+	}
+	for _, k := range []string{"1", "5", "G"} {
+		m, _ = updateModel(t, m, keyMsg(k))
+	}
+	if m.selectedIdx != 14 || m.selectedID != "114" {
+		t.Fatalf("expected 15G to select row 14, got %d (%s)", m.selectedIdx, m.selectedID)
+	}
+
+	m, _ = updateModel(t, m, keyMsg("4"))
+	m, _ = updateModel(t, m, keyMsg("m"))
+	if m.numBuf != "" {
+		t.Fatalf("expected count to reset on other keys, got %q", m.numBuf)
+	}
+}
+
+func TestModelRefreshCountdown(t *testing.T) {
+	fixed := time.Date(2024, 1, 15, 14, 32, 5, 0, time.UTC)
+	m := initialModel(defaultConfig())
+	m.now = func() time.Time { return fixed }
+	if !strings.Contains(m.refreshIndicator(), "Refreshing...") {
+		t.Fatalf("expected initial fetch to show as refreshing, got %q", m.refreshIndicator())
+	}
+
+	m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}})
+	if m.isRefreshing {
+		t.Fatalf("expected jobMsg to end the refresh")
+	}
+	if got := m.timeUntilRefresh(); got != jobsRefreshEvery {
+		t.Fatalf("expected full interval after refresh, got %s", got)
+	}
+
+	m.now = func() time.Time { return fixed.Add(2 * time.Second) }
+	if got := m.refreshIndicator(); !strings.Contains(got, "Next: 3s") {
+		t.Fatalf("expected countdown, got %q", got)
+	}
+	m.now = func() time.Time { return fixed.Add(jobsRefreshEvery - 500*time.Millisecond) }
+	if got := m.refreshIndicator(); !strings.Contains(got, "Next: <1s") {
+		t.Fatalf("expected almost-due countdown, got %q", got)
+	}
+	m.now = func() time.Time { return fixed.Add(time.Hour) }
+	if got := m.timeUntilRefresh(); got != 0 {
+		t.Fatalf("expected countdown to clamp at zero, got %s", got)
+	}
+}
+
+func TestModelRefreshInterval(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.RefreshInterval = 100 * time.Millisecond
+	start := time.Date(2024, 1, 15, 14, 32, 5, 0, time.UTC)
+	clock := start
+	m := initialModel(cfg)
+	m.now = func() time.Time { return clock }
+	m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}})
+
+	fetches := 0
+	for clock.Sub(start) < time.Second {
+		clock = clock.Add(50 * time.Millisecond)
+		m, _ = updateModel(t, m, tickMsg(clock))
+		if m.isRefreshing {
+			fetches++
+			m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}})
+		}
+	}
+	if fetches != 10 {
+		t.Fatalf("expected 10 fetches in 1s at a 100ms interval, got %d", fetches)
+	}
+	if got := m.refreshIndicator(); !strings.Contains(got, "/100ms") {
+		t.Fatalf("expected the interval in the status bar, got %q", got)
+	}
+}
+
+func TestModelManualRefreshSkipsCache(t *testing.T) {
+	t.Cleanup(jobsCache.invalidate)
+	key := strings.Join(squeueFlags(defaultConfig()), " ")
+	jobsCache.set(key, []Job{{ID: "1", State: "RUNNING"}}, time.Now())
+
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}})
+	m, _ = updateModel(t, m, keyMsg("r"))
+	if !m.isRefreshing {
+		t.Fatalf("expected r to start a refresh")
+	}
+	if _, ok := jobsCache.get(key, time.Hour, time.Now()); ok {
+		t.Fatalf("expected r to bypass the cached squeue output")
+	}
+}
+
+func TestModelJobListVirtualScroll(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	var jobs jobMsg
+	for i := 0; i < 500; i++ {
+		jobs = append(jobs, Job{ID: strconv.Itoa(1000 + i), State: "RUNNING"})
+	}
+	m, _ = updateModel(t, m, jobs)
+
+	rows := m.jobListRows()
+	if got := m.vpJobs.TotalLineCount(); got > rows+3 {
+		t.Fatalf("expected only the visible window to be rendered, got %d lines", got)
+	}
+
+	m, _ = updateModel(t, m, keyMsg("G"))
+	if want := 500 - rows; m.jobListOffset != want {
+		t.Fatalf("expected offset %d after G, got %d", want, m.jobListOffset)
+	}
+	if !strings.Contains(m.vpJobs.View(), "1499") {
+		t.Fatalf("expected last job to be visible after G")
+	}
+
+	m, _ = updateModel(t, m, keyMsg("g"))
+	m, _ = updateModel(t, m, keyMsg("g"))
+	if m.jobListOffset != 0 || !strings.Contains(m.vpJobs.View(), "1000") {
+		t.Fatalf("expected gg to scroll back to the top, offset %d", m.jobListOffset)
+	}
+
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyPgDown})
+	if m.jobListOffset != rows || m.selectedIdx != 0 {
+		t.Fatalf("expected pgdown to scroll one page without moving selection, offset %d sel %d", m.jobListOffset, m.selectedIdx)
+	}
+	m, _ = updateModel(t, m, keyMsg("j"))
+	if m.jobListOffset != 1 {
+		t.Fatalf("expected moving the selection to bring it back into view, offset %d", m.jobListOffset)
+	}
+}
+
+func TestRenderSparkline(t *testing.T) {
+	if got := renderSparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}, 8); got != "▁▂▃▄▅▆▇█" {
+		t.Fatalf("unexpected ramp sparkline %q", got)
+	}
+	if got := renderSparkline([]float64{3, 3, 3}, 10); got != "▁▁▁" {
+		t.Fatalf("expected flat series to render lowest block, got %q", got)
+	}
+	if got := renderSparkline([]float64{9, 0, 10}, 2); got != "▁█" {
+		t.Fatalf("expected only the last width samples, got %q", got)
+	}
+
+	m := initialModel(defaultConfig())
+	for i := 0; i < usageSampleCap+5; i++ {
+		m.recordUsageSample(float64(i), float64(i*2))
+	}
+	if len(m.cpuSamples) != usageSampleCap || m.cpuSamples[0] != 5 || m.memSamples[usageSampleCap-1] != float64((usageSampleCap+4)*2) {
+		t.Fatalf("expected a rolling window of %d samples, got %v", usageSampleCap, m.cpuSamples)
+	}
+}
+
+func TestModelPasteSetsNameFilter(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "4242", Name: "train", State: "RUNNING"},
+		{ID: "4243", Name: "eval", State: "RUNNING"},
+	})
+
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" eval\n"), Paste: true})
+	if m.filterName != "eval" {
+		t.Fatalf("expected pasted text to become the name filter, got %q", m.filterName)
+	}
+	if len(m.jobs) != 1 || m.jobs[0].ID != "4243" || m.selectedID != "4243" {
+		t.Fatalf("expected filtered jobs to follow the paste, got %#v (selected %s)", m.jobs, m.selectedID)
+	}
+
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("\x1b[200~4242\x1b[201~"), Paste: true})
+	if m.filterName != "4242" {
+		t.Fatalf("expected bracket markers to be stripped, got %q", m.filterName)
+	}
+	if len(m.jobs) != 1 || m.jobs[0].ID != "4242" {
+		t.Fatalf("expected a pasted job ID to match that job, got %#v", m.jobs)
+	}
+}
+
+func TestWriteJobsJSON(t *testing.T) {
+	s := NewJobStore()
+	now := time.Date(2024, 1, 15, 14, 32, 5, 0, time.UTC)
+	s.ApplySnapshot([]Job{{ID: "1", Name: "train", State: "RUNNING"}, {ID: "2", State: "FAILED"}}, now)
+	s.DismissIfTerminal("2")
+
+	dir := t.TempDir()
+	name, err := writeJobsJSON(s.AllRecords(), dir, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(name) != "slurm-jobs-2024-01-15T14:32:05Z.json" {
+		t.Fatalf("unexpected file name %q", name)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []JobRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Job.Name != "train" || !records[1].Dismissed {
+		t.Fatalf("expected all records including dismissed ones, got %#v", records)
+	}
+}
+
+func TestModelCancelArrayChoices(t *testing.T) {
+	client := &mockSlurmClient{}
+	m := initialModel(defaultConfig())
+	m.slurm = client
+	m, _ = updateModel(t, m, jobMsg{{ID: "12345_3", ArrayJobID: "12345", ArrayTaskID: "3", State: "RUNNING"}})
+	m, _ = updateModel(t, m, keyMsg("e"))
+
+	m, _ = updateModel(t, m, keyMsg("c"))
+	m, cmd := updateModel(t, m, keyMsg("a"))
+	m, _ = runJobAction(t, m, cmd)
+	m, _ = updateModel(t, m, keyMsg("c"))
+	m, cmd = updateModel(t, m, keyMsg("A"))
+	m, _ = runJobAction(t, m, cmd)
+	if m.pending != nil {
+		t.Fatalf("expected the modal to close after choosing")
+	}
+	want := []string{"12345_3", "12345"}
+	if strings.Join(client.cancelled, ",") != strings.Join(want, ",") {
+		t.Fatalf("cancelled %v, want %v", client.cancelled, want)
+	}
+}
+
+func TestModelFollowIndicatorBlinks(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m.isRefreshing = true // keep ticks from spawning squeue
+	var dots []string
+	for i := 0; i < 4; i++ {
+		m, _ = updateModel(t, m, tickMsg(time.Now()))
+		dots = append(dots, ansi.Strip(m.followDot()))
+	}
+	if strings.Join(dots, "") != "○●●○" {
+		t.Fatalf("expected the dot to toggle every other tick, got %q", dots)
+	}
+
+	m.setFollow(false)
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+	if got := ansi.Strip(m.followDot()); got != "○" {
+		t.Fatalf("expected a hollow dot while paused, got %q", got)
+	}
+
+	// Scrolling pauses only the focused pane; the dot and f follow it.
+	m.setFollow(true)
+	m.setFocus(1)
+	m.followOut = false
+	if got := ansi.Strip(m.followDot()); got != "○" {
+		t.Fatalf("expected a hollow dot for the paused stdout pane, got %q", got)
+	}
+	m.setFocus(2)
+	if !m.following() {
+		t.Fatalf("expected the stderr pane to still follow")
+	}
+	m.setFocus(1)
+	m, _ = updateModel(t, m, keyMsg("f"))
+	if !m.followOut || !m.followErr {
+		t.Fatalf("expected f on a paused pane to resume following, got out %v err %v", m.followOut, m.followErr)
+	}
+}
+
+func TestModelInitialViewFromConfig(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.InitialFocusArea = 2
+	cfg.InitialMergedMode = true
+	cfg.InitialFollow = false
+	m := initialModel(cfg)
+	if m.focusArea != 2 || !m.mergedMode || m.following() {
+		t.Fatalf("expected config to drive the initial view, got focus %d merged %v follow %v", m.focusArea, m.mergedMode, m.following())
+	}
+	m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}})
+	if m.followErr || m.followOut || m.followMerged {
+		t.Fatalf("expected selecting a job to keep the configured follow default")
+	}
+
+	if d := initialModel(defaultConfig()); d.focusArea != 0 || d.mergedMode || !d.following() {
+		t.Fatalf("unexpected defaults: focus %d merged %v follow %v", d.focusArea, d.mergedMode, d.following())
+	}
+}
+
+func TestModelClusterConfigModal(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, clusterMetaMsg{meta: newClusterMeta(map[string]string{"ClusterName": "hpc2", "MaxTime": "7-00:00:00"})})
+	if !strings.Contains(m.View(), "slurm-tui [hpc2]") {
+		t.Fatalf("expected cluster name in the title")
+	}
+
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyCtrlO})
+	if m.modal == nil || len(m.modal.lines) != 2 || !strings.Contains(m.modal.lines[1], "7-00:00:00") {
+		t.Fatalf("expected a modal listing the config, got %#v", m.modal)
+	}
+	m, _ = updateModel(t, m, keyMsg("q"))
+	if m.modal != nil {
+		t.Fatalf("expected q to close the modal, not quit")
+	}
+}
+
+func TestModelShowsEfficiencyForTerminalJobs(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, cmd := updateModel(t, m, jobMsg{{ID: "9", State: "COMPLETED"}})
+	if cmd == nil || !m.efficiencyRequested["9"] {
+		t.Fatalf("expected an efficiency fetch for the selected terminal job")
+	}
+	m, _ = updateModel(t, m, efficiencyMsg{jobID: "9", report: EfficiencyReport{CPUEfficiency: 85.3, MemEfficiency: 42.1, CPUKnown: true, MemKnown: true}})
+	if !strings.Contains(ansi.Strip(m.View()), "CPU: 85.3% efficient  Mem: 42.1% efficient") {
+		t.Fatalf("expected efficiency in the job info line")
+	}
+}
+
+func TestModelArrayExpandToggle(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "1", Name: "prep", State: "RUNNING"},
+		{ID: "50_1", Name: "sweep", State: "RUNNING", ArrayJobID: "50", ArrayTaskID: "1"},
+		{ID: "50_2", Name: "sweep", State: "PENDING", ArrayJobID: "50", ArrayTaskID: "2"},
+	})
+	if len(m.jobs) != 2 || m.jobs[1].ID != "50" || !m.jobs[1].IsArrayGroup() {
+		t.Fatalf("expected the array to start collapsed, got %#v", m.jobs)
+	}
+
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, keyMsg("e"))
+	if len(m.jobs) != 3 || m.selectedID != "50_1" {
+		t.Fatalf("expected e to expand the array onto its first task, got %d rows, selected %s", len(m.jobs), m.selectedID)
+	}
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, keyMsg("e"))
+	if len(m.jobs) != 2 || m.selectedID != "50" {
+		t.Fatalf("expected e on a task to collapse back to the parent, got %d rows, selected %s", len(m.jobs), m.selectedID)
+	}
+}
+
+func TestModelConfigKeyBindingsAndMaxJobs(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.KeyBindings = map[string]string{"x": "c"}
+	cfg.MaxJobs = 2
+	m := initialModel(cfg)
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "1", Name: "a", State: "RUNNING"},
+		{ID: "2", Name: "b", State: "RUNNING"},
+		{ID: "3", Name: "c", State: "RUNNING"},
+	})
+	if len(m.jobs) != 2 {
+		t.Fatalf("expected max_jobs to cap the list at 2, got %d", len(m.jobs))
+	}
+
+	m, _ = updateModel(t, m, keyMsg("x"))
+	if m.pending == nil || m.pending.jobID != "1" {
+		t.Fatalf("expected x to act like c and arm the cancel modal")
+	}
+}
+
+func TestModelHeaderShowsUserScope(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.User = "alice,bob"
+	m := initialModel(cfg)
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 140, Height: 30})
+	m, _ = updateModel(t, m, jobMsg{{ID: "1", Name: "a", State: "RUNNING", User: "bob"}})
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "[User: alice,bob]") {
+		t.Fatalf("expected monitored users in the header, got:\n%s", view)
+	}
+	if !strings.Contains(view, "USER") {
+		t.Fatalf("expected a USER column when watching several users")
+	}
+}
+
+func TestModelTogglePartitionFilter(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Partition = "gpu"
+	m := initialModel(cfg)
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 140, Height: 30})
+	m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}})
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "[Partition: gpu]") {
+		t.Fatalf("expected the partition filter in the header")
+	}
+
+	m, cmd := updateModel(t, m, keyMsg("p"))
+	if m.cfg.Partition != "" || !m.isRefreshing || cmd == nil {
+		t.Fatalf("expected p to drop the partition filter and refresh")
+	}
+	if view := ansi.Strip(m.View()); strings.Contains(view, "[Partition:") {
+		t.Fatalf("expected no partition badge while showing all partitions")
+	}
+	m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}})
+	m, _ = updateModel(t, m, keyMsg("p"))
+	if m.cfg.Partition != "gpu" {
+		t.Fatalf("expected p to restore the partition filter, got %q", m.cfg.Partition)
+	}
+}
+
+func TestModelSubmitForm(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}})
+
+	m, _ = updateModel(t, m, keyMsg("s"))
+	if m.submit == nil {
+		t.Fatalf("expected s to open the submit form")
+	}
+	m, _ = updateModel(t, m, keyMsg("run.sh"))
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	m, _ = updateModel(t, m, keyMsg("sweep"))
+	if got := m.submit.request(); got.Script != "run.sh" || got.JobName != "sweep" {
+		t.Fatalf("unexpected form values %#v", got)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Submit Job (sbatch)") {
+		t.Fatalf("expected the submit form to render")
+	}
+
+	m, cmd := updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.submit != nil || cmd == nil {
+		t.Fatalf("expected enter to close the form and run sbatch")
+	}
+
+	m, cmd = updateModel(t, m, submitMsg{jobID: "4321"})
+	if !m.isRefreshing || cmd == nil {
+		t.Fatalf("expected a refresh after submitting")
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "submitted batch job 4321") {
+		t.Fatalf("expected the new job ID in the status bar")
+	}
+}
+
+func TestModelHoldReleaseFlow(t *testing.T) {
+	client := &mockSlurmClient{}
+	m := initialModel(defaultConfig())
+	m.slurm = client
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "201", Name: "queued", State: "PENDING"},
+		{ID: "202", Name: "busy", State: "RUNNING"},
+	})
+
+	m, _ = updateModel(t, m, keyMsg("h"))
+	if m.pending == nil || m.pending.kind != "hold" || m.pending.jobID != "201" {
+		t.Fatalf("expected hold confirm armed for 201, got %+v", m.pending)
+	}
+	m, cmd := updateModel(t, m, keyMsg("y"))
+	if m.pending != nil || len(client.held) != 0 {
+		t.Fatalf("expected hold to run asynchronously, held %v", client.held)
+	}
+	m, cmd = runJobAction(t, m, cmd)
+	if cmd == nil || !reflect.DeepEqual(client.held, []string{"201"}) {
+		t.Fatalf("expected hold to run and refresh, held %v", client.held)
+	}
+	m, _ = updateModel(t, m, jobMsg{{ID: "201", Name: "queued", State: "PENDING"}, {ID: "202", Name: "busy", State: "RUNNING"}})
+
+	m, _ = updateModel(t, m, keyMsg("H"))
+	if m.pending == nil || m.pending.kind != "release" {
+		t.Fatalf("expected release confirm armed, got %+v", m.pending)
+	}
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.pending != nil || len(client.released) != 0 {
+		t.Fatalf("expected esc to abort the release")
+	}
+	m, _ = updateModel(t, m, keyMsg("H"))
+	m, cmd = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = runJobAction(t, m, cmd)
+	if !reflect.DeepEqual(client.released, []string{"201"}) {
+		t.Fatalf("expected release of 201, got %v", client.released)
+	}
+
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, keyMsg("h"))
+	if m.pending != nil {
+		t.Fatalf("expected hold on a RUNNING job not to arm the modal")
+	}
+}
+
+func TestModelRequeueFlow(t *testing.T) {
+	client := &mockSlurmClient{}
+	m := initialModel(defaultConfig())
+	m.slurm = client
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "301", Name: "waiting", State: "PENDING"},
+		{ID: "302", Name: "train", State: "RUNNING"},
+	})
+
+	m, _ = updateModel(t, m, keyMsg("R"))
+	if m.pending != nil {
+		t.Fatalf("expected requeue of a PENDING job to skip the modal")
+	}
+
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, keyMsg("R"))
+	if m.pending == nil || m.pending.kind != "requeue" || m.pending.jobID != "302" {
+		t.Fatalf("expected requeue confirm armed for 302, got %+v", m.pending)
+	}
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Requeue job 302? [y/N]") {
+		t.Fatalf("expected requeue wording in the modal")
+	}
+
+	m, cmd := updateModel(t, m, keyMsg("y"))
+	if cmd == nil || len(client.requeued) != 0 {
+		t.Fatalf("expected requeue to run asynchronously")
+	}
+	m.isRefreshing = false
+	m, cmd = runJobAction(t, m, cmd)
+	if !reflect.DeepEqual(client.requeued, []string{"302"}) {
+		t.Fatalf("expected requeue of 302, got %v", client.requeued)
+	}
+	if !m.isRefreshing || cmd == nil {
+		t.Fatalf("expected a refresh after requeue")
+	}
+}
+
+func TestModelSignalPrompt(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "401", Name: "train", State: "RUNNING"},
+		{ID: "402", Name: "later", State: "PENDING"},
+	})
+
+	m, _ = updateModel(t, m, keyMsg("S"))
+	if m.signal == nil || m.signal.jobID != "401" {
+		t.Fatalf("expected S to open the signal prompt for 401")
+	}
+	m, cmd := updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.signal == nil || cmd != nil {
+		t.Fatalf("expected an empty signal to be rejected")
+	}
+	m, _ = updateModel(t, m, keyMsg("sigusr1"))
+	m, cmd = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.signal != nil || cmd == nil {
+		t.Fatalf("expected a valid signal to close the prompt and send it")
+	}
+
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, keyMsg("S"))
+	if m.signal != nil {
+		t.Fatalf("expected no signal prompt for a PENDING job")
+	}
+}
+
+func TestModelAccountingOnTerminalTransition(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 160, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "501", Name: "train", State: "RUNNING"}})
+	m, _ = updateModel(t, m, jobMsg{{ID: "501", Name: "train", State: "FAILED"}})
+
+	m, _ = updateModel(t, m, accountingMsg{jobID: "501", info: AccountingInfo{ExitCode: "1:0", CPUTime: "00:10:00", MaxRSS: "2G"}})
+	rec, _ := m.store.Record("501")
+	if rec.Accounting == nil || rec.Accounting.ExitCode != "1:0" {
+		t.Fatalf("expected accounting stored on the record, got %+v", rec.Accounting)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Exit:1:0") || !strings.Contains(view, "MaxRSS:2G") {
+		t.Fatalf("expected accounting in the job detail line")
+	}
+}
+
+func TestEfficiencyColor(t *testing.T) {
+	cases := map[float64]lipgloss.Color{95: "42", 80: "42", 79.9: "220", 50: "220", 49: "196", 0: "196"}
+	for pct, want := range cases {
+		if got := efficiencyColor(pct); got != want {
+			t.Fatalf("efficiencyColor(%v) = %v, want %v", pct, got, want)
+		}
+	}
+}
+
+func TestModelJobDetailModal(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "601", Name: "eval", State: "PENDING"}})
+
+	if _, cmd := updateModel(t, m, keyMsg("i")); cmd == nil {
+		t.Fatalf("expected i to fetch job details")
+	}
+	m, _ = updateModel(t, m, jobDetailMsg{jobID: "601", detail: JobDetail{
+		JobID:      "601",
+		WorkDir:    "/scratch/eval",
+		Reason:     "Dependency",
+		Dependency: "afterok:600(unfulfilled)",
+	}})
+	if m.modal == nil || m.modal.title != "Job 601" {
+		t.Fatalf("expected the detail modal to open, got %+v", m.modal)
+	}
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"WorkDir     /scratch/eval", "Reason      Dependency", "Requires job 600 to succeed"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in the detail modal", want)
+		}
+	}
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.modal != nil {
+		t.Fatalf("expected esc to close the detail modal")
+	}
+
+	m, _ = updateModel(t, m, jobDetailMsg{jobID: "601", err: errors.New("scontrol show job 601: Invalid job id specified")})
+	if m.modal != nil {
+		t.Fatalf("expected no modal on error")
+	}
+}
+
+func TestHighlightBatchScript(t *testing.T) {
+	cases := []struct {
+		line  string
+		style lipgloss.Style
+		ok    bool
+	}{
+		{"#SBATCH --gres=gpu:2", sbatchDirectiveStyle, true},
+		{"  #SBATCH -t 4:00:00", sbatchDirectiveStyle, true},
+		{"#!/bin/bash", scriptCommentStyle, true},
+		{"# prepare the environment", scriptCommentStyle, true},
+		{"module load cuda/12.2", moduleLoadStyle, true},
+		{"srun python train.py", lipgloss.Style{}, false},
+		{"module list", lipgloss.Style{}, false},
+	}
+	for _, tc := range cases {
+		style, ok := batchScriptLineStyle(tc.line)
+		if ok != tc.ok || style.GetForeground() != tc.style.GetForeground() || style.GetBold() != tc.style.GetBold() {
+			t.Fatalf("batchScriptLineStyle(%q) = %v/%v, want %v/%v", tc.line, style.GetForeground(), ok, tc.style.GetForeground(), tc.ok)
+		}
+	}
+	if !sbatchDirectiveStyle.GetBold() {
+		t.Fatalf("expected #SBATCH directives in bold")
+	}
+
+	script := "#!/bin/bash\n#SBATCH -J train\nmodule load cuda\n\tsrun train\n"
+	if got := ansi.Strip(highlightBatchScript(script)); got != "#!/bin/bash\n#SBATCH -J train\nmodule load cuda\n    srun train" {
+		t.Fatalf("unexpected highlighted script %q", got)
+	}
+}
+
+func TestModelBatchScriptModal(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "611", Name: "train", State: "RUNNING"}})
+
+	m, cmd := updateModel(t, m, keyMsg("P"))
+	if cmd == nil || m.modal == nil || m.modal.kind != "script" {
+		t.Fatalf("expected P to open the batch script modal, got %+v", m.modal)
+	}
+	m, _ = updateModel(t, m, batchScriptMsg{jobID: "999", script: "#!/bin/sh\n"})
+	if !strings.Contains(ansi.Strip(m.View()), "loading batch script") {
+		t.Fatalf("expected scripts of other jobs to be ignored")
+	}
+	m, _ = updateModel(t, m, batchScriptMsg{jobID: "611", script: "#!/bin/bash\n#SBATCH -p gpu\nsrun train.py\n"})
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"Batch script of job 611", "#SBATCH -p gpu", "srun train.py"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in the script modal", want)
+		}
+	}
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.modal != nil {
+		t.Fatalf("expected esc to close the script modal")
+	}
+
+	m, _ = updateModel(t, m, keyMsg("P"))
+	m, _ = updateModel(t, m, batchScriptMsg{jobID: "611", err: errors.New("scontrol write batch_script 611: Invalid job id specified")})
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Invalid job id specified") {
+		t.Fatalf("expected the scontrol error in the modal")
+	}
+}
+
+func TestModelSinfoPanel(t *testing.T) {
+	m := initialModel(defaultConfig())
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	m, cmd := updateModel(t, m, keyMsg("I"))
+	if !m.sinfoOpen() || cmd == nil || !m.sinfoFetching {
+		t.Fatalf("expected I to open the partition panel and fetch sinfo")
+	}
+	m, _ = updateModel(t, m, sinfoMsg{partitions: []PartitionInfo{{Name: "gpu", Available: "up", TimeLimit: "2-00:00:00", NodeCount: "2", State: "idle", Nodes: "gpu[01-02]"}}})
+	if m.sinfoFetching {
+		t.Fatalf("expected the fetch to be done")
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "gpu        up     2-00:00:00") {
+		t.Fatalf("expected the partition row in the panel")
+	}
+
+	m, _ = updateModel(t, m, tickMsg(now))
+	if m.sinfoFetching {
+		t.Fatalf("expected no refetch before the refresh interval")
+	}
+	now = now.Add(m.jobsRefreshEvery)
+	m, _ = updateModel(t, m, tickMsg(now))
+	if !m.sinfoFetching {
+		t.Fatalf("expected the open panel to refresh after the interval")
+	}
+
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	m, _ = updateModel(t, m, sinfoMsg{partitions: []PartitionInfo{{Name: "late"}}})
+	if m.modal != nil {
+		t.Fatalf("expected a late sinfo result not to reopen the panel")
+	}
+}
+
+func TestModelNodeDetailPicker(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "701", Name: "train", State: "RUNNING", Nodes: "gpu[01-03]"},
+		{ID: "702", Name: "wait", State: "PENDING"},
+	})
+
+	m, cmd := updateModel(t, m, keyMsg("n"))
+	if m.modal == nil || m.modal.kind != "node" || cmd == nil {
+		t.Fatalf("expected n to open the node modal and fetch the first node")
+	}
+	if !reflect.DeepEqual(m.modal.nodes, []string{"gpu01", "gpu02", "gpu03"}) {
+		t.Fatalf("unexpected node picker entries %v", m.modal.nodes)
+	}
+
+	m, _ = updateModel(t, m, nodeDetailMsg{node: "gpu01", detail: NodeDetail{NodeName: "gpu01", State: "MIXED", CPULoad: "7.95"}})
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "gpu01 gpu02 gpu03") || !strings.Contains(view, "CPULoad   7.95") {
+		t.Fatalf("expected picker and node details in the modal:\n%s", view)
+	}
+
+	m, cmd = updateModel(t, m, keyMsg("l"))
+	if m.modal.nodeIdx != 1 || cmd == nil {
+		t.Fatalf("expected l to move to gpu02 and fetch it")
+	}
+	m, _ = updateModel(t, m, nodeDetailMsg{node: "gpu01", detail: NodeDetail{NodeName: "gpu01", State: "STALE"}})
+	if view := ansi.Strip(m.View()); strings.Contains(view, "STALE") {
+		t.Fatalf("expected a result for a different node to be ignored")
+	}
+	m, _ = updateModel(t, m, keyMsg("h"))
+	m, _ = updateModel(t, m, keyMsg("h"))
+	if m.modal.nodeIdx != 2 {
+		t.Fatalf("expected h to wrap to the last node, got %d", m.modal.nodeIdx)
+	}
+
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, keyMsg("n"))
+	if m.modal != nil {
+		t.Fatalf("expected no node modal for a PENDING job")
+	}
+}
+
+func TestModelBatchSelection(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "801", Name: "a", State: "RUNNING"},
+		{ID: "802", Name: "b", State: "PENDING"},
+		{ID: "803", Name: "c", State: "FAILED"},
+		{ID: "804", Name: "d", State: "RUNNING"},
+	})
+	space := tea.KeyMsg{Type: tea.KeySpace}
+
+	m, _ = updateModel(t, m, space)
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, space)
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, space)
+	m, _ = updateModel(t, m, space)
+	if !reflect.DeepEqual(m.selected, map[string]bool{"801": true, "803": true}) {
+		t.Fatalf("expected 801 and 803 marked, got %v", m.selected)
+	}
+	m, _ = updateModel(t, m, space)
+	m, _ = updateModel(t, m, keyMsg("k"))
+	m, _ = updateModel(t, m, keyMsg("k"))
+	m, _ = updateModel(t, m, space)
+
+	m, _ = updateModel(t, m, keyMsg("c"))
+	if m.pending == nil || !reflect.DeepEqual(m.pending.jobIDs, []string{"801", "802", "804"}) {
+		t.Fatalf("expected only the active marked jobs in the batch, got %+v", m.pending)
+	}
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "Send cancel signal to 3 jobs?") || !strings.Contains(view, "801 802 804") {
+		t.Fatalf("expected the modal to list every batch ID:\n%s", view)
+	}
+
+	m, cmd := updateModel(t, m, keyMsg("y"))
+	if cmd == nil || m.pending != nil || len(m.selected) != 0 {
+		t.Fatalf("expected confirm to send the batch and clear the marks")
+	}
+	m.isRefreshing = false
+	m, cmd = updateModel(t, m, batchCancelMsg{jobIDs: []string{"801", "802", "804"}})
+	if cmd == nil || !m.isRefreshing {
+		t.Fatalf("expected a refresh after the batch cancel")
+	}
+}
+
+func TestModelBatchDismiss(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "901", Name: "a", State: "COMPLETED"},
+		{ID: "902", Name: "b", State: "RUNNING"},
+		{ID: "903", Name: "c", State: "FAILED"},
+	})
+	m.selected = map[string]bool{"901": true, "902": true, "903": true}
+
+	m, _ = updateModel(t, m, keyMsg("d"))
+	if len(m.jobs) != 1 || m.jobs[0].ID != "902" || len(m.selected) != 0 {
+		t.Fatalf("expected only terminal marked jobs dismissed, got %+v", m.jobs)
+	}
+}
+
+func TestModelSortCycle(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "20", Name: "b", State: "RUNNING"},
+		{ID: "3", Name: "a", State: "PENDING"},
+	})
+
+	m, _ = updateModel(t, m, keyMsg("o"))
+	if m.sortField != "id" || !m.sortAsc || m.jobs[0].ID != "3" {
+		t.Fatalf("expected ascending id sort, got %q asc=%v %v", m.sortField, m.sortAsc, m.jobs)
+	}
+	if m.selectedID != "20" || m.jobs[m.selectedIdx].ID != "20" {
+		t.Fatalf("expected the selection to follow job 20 after sorting")
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "JOB ID ▲") {
+		t.Fatalf("expected an ascending indicator on the JOB ID header")
+	}
+	m, _ = updateModel(t, m, keyMsg("o"))
+	if m.sortField != "id" || m.sortAsc {
+		t.Fatalf("expected descending id sort next")
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "JOB ID ▼") {
+		t.Fatalf("expected a descending indicator on the JOB ID header")
+	}
+	for range 2 * (len(sortFields) - 1) {
+		m, _ = updateModel(t, m, keyMsg("o"))
+	}
+	if m.sortField != "node" || m.sortAsc {
+		t.Fatalf("expected to end on descending node sort, got %q asc=%v", m.sortField, m.sortAsc)
+	}
+	m, _ = updateModel(t, m, keyMsg("o"))
+	if m.sortField != "" || m.jobs[0].ID != "20" {
+		t.Fatalf("expected the cycle to return to first-seen order")
+	}
+}
+
+func TestModelStateFilterCycle(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "11", Name: "a", State: "RUNNING"},
+		{ID: "12", Name: "b", State: "PENDING"},
+		{ID: "13", Name: "c", State: "RUNNING"},
+	})
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, keyMsg("j"))
+
+	m, _ = updateModel(t, m, keyMsg("F"))
+	if m.stateFilter != "RUNNING" || len(m.jobs) != 2 || m.selectedID != "13" || m.selectedIdx != 1 {
+		t.Fatalf("expected RUNNING filter keeping 13 selected, got %q %v sel=%s/%d", m.stateFilter, m.jobs, m.selectedID, m.selectedIdx)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Filter: RUNNING") {
+		t.Fatalf("expected the filter in the status bar")
+	}
+
+	m, _ = updateModel(t, m, keyMsg("F"))
+	if m.stateFilter != "PENDING" || m.selectedID != "12" || m.selectedIdx != 0 {
+		t.Fatalf("expected the hidden selection to move to the first PENDING job, got %s", m.selectedID)
+	}
+
+	m, _ = updateModel(t, m, keyMsg("F"))
+	if len(m.jobs) != 0 {
+		t.Fatalf("expected no COMPLETED jobs, got %v", m.jobs)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "No COMPLETED jobs.") {
+		t.Fatalf("expected an empty-filter hint")
+	}
+	m, _ = updateModel(t, m, keyMsg("F"))
+	m, _ = updateModel(t, m, keyMsg("F"))
+	if m.stateFilter != "" || len(m.jobs) != 3 {
+		t.Fatalf("expected the cycle to return to all jobs")
+	}
+}
+
+func TestHighlightMatch(t *testing.T) {
+	if got := highlightMatch("train-model   ", "MODEL"); ansi.Strip(got) != "train-model   " || !strings.HasPrefix(got, "train-") {
+		t.Fatalf("expected the highlight to keep the cell text, got %q", got)
+	}
+	if got := highlightMatch("eval", "train"); got != "eval" {
+		t.Fatalf("expected no change without a match, got %q", got)
+	}
+	if got := highlightMatch("eval", ""); got != "eval" {
+		t.Fatalf("expected no change for an empty query, got %q", got)
+	}
+}
+
+func TestModelJobSearch(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "31", Name: "train", State: "RUNNING"},
+		{ID: "32", Name: "eval", State: "PENDING"},
+		{ID: "33", Name: "pretrain", State: "RUNNING"},
+	})
+
+	m, _ = updateModel(t, m, keyMsg("/"))
+	if !m.jobSearchActive {
+		t.Fatalf("expected / to open the job search")
+	}
+	m, _ = updateModel(t, m, keyMsg("j"))
+	if m.selectedIdx != 0 || m.jobSearch != "j" {
+		t.Fatalf("expected typed keys to go to the search input, got %q", m.jobSearch)
+	}
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyBackspace})
+	m, _ = updateModel(t, m, keyMsg("EVAL"))
+	if m.jobSearch != "EVAL" || len(m.jobs) != 3 {
+		t.Fatalf("expected the search to keep every job visible, got %q %v", m.jobSearch, m.jobs)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "/EVAL") {
+		t.Fatalf("expected the search bar in the jobs pane")
+	}
+
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.jobSearchActive || m.jobSearch != "EVAL" || m.selectedID != "32" {
+		t.Fatalf("expected enter to lock the search and jump to the match, got active=%v sel=%s", m.jobSearchActive, m.selectedID)
+	}
+	m, _ = updateModel(t, m, keyMsg("j"))
+	if m.selectedID != "33" {
+		t.Fatalf("expected navigation to work once locked")
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "/EVAL  [/] edit  [esc] clear") {
+		t.Fatalf("expected the locked search bar")
+	}
+
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.jobSearch != "" || m.jobSearchBarVisible() {
+		t.Fatalf("expected esc to clear the locked search")
+	}
+
+	m, _ = updateModel(t, m, keyMsg("/"))
+	m, _ = updateModel(t, m, keyMsg("zzz"))
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.selectedID != "33" {
+		t.Fatalf("expected no jump when nothing matches")
+	}
+	m, _ = updateModel(t, m, keyMsg("/"))
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.jobSearch != "" || m.jobSearchActive {
+		t.Fatalf("expected esc in the input to clear the search")
+	}
+
+	m, _ = updateModel(t, m, keyMsg("/"))
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.jobSearchBarVisible() {
+		t.Fatalf("expected an empty query to close the search bar")
+	}
+}
+
+func TestModelLogSearch(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.LogDir = t.TempDir()
+	var lines []string
+	for i := 0; i < 100; i++ {
+		line := fmt.Sprintf("step %d ok", i)
+		if i == 10 || i == 40 {
+			line = fmt.Sprintf("step %d ERROR diverged", i)
+		}
+		lines = append(lines, line)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.LogDir, "51.out"), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := initialModel(cfg)
+	m.isRefreshing = true // keep ticks from spawning squeue
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "51", Name: "train", State: "RUNNING"}})
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+	m, _ = updateModel(t, m, keyMsg("tab"))
+
+	m, _ = updateModel(t, m, keyMsg("/"))
+	m, _ = updateModel(t, m, keyMsg("error"))
+	if !m.logSearchTyping || !strings.Contains(ansi.Strip(m.View()), "/error") {
+		t.Fatalf("expected the query in the status bar while typing")
+	}
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if !reflect.DeepEqual(m.logSearchMatches, []int{10, 40}) || m.vpOut.YOffset != 10 || m.followOut {
+		t.Fatalf("expected to jump to the first match, got matches %v offset %d", m.logSearchMatches, m.vpOut.YOffset)
+	}
+	m, _ = updateModel(t, m, keyMsg("n"))
+	if m.logSearchMatchIdx != 1 || m.vpOut.YOffset != 40 {
+		t.Fatalf("expected n to move to the second match, got offset %d", m.vpOut.YOffset)
+	}
+	m, _ = updateModel(t, m, keyMsg("n"))
+	if m.logSearchMatchIdx != 0 || m.vpOut.YOffset != 10 {
+		t.Fatalf("expected n to wrap to the first match")
+	}
+	m, _ = updateModel(t, m, keyMsg("N"))
+	if m.logSearchMatchIdx != 1 {
+		t.Fatalf("expected N to wrap backwards")
+	}
+	if m.modal != nil {
+		t.Fatalf("expected n to navigate matches, not open node details")
+	}
+
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.logSearchQuery != "" || m.logSearchMatches != nil {
+		t.Fatalf("expected esc to clear the log search")
+	}
+}
+
+func TestExportLogCmd(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, exportLogName("61", "stdout", time.Date(2024, 1, 15, 14, 32, 5, 0, time.UTC)))
+	if filepath.Base(dest) != "61_stdout_20240115-143205.txt" {
+		t.Fatalf("unexpected export name %q", filepath.Base(dest))
+	}
+
+	msg := exportLogCmd("61", "stdout", dest, "epoch 1\n\x1b[31mloss nan\x1b[0m")().(statusMsg)
+	if msg.color != "42" || !strings.Contains(msg.text, dest) {
+		t.Fatalf("unexpected status %+v", msg)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "epoch 1\nloss nan\n" {
+		t.Fatalf("expected plain text with a trailing newline, got %q", data)
+	}
+
+	msg = exportLogCmd("61", "stderr", filepath.Join(dir, "missing", "out.txt"), "x")().(statusMsg)
+	if msg.color != "196" || !strings.Contains(msg.text, "export 61 stderr") {
+		t.Fatalf("expected a write failure status, got %+v", msg)
+	}
+
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, msg)
+	if len(m.statusQueue) == 0 || m.statusQueue[len(m.statusQueue)-1].color != "196" {
+		t.Fatalf("expected the failure in the status bar")
+	}
+}
+
+func TestModelErrorSurvivesRefresh(t *testing.T) {
+	clock := time.Date(2024, 1, 15, 14, 32, 5, 0, time.UTC)
+	m := initialModel(defaultConfig())
+	m.now = func() time.Time { return clock }
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}})
+	m, _ = updateModel(t, m, errMsg(errors.New("connection refused")))
+	for range 4 {
+		clock = clock.Add(jobsRefreshEvery)
+		m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}})
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "squeue error: connection refused") {
+		t.Fatalf("expected the error to stay visible across refreshes:\n%s", view)
+	}
+}
+
+func TestModelToggleLineNumbers(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.LogDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(cfg.LogDir, "71.out"), []byte("first\nsecond\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := initialModel(cfg)
+	m.isRefreshing = true // keep ticks from spawning squeue
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "71", Name: "train", State: "RUNNING"}})
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+
+	m, _ = updateModel(t, m, keyMsg("L"))
+	if !m.showLineNumbers || !strings.HasPrefix(m.outContentCache, "     1 │ first\n     2 │ second") {
+		t.Fatalf("expected numbered stdout, got %q", m.outContentCache)
+	}
+	m, _ = updateModel(t, m, keyMsg("L"))
+	if m.showLineNumbers || m.outContentCache != "first\nsecond" {
+		t.Fatalf("expected plain stdout after toggling off, got %q", m.outContentCache)
+	}
+}
+
+func TestModelColorizeLogToggle(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.LogDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(cfg.LogDir, "72.out"), []byte("INFO start\nplain\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := initialModel(cfg)
+	m.isRefreshing = true // keep ticks from spawning squeue
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "72", Name: "train", State: "RUNNING"}})
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+
+	m, _ = updateModel(t, m, keyMsg("C"))
+	if !strings.HasPrefix(m.outContentCache, "\x1b[38;5;51mINFO start") {
+		t.Fatalf("expected the INFO line colored, got %q", m.outContentCache)
+	}
+	m, _ = updateModel(t, m, keyMsg("C"))
+	if m.outContentCache != "INFO start\nplain" {
+		t.Fatalf("expected plain content after toggling off, got %q", m.outContentCache)
+	}
+}
+
+func TestModelMergedTimestampToggle(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.LogDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(cfg.LogDir, "84.out"), []byte("step 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := initialModel(cfg)
+	m.isRefreshing = true // keep ticks from spawning squeue
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "84", Name: "train", State: "RUNNING"}})
+	m, _ = updateModel(t, m, keyMsg("m"))
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+	if m.mergedContentCache != "[OUT] step 1" {
+		t.Fatalf("unexpected merged content %q", m.mergedContentCache)
+	}
+
+	m, _ = updateModel(t, m, keyMsg("T"))
+	if !regexp.MustCompile(`^\d{2}:\d{2}:\d{2}\.\d{3} \[OUT\] step 1$`).MatchString(m.mergedContentCache) {
+		t.Fatalf("expected a timestamped merged pane, got %q", m.mergedContentCache)
+	}
+	m, _ = updateModel(t, m, keyMsg("T"))
+	if m.mergedContentCache != "[OUT] step 1" {
+		t.Fatalf("expected timestamps gone after toggling off, got %q", m.mergedContentCache)
+	}
+}
+
+func TestLogPaths(t *testing.T) {
+	job := Job{ID: "81", Name: "train", User: "bob", Nodes: "gpu[03-04]"}
+	cfg := defaultConfig()
+	out, errPath := logPaths(job, cfg)
+	if out != filepath.Join("slurm_logs", "81.out") || errPath != filepath.Join("slurm_logs", "81.err") {
+		t.Fatalf("unexpected default paths %q %q", out, errPath)
+	}
+
+	cfg.LogOutPattern = "/scratch/%u/%n-%j.log"
+	out, errPath = logPaths(job, cfg)
+	if out != "/scratch/bob/train-81.log" || errPath != filepath.Join("slurm_logs", "81.err") {
+		t.Fatalf("expected only stdout to follow its pattern, got %q %q", out, errPath)
+	}
+	cfg.LogErrPattern = "/tmp/%N/%j.%%err"
+	if _, errPath = logPaths(job, cfg); errPath != "/tmp/gpu03/81.%err" {
+		t.Fatalf("unexpected stderr path %q", errPath)
+	}
+}
+
+func TestModelResolvesLogPathsAsync(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.LogDir = t.TempDir()
+	found := filepath.Join(t.TempDir(), "train-91.log")
+	if err := os.WriteFile(found, []byte("from scontrol\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"91.out", "91.err"} {
+		if err := os.WriteFile(filepath.Join(cfg.LogDir, name), []byte("configured\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := &mockSlurmClient{logPaths: map[string][2]string{"91": {found, found}}}
+	m := initialModel(cfg)
+	m.slurm = client
+	m.isRefreshing = true
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "91", Name: "train", State: "RUNNING"}, {ID: "92", Name: "old", State: "COMPLETED"}})
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+	if len(client.discovered) != 0 {
+		t.Fatalf("expected no scontrol call inside Update, got %v", client.discovered)
+	}
+	if want := filepath.Join(cfg.LogDir, "91.out"); m.outFollower.path != want {
+		t.Fatalf("expected the configured path until scontrol answers, got %q", m.outFollower.path)
+	}
+	if m.maybeDiscoverLogPaths() != nil {
+		t.Fatalf("expected the lookup to be in flight already")
+	}
+
+	m, _ = updateModel(t, m, discoverLogPathsCmd(client, "91")())
+	if m.outFollower.path != found || m.errFollower.path != found || m.outContentCache != "from scontrol" {
+		t.Fatalf("expected the followers to move to %q, got %q (%q)", found, m.outFollower.path, m.outContentCache)
+	}
+
+	// A failed lookup is cached too, so moving over the job again is free.
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, discoverLogPathsCmd(client, "92")())
+	for range 3 {
+		m, _ = updateModel(t, m, keyMsg("k"))
+		m, _ = updateModel(t, m, keyMsg("j"))
+	}
+	if m.maybeDiscoverLogPaths() != nil || len(client.discovered) != 2 {
+		t.Fatalf("expected one lookup per job, got %v", client.discovered)
+	}
+	if rec, _ := m.store.Record("92"); !rec.LogPathsQueried || rec.LogOutPath != "" {
+		t.Fatalf("expected the failure to be recorded, got %+v", rec)
+	}
+}
+
+func TestModelDiscoversMissingLogPaths(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.LogDir = t.TempDir()
+	dir := filepath.Join(t.TempDir(), "my runs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	outPath, errPath := filepath.Join(dir, "eval 82.out"), filepath.Join(dir, "eval 82.err")
+	if err := os.WriteFile(outPath, []byte("found it\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &mockSlurmClient{logPaths: map[string][2]string{"82": {outPath, errPath}}}
+	m := initialModel(cfg)
+	m.slurm = client
+	m.isRefreshing = true // keep ticks from spawning squeue
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	// Paths remembered from an earlier session that no longer exist.
+	jobs := []Job{{ID: "82", Name: "eval", State: "RUNNING"}}
+	m.store.ApplySnapshot(jobs, time.Now())
+	m.store.SetLogPaths("82", filepath.Join(cfg.LogDir, "82.out"), filepath.Join(cfg.LogDir, "82.err"))
+	m, _ = updateModel(t, m, jobMsg(jobs))
+	if m.logPathsRequested["82"] {
+		t.Fatalf("expected stored paths to be used without asking scontrol")
+	}
+
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+	if !m.outFollower.missing || !m.logPathsRequested["82"] || len(client.discovered) != 0 {
+		t.Fatalf("expected the missing file to start one async lookup, got %v", client.discovered)
+	}
+	m, _ = updateModel(t, m, discoverLogPathsCmd(client, "82")())
+	if m.outFollower.path != outPath || m.errFollower.path != errPath {
+		t.Fatalf("expected discovered paths, got %q %q", m.outFollower.path, m.errFollower.path)
+	}
+	if m.outContentCache != "found it" {
+		t.Fatalf("expected discovered stdout content, got %q", m.outContentCache)
+	}
+	if rec, _ := m.store.Record("82"); rec.LogOutPath != outPath {
+		t.Fatalf("expected discovered paths to be stored, got %q", rec.LogOutPath)
+	}
+
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+	if m.logPathsRequested["82"] || m.maybeDiscoverLogPaths() != nil {
+		t.Fatalf("expected discovery to run once")
+	}
+
+	// A job whose selection-time lookup failed is not asked again when its
+	// configured file turns out missing.
+	m, _ = updateModel(t, m, jobMsg{{ID: "82", Name: "eval", State: "RUNNING"}, {ID: "83", Name: "old", State: "COMPLETED"}})
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, discoverLogPathsCmd(client, "83")())
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+	if !m.outFollower.missing || m.logPathsRequested["83"] || len(client.discovered) != 2 {
+		t.Fatalf("expected the cached failure to be reused, got %v", client.discovered)
+	}
+}
+
+func TestModelPersistsJobStore(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.StorePath = filepath.Join(t.TempDir(), "store.json")
+	m := initialModel(cfg)
+	m.isRefreshing = true // keep ticks from spawning squeue
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "86", Name: "train", State: "FAILED"}})
+	m, _ = updateModel(t, m, keyMsg("d"))
+
+	save := m.saveStoreCmd()
+	// Update keeps writing the store while the save runs; the command must
+	// work from its own copy.
+	m, _ = updateModel(t, m, jobMsg{{ID: "86", Name: "train", State: "FAILED"}, {ID: "87", Name: "late", State: "RUNNING"}})
+	if msg := save(); msg != nil {
+		t.Fatalf("unexpected save result %#v", msg)
+	}
+	restarted := initialModel(cfg)
+	if _, ok := restarted.store.Record("87"); ok {
+		t.Fatalf("expected the save to hold the records from when it was issued")
+	}
+	if rec, ok := restarted.store.Record("86"); !ok || !rec.Dismissed {
+		t.Fatalf("expected the dismissed job to survive a restart, got %+v (found=%v)", rec, ok)
+	}
+
+	cfg.StorePath = ""
+	if disabled := initialModel(cfg); disabled.saveStoreCmd() != nil {
+		t.Fatalf("expected no save command with persistence disabled")
+	}
+}
+
+func TestModelRetentionZeroDismissesOnFirstTick(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.TerminalRetentionDuration = 0
+	m := initialModel(cfg)
+	m.isRefreshing = true // keep ticks from spawning squeue
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "87", State: "FAILED"}, {ID: "88", State: "RUNNING"}})
+	if len(m.jobs) != 2 {
+		t.Fatalf("expected both jobs before the tick, got %+v", m.jobs)
+	}
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+	if len(m.jobs) != 1 || m.jobs[0].ID != "88" || m.selectedID != "88" {
+		t.Fatalf("expected only the running job left selected, got %+v (selected %q)", m.jobs, m.selectedID)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	cases := map[time.Duration]string{
+		-time.Second:                   "0s",
+		0:                              "0s",
+		1500 * time.Millisecond:        "1s",
+		59 * time.Second:               "59s",
+		time.Minute:                    "1m0s",
+		12*time.Minute + 5*time.Second: "12m5s",
+		time.Hour - time.Second:        "59m59s",
+		time.Hour:                      "1h0m",
+		2*time.Hour + 15*time.Minute:   "2h15m",
+		24*time.Hour - time.Minute:     "23h59m",
+		24 * time.Hour:                 "1d0h",
+		3*24*time.Hour + 4*time.Hour:   "3d4h",
+		400 * 24 * time.Hour:           "400d0h",
+	}
+	for d, want := range cases {
+		if got := formatDuration(d); got != want {
+			t.Fatalf("formatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestJobDetailLinesFirstSeen(t *testing.T) {
+	now := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	rec := JobRecord{FirstSeen: now.Add(-90 * time.Minute)}
+	lines := jobDetailLines(JobDetail{JobID: "88"}, rec, now, time.UTC)
+	if !slices.Contains(lines, "FirstSeen   2024-01-15 12:30:00 (1h30m ago)") {
+		t.Fatalf("expected a FirstSeen row, got %q", lines)
+	}
+	if lines := jobDetailLines(JobDetail{JobID: "88"}, JobRecord{}, now, time.UTC); !slices.Contains(lines, "FirstSeen   -") {
+		t.Fatalf("expected a placeholder for unknown jobs, got %q", lines)
+	}
+}
+
+func TestJobDetailLinesClusterTime(t *testing.T) {
+	cet := time.FixedZone("CET", 3600)
+	d := JobDetail{JobID: "89", SubmitTime: "2024-01-15T09:00:00", StartTime: "Unknown"}
+	lines := jobDetailLines(d, JobRecord{}, time.Now(), cet)
+	for _, want := range []string{"SubmitTime  2024-01-15 09:00:00 CET", "StartTime   Unknown", "EndTime     -"} {
+		if !slices.Contains(lines, want) {
+			t.Fatalf("expected %q in the cluster's zone, got %q", want, lines)
+		}
+	}
+}
+
+func TestPartitionColumn(t *testing.T) {
+	cfg := defaultConfig()
+	titles := func(m model) []string {
+		var out []string
+		for _, col := range m.jobColumns() {
+			out = append(out, col.title)
+		}
+		return out
+	}
+	m := initialModel(cfg)
+	if slices.Contains(titles(m), "PARTITION") {
+		t.Fatalf("expected the partition column to be optional, got %v", titles(m))
+	}
+	cfg.Columns = []string{"partition"}
+	m = initialModel(cfg)
+	got := titles(m)
+	if i := slices.Index(got, "PARTITION"); i < 1 || got[i-1] != "STATE" || got[i+1] != "TIME" {
+		t.Fatalf("expected PARTITION between STATE and TIME, got %v", got)
+	}
+}
+
+func TestReasonColumn(t *testing.T) {
+	cases := map[Job]string{
+		{State: "PENDING", Reason: "Priority"}:              "PENDING (Priority)",
+		{State: "PENDING", Reason: "None"}:                  "PENDING",
+		{State: "RUNNING", Reason: "Priority"}:              "RUNNING",
+		{State: "PENDING", Reason: "Priority", Position: 3}: "PENDING (Priority) Pos: 3",
+		{State: "PENDING", Position: 1}:                     "PENDING Pos: 1",
+	}
+	for job, want := range cases {
+		if got := stateCell(job); got != want {
+			t.Fatalf("state cell for %+v = %q, want %q", job, got, want)
+		}
+	}
+
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}})
+	if got := m.stateColumnWidth(); got != 11 {
+		t.Fatalf("expected the default STATE width without pending jobs, got %d", got)
+	}
+	m, _ = updateModel(t, m, jobMsg{{ID: "1", State: "RUNNING"}, {ID: "2", State: "PENDING", Reason: "Priority"}})
+	if got := m.stateColumnWidth(); got != len("PENDING (Priority) Pos: 1") {
+		t.Fatalf("expected STATE to grow for the pending note, got %d", got)
+	}
+	colors := map[string]lipgloss.Color{"Resources": "220", "Dependency": "196", "PartitionTimeLimit": "196", "BeginTime": "244"}
+	for r, want := range colors {
+		if got := reasonColor(r); got != want {
+			t.Fatalf("reasonColor(%q) = %v, want %v", r, got, want)
+		}
+	}
+}
+
+func TestModelQueuePositions(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m.isRefreshing = true
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "1", Name: "a", State: "RUNNING", Partition: "gpu"},
+		{ID: "2", Name: "b", State: "PENDING", Partition: "gpu", Priority: 10},
+		{ID: "3", Name: "c", State: "PENDING", Partition: "gpu", Priority: 50, Reason: "Resources"},
+	})
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "(Resources) Pos: 1") || !strings.Contains(view, "Pos: 2") {
+		t.Fatalf("expected queue positions in the job list:\n%s", view)
+	}
+}
+
+func TestJobDetailLinesAccount(t *testing.T) {
+	rec := JobRecord{Job: Job{ID: "91", Account: "ml-lab", Partition: "gpu"}}
+	lines := jobDetailLines(JobDetail{JobID: "91"}, rec, time.Now(), time.UTC)
+	for _, want := range []string{"Account     ml-lab", "Partition   gpu"} {
+		if !slices.Contains(lines, want) {
+			t.Fatalf("expected %q from the squeue record, got %q", want, lines)
+		}
+	}
+	lines = jobDetailLines(JobDetail{JobID: "91", Account: "physics"}, rec, time.Now(), time.UTC)
+	if !slices.Contains(lines, "Account     physics") {
+		t.Fatalf("expected scontrol's account to win, got %q", lines)
+	}
+}
+
+func TestTimeLimitColor(t *testing.T) {
+	cases := []struct {
+		fraction float64
+		want     lipgloss.Color
+		ok       bool
+	}{
+		{0.5, "", false},
+		{0.8, "", false},
+		{0.81, "208", true},
+		{0.95, "208", true},
+		{0.96, "196", true},
+		{1.2, "196", true},
+	}
+	for _, tc := range cases {
+		if got, ok := timeLimitColor(tc.fraction); got != tc.want || ok != tc.ok {
+			t.Fatalf("timeLimitColor(%v) = %v, %v; want %v, %v", tc.fraction, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestModelSstatPolling(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	m := initialModel(defaultConfig())
+	m.now = func() time.Time { return now }
+	m.isRefreshing = true // keep ticks from spawning squeue
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "94", Name: "train", State: "RUNNING", Time: "1:40:00"}})
+	if _, ok := m.sstatPolledAt["94"]; !ok {
+		t.Fatalf("expected the running job to be polled")
+	}
+	if cmd := m.maybeFetchSstat(); cmd != nil {
+		t.Fatalf("expected no second poll within %s", sstatInterval)
+	}
+	now = now.Add(sstatInterval)
+	if cmd := m.maybeFetchSstat(); cmd == nil {
+		t.Fatalf("expected a poll after %s", sstatInterval)
+	}
+
+	m, _ = updateModel(t, m, sstatMsg{jobID: "94", stats: LiveStats{AveCPU: "01:15:00", MaxRSS: "2097152K"}})
+	if rec, _ := m.store.Record("94"); rec.LiveStats == nil || rec.LiveStats.MaxRSS != "2097152K" {
+		t.Fatalf("expected live stats stored, got %+v", rec.LiveStats)
+	}
+	if len(m.cpuSamples) != 1 || m.cpuSamples[0] != 0.75 {
+		t.Fatalf("expected a CPU sample of 0.75, got %v", m.cpuSamples)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "CPU: 75% RSS: 2.0 GB") {
+		t.Fatalf("expected the live stats in the job info line")
+	}
+
+	m, _ = updateModel(t, m, jobMsg{{ID: "95", Name: "eval", State: "PENDING"}})
+	if _, ok := m.sstatPolledAt["95"]; ok {
+		t.Fatalf("expected pending jobs not to be polled")
+	}
+}
+
+// updateKeys returns the keys of the switch statements on key directly in
+// the tea.KeyMsg case of Update.
+func updateKeys(t *testing.T) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "ui.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "Update" || fn.Recv == nil {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			clause, ok := n.(*ast.CaseClause)
+			if !ok || len(clause.List) != 1 {
+				return true
+			}
+			if sel, ok := clause.List[0].(*ast.SelectorExpr); !ok || sel.Sel.Name != "KeyMsg" {
+				return true
+			}
+			for _, stmt := range clause.Body {
+				sw, ok := stmt.(*ast.SwitchStmt)
+				if !ok {
+					continue
+				}
+				if ident, ok := sw.Tag.(*ast.Ident); !ok || ident.Name != "key" {
+					continue
+				}
+				for _, c := range sw.Body.List {
+					for _, expr := range c.(*ast.CaseClause).List {
+						if lit, ok := expr.(*ast.BasicLit); ok {
+							key, _ := strconv.Unquote(lit.Value)
+							keys = append(keys, key)
+						}
+					}
+				}
+			}
+			return false
+		})
+	}
+	return keys
+}
+
+func TestHelpCoversUpdateKeys(t *testing.T) {
+	documented := map[string]bool{}
+	for _, kb := range defaultKeymap {
+		documented[kb.key] = true
+		for _, key := range strings.FieldsFunc(kb.key, func(r rune) bool { return r == '/' || r == ' ' }) {
+			documented[key] = true
+		}
+	}
+	keys := updateKeys(t)
+	if len(keys) < 30 {
+		t.Fatalf("expected to find the key handlers of Update, got %v", keys)
+	}
+	help := ansi.Strip(strings.Join(helpLines(), "\n"))
+	for _, key := range keys {
+		name := key
+		if key == " " {
+			name = "space"
+		}
+		if !documented[name] {
+			t.Errorf("key %q is handled in Update but missing from defaultKeymap", key)
+		}
+		if !strings.Contains(help, name) {
+			t.Errorf("key %q is missing from the help text", name)
+		}
+	}
+}
+
+func TestModelHelpOverlay(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 20})
+
+	m, _ = updateModel(t, m, keyMsg("?"))
+	if !m.helpOpen() {
+		t.Fatalf("expected ? to open the help overlay")
+	}
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "Keybindings") || !strings.Contains(view, "toggle this help") {
+		t.Fatalf("expected the keymap in the overlay:\n%s", view)
+	}
+	m, _ = updateModel(t, m, keyMsg("j"))
+	if m.modal.offset != 1 {
+		t.Fatalf("expected j to scroll the help, offset %d", m.modal.offset)
+	}
+	m, _ = updateModel(t, m, keyMsg("k"))
+	if m.modal.offset != 0 {
+		t.Fatalf("expected k to scroll back, offset %d", m.modal.offset)
+	}
+	m, _ = updateModel(t, m, keyMsg("?"))
+	if m.modal != nil {
+		t.Fatalf("expected ? to close the help overlay")
+	}
+	m, _ = updateModel(t, m, keyMsg("?"))
+	m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.modal != nil {
+		t.Fatalf("expected esc to close the help overlay")
+	}
+}
+
+func TestHitTestJobRow(t *testing.T) {
+	cases := []struct{ clickY, top, offset, want int }{
+		{4, 4, 0, -1}, // column header
+		{5, 4, 0, -1}, // rule
+		{6, 4, 0, 0},
+		{9, 4, 0, 3},
+		{9, 4, 10, 13},
+		{5, 3, 2, 2}, // compact layout
+		{1, 4, 0, -1},
+	}
+	for _, tc := range cases {
+		if got := hitTestJobRow(tc.clickY, tc.top, tc.offset); got != tc.want {
+			t.Fatalf("hitTestJobRow(%d, %d, %d) = %d, want %d", tc.clickY, tc.top, tc.offset, got, tc.want)
+		}
+	}
+}
+
+func TestHitTestArea(t *testing.T) {
+	sized := func(width, height int, compact, merged bool) model {
+		cfg := defaultConfig()
+		cfg.CompactMode = compact
+		m := initialModel(cfg)
+		m.mergedMode = merged
+		m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: width, Height: height})
+		return m
+	}
+	cases := []struct {
+		name string
+		m    model
+		x, y int
+		want int
+	}{
+		{"header", sized(120, 40, false, false), 10, 1, -1},
+		{"jobs border", sized(120, 40, false, false), 10, 3, 0},
+		{"jobs row", sized(120, 40, false, false), 10, 6, 0},
+		{"jobs bottom border", sized(120, 40, false, false), 10, 14, 0},
+		{"stdout border", sized(120, 40, false, false), 10, 15, 1},
+		{"stdout", sized(120, 40, false, false), 59, 20, 1},
+		{"stderr", sized(120, 40, false, false), 60, 20, 2},
+		{"below logs", sized(120, 40, false, false), 10, 40, -1},
+		{"merged", sized(120, 40, false, true), 100, 20, 1},
+		{"compact jobs", sized(80, 24, true, false), 5, 3, 0},
+		{"compact stderr", sized(80, 24, true, false), 70, 12, 2},
+		{"small jobs", sized(60, 20, false, false), 5, 8, 0},
+		{"small stdout", sized(60, 20, false, false), 5, 9, 1},
+		{"outside width", sized(60, 20, false, false), 60, 12, -1},
+	}
+	for _, tc := range cases {
+		if got := hitTestArea(tc.x, tc.y, tc.m); got != tc.want {
+			t.Fatalf("%s: hitTestArea(%d, %d) = %d, want %d", tc.name, tc.x, tc.y, got, tc.want)
+		}
+	}
+}
+
+func TestModelMouse(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m.isRefreshing = true
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{
+		{ID: "1", Name: "a", State: "RUNNING"},
+		{ID: "2", Name: "b", State: "RUNNING"},
+		{ID: "3", Name: "c", State: "PENDING"},
+	})
+	click := func(x, y int) tea.MouseMsg {
+		return tea.MouseMsg{X: x, Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress}
+	}
+
+	m, _ = updateModel(t, m, click(10, 8))
+	if m.selectedID != "3" || m.focusArea != 0 {
+		t.Fatalf("expected a click on the third row to select job 3, got %q (focus %d)", m.selectedID, m.focusArea)
+	}
+	m, _ = updateModel(t, m, click(10, 12))
+	if m.selectedID != "3" {
+		t.Fatalf("expected a click below the last job to keep the selection, got %q", m.selectedID)
+	}
+	m, _ = updateModel(t, m, click(80, 25))
+	if m.focusArea != 2 {
+		t.Fatalf("expected a click on stderr to focus it, got %d", m.focusArea)
+	}
+
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	m.vpOut.SetContent(strings.Join(lines, "\n"))
+	m.vpOut.GotoBottom()
+	m, _ = updateModel(t, m, tea.MouseMsg{X: 10, Y: 25, Button: tea.MouseButtonWheelUp, Action: tea.MouseActionPress})
+	if m.followOut || m.vpOut.AtBottom() {
+		t.Fatalf("expected the wheel to scroll stdout up and stop following")
+	}
+	if m.focusArea != 2 {
+		t.Fatalf("expected the wheel not to move focus")
+	}
+
+	m, _ = updateModel(t, m, keyMsg("?"))
+	m, _ = updateModel(t, m, click(10, 6))
+	if m.selectedID != "3" {
+		t.Fatalf("expected clicks to be ignored while a modal is open")
+	}
+}
+
+func TestSplitRatio(t *testing.T) {
+	ratio := defaultSplitRatio
+	for range 20 {
+		ratio = adjustSplitRatio(ratio, splitRatioStep)
+	}
+	if ratio != maxSplitRatio {
+		t.Fatalf("expected the ratio to clamp at %v, got %v", maxSplitRatio, ratio)
+	}
+	for range 20 {
+		ratio = adjustSplitRatio(ratio, -splitRatioStep)
+	}
+	if ratio != minSplitRatio {
+		t.Fatalf("expected the ratio to clamp at %v, got %v", minSplitRatio, ratio)
+	}
+	if got := adjustSplitRatio(adjustSplitRatio(defaultSplitRatio, splitRatioStep), -splitRatioStep); got != defaultSplitRatio {
+		t.Fatalf("expected steps to round trip, got %v", got)
+	}
+
+	cases := []struct {
+		body       int
+		ratio      float64
+		jobs, logs int
+	}{
+		{33, defaultSplitRatio, 10, 22},
+		{8, defaultSplitRatio, 3, 4},
+		{100, defaultSplitRatio, 33, 66},
+		{33, minSplitRatio, 3, 29},
+		{100, minSplitRatio, 10, 89},
+		{33, maxSplitRatio, 29, 3},
+		{8, maxSplitRatio, 7, 3},
+		{100, maxSplitRatio, 90, 9},
+	}
+	for _, tc := range cases {
+		jobs, logs := splitHeights(tc.body, tc.ratio)
+		if jobs != tc.jobs || logs != tc.logs {
+			t.Fatalf("splitHeights(%d, %v) = %d, %d, want %d, %d", tc.body, tc.ratio, jobs, logs, tc.jobs, tc.logs)
+		}
+	}
+}
+
+func TestModelSplitRatioKeys(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	jobsHeight := m.vpJobs.Height
+
+	m, _ = updateModel(t, m, keyMsg("+"))
+	if m.vpJobs.Height <= jobsHeight || m.vpOut.Height != 33-m.vpJobs.Height-1 || m.vpMerged.Height != m.vpOut.Height {
+		t.Fatalf("expected + to grow the jobs pane, got jobs %d logs %d", m.vpJobs.Height, m.vpOut.Height)
+	}
+	if !strings.Contains(ansi.Strip(m.View()), "split: 38%") {
+		t.Fatalf("expected the new ratio in the status bar")
+	}
+	m, _ = updateModel(t, m, keyMsg("-"))
+	if m.vpJobs.Height != jobsHeight || m.splitRatio != defaultSplitRatio {
+		t.Fatalf("expected - to restore the split, got %d (%v)", m.vpJobs.Height, m.splitRatio)
+	}
+}
+
+func TestModelFullscreenLog(t *testing.T) {
+	for _, compact := range []bool{false, true} {
+		cfg := defaultConfig()
+		cfg.CompactMode = compact
+		m := initialModel(cfg)
+		m.isRefreshing = true
+		m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+		m, _ = updateModel(t, m, jobMsg{{ID: "1", Name: "train", State: "RUNNING"}})
+		jobsWidth, jobsHeight, logsHeight := m.vpJobs.Width, m.vpJobs.Height, m.vpOut.Height
+		split := strings.Count(m.View(), "\n")
+
+		m, _ = updateModel(t, m, keyMsg("Z"))
+		if m.fullscreenLog {
+			t.Fatalf("expected Z to need a focused log pane")
+		}
+		m, _ = updateModel(t, m, keyMsg("tab"))
+		m, _ = updateModel(t, m, keyMsg("Z"))
+		if !m.fullscreenLog {
+			t.Fatalf("expected Z to maximize the log pane")
+		}
+		wantLogs := jobsHeight + logsHeight + 2
+		if compact {
+			wantLogs = jobsHeight + logsHeight
+		}
+		if m.vpOut.Height != wantLogs || m.vpErr.Height != wantLogs || m.vpMerged.Height != wantLogs {
+			t.Fatalf("compact=%v: expected log panes of %d rows, got %d", compact, wantLogs, m.vpOut.Height)
+		}
+		if m.vpJobs.Width != jobsWidth || m.vpJobs.Height != jobsHeight {
+			t.Fatalf("expected the jobs viewport size to be kept")
+		}
+		if got := strings.Count(m.View(), "\n"); got != split {
+			t.Fatalf("compact=%v: expected the same screen height, got %d lines, want %d", compact, got, split)
+		}
+		if hitTestArea(10, panesTop, m) != 1 {
+			t.Fatalf("expected the logs to start right below the info lines")
+		}
+
+		m, _ = updateModel(t, m, jobMsg{{ID: "1", Name: "train", State: "RUNNING"}, {ID: "2", Name: "hidden", State: "PENDING"}})
+		if strings.Contains(m.vpJobs.View(), "hidden") || strings.Contains(ansi.Strip(m.View()), "hidden") {
+			t.Fatalf("expected the jobs pane not to render in full-screen mode")
+		}
+		m, _ = updateModel(t, m, keyMsg("tab"))
+		if m.focusArea != 2 {
+			t.Fatalf("expected tab to skip the hidden jobs pane, got %d", m.focusArea)
+		}
+
+		m, _ = updateModel(t, m, keyMsg("Z"))
+		if m.fullscreenLog || m.vpJobs.Height != jobsHeight || m.vpOut.Height != logsHeight {
+			t.Fatalf("expected a second Z to restore the layout")
+		}
+		if !strings.Contains(m.vpJobs.View(), "hidden") {
+			t.Fatalf("expected the jobs pane to render again")
+		}
+
+		m, _ = updateModel(t, m, keyMsg("Z"))
+		m, _ = updateModel(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+		if m.fullscreenLog || m.vpOut.Height != logsHeight {
+			t.Fatalf("expected esc to restore the layout")
+		}
+	}
+}
+
+func TestModelPollsRemoteLogsAsync(t *testing.T) {
+	reader := &fakeLogReader{files: map[string]string{"/remote/77.out": "remote line\n"}}
+	cfg := defaultConfig()
+	cfg.LogDir = "/remote"
+	m := initialModel(cfg)
+	m.logReader = reader
+	m.isRefreshing = true
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "77", Name: "train", State: "RUNNING"}})
+
+	cmd := m.pollSelectedLogs()
+	if cmd == nil || len(reader.reads) != 0 {
+		t.Fatalf("expected remote reads to wait for the returned command, reads %v", reader.reads)
+	}
+	if m.pollSelectedLogs() != nil {
+		t.Fatalf("expected no second poll while one is in flight")
+	}
+	msg := cmd()
+	m, _ = updateModel(t, m, msg)
+	if m.outContentCache != "remote line" || m.remotePolling {
+		t.Fatalf("expected the remote chunk to be applied, got %q", m.outContentCache)
+	}
+
+	// A result that arrives after the user moved on is dropped.
+	reader.files["/remote/77.out"] += "late\n"
+	stale := m.pollSelectedLogs()()
+	m, _ = updateModel(t, m, jobMsg{{ID: "77", Name: "train", State: "RUNNING"}, {ID: "78", Name: "eval", State: "RUNNING"}})
+	m, _ = updateModel(t, m, keyMsg("j"))
+	m, _ = updateModel(t, m, stale)
+	if m.selectedID != "78" || strings.Contains(m.outContentCache, "late") || m.remotePolling {
+		t.Fatalf("expected the stale chunk to be dropped, got %q", m.outContentCache)
+	}
+}
+
+func TestGlobalSearchReadsRemoteLogs(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.LogDir = "/remote"
+	reader := &fakeLogReader{files: map[string]string{"/remote/5.err": "ok\nCUDA error: out of memory\n"}}
+	msg := globalSearchCmd("cuda", []Job{{ID: "5", Name: "train"}}, nil, cfg, reader)().(globalSearchMsg)
+	if len(msg.results) != 1 || msg.results[0].Stream != streamErr || msg.results[0].LineIdx != 1 {
+		t.Fatalf("expected one match in the remote stderr, got %+v", msg.results)
+	}
+}
+
+func TestModelGlobalSearchUsesResolvedLogPaths(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.LogDir = "/remote"
+	reader := &fakeLogReader{files: map[string]string{
+		"/remote/5.out":        "CUDA error in the pattern file\n",
+		"/scratch/train-5.out": "ok\nCUDA error: out of memory\n",
+	}}
+	m := initialModel(cfg)
+	m.logReader = reader
+	m.isRefreshing = true
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	jobs := []Job{{ID: "5", Name: "train", State: "RUNNING"}}
+	m.store.ApplySnapshot(jobs, time.Now())
+	m.store.SetLogPaths("5", "/scratch/train-5.out", "/scratch/train-5.err")
+	m, _ = updateModel(t, m, jobMsg(jobs))
+
+	m, _ = updateModel(t, m, keyMsg("ctrl+g"))
+	m.globalSearchInput.SetValue("cuda")
+	msg := m.handleGlobalSearchKey(keyMsg("enter"))().(globalSearchMsg)
+	if len(msg.results) != 1 || msg.results[0].LineIdx != 1 || msg.results[0].Preview != "CUDA error: out of memory" {
+		t.Fatalf("expected the match from the scontrol path, got %+v", msg.results)
+	}
+}
+
+func TestModelGlobalSearchKeepsSelectionVisible(t *testing.T) {
+	m := initialModel(defaultConfig())
+	m.isRefreshing = true
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, keyMsg("ctrl+g"))
+	var results []SearchResult
+	for i := 0; i < 100; i++ {
+		results = append(results, SearchResult{JobID: strconv.Itoa(1000 + i), Stream: streamOut, Preview: "match"})
+	}
+	m.globalSearchInput.Blur()
+	m, _ = updateModel(t, m, globalSearchMsg{query: "match", results: results})
+	for i := 0; i < 60; i++ {
+		m, _ = updateModel(t, m, keyMsg("j"))
+	}
+	view := ansi.Strip(m.vpJobs.View())
+	if !strings.Contains(view, ">  1060") || strings.Contains(view, " 1000 ") {
+		t.Fatalf("expected the list to scroll to the selected result:\n%s", view)
+	}
+	for i := 0; i < 60; i++ {
+		m, _ = updateModel(t, m, keyMsg("k"))
+	}
+	if view := ansi.Strip(m.vpJobs.View()); !strings.Contains(view, ">  1000") {
+		t.Fatalf("expected the list to scroll back up:\n%s", view)
+	}
+}
+
+func TestModelResumesCheckpointOnce(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.LogDir = t.TempDir()
+	cfg.CheckpointDir = t.TempDir()
+	outPath := filepath.Join(cfg.LogDir, "50.out")
+	if err := os.WriteFile(outPath, []byte("old 1\nold 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"50", "51"} {
+		f := newLogFollower(filepath.Join(cfg.LogDir, id+".out"))
+		f.checkpointID = id
+		f.offset, f.initialized = 12, true
+		if err := f.SaveCheckpoint(cfg.CheckpointDir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := initialModel(cfg)
+	m.isRefreshing = true
+	m, _ = updateModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = updateModel(t, m, jobMsg{{ID: "50", Name: "train", State: "COMPLETED"}, {ID: "51", Name: "eval", State: "COMPLETED"}})
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+	if m.outContentCache != "" {
+		t.Fatalf("expected the first job to resume after the checkpoint, got %q", m.outContentCache)
+	}
+	for _, id := range []string{"50", "51"} {
+		m, _ = updateModel(t, m, keyMsg("j"))
+		if _, err := os.Stat(checkpointPath(cfg.CheckpointDir, id)); !os.IsNotExist(err) {
+			t.Fatalf("expected the checkpoint of %s to be removed, got %v", id, err)
+		}
+	}
+	m, _ = updateModel(t, m, keyMsg("k"))
+	m, _ = updateModel(t, m, tickMsg(time.Now()))
+	if m.selectedID != "50" || m.outContentCache != "old 1\nold 2" {
+		t.Fatalf("expected the usual tail when coming back to the job, got %q", m.outContentCache)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func goldenModel(width, height int) model {
+	fixed := time.Date(2024, 1, 15, 14, 32, 5, 0, time.UTC)
+	m := initialModel(defaultConfig())
+	m.now = func() time.Time { return fixed }
+	m.lastJobFetch = fixed.Add(-2 * time.Second)
+	m.isRefreshing = false
+	next, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	m = next.(model)
+	m.jobs = []Job{
+		{ID: "101", Name: "train", State: "RUNNING", Time: "1:02:03", TimeLimit: "4:00:00", Nodes: "node01", TRES: "gres/gpu:2"},
+		{ID: "102", Name: "eval", State: "PENDING", Time: "0:00", TimeLimit: "1:00:00", Reason: "Priority"},
+		{ID: "103", Name: "preprocess", State: "FAILED", Time: "0:42", TimeLimit: "1:00:00", Nodes: "node07"},
+	}
+	m.store.ApplySnapshot(m.jobs, fixed.Add(-2*time.Hour-15*time.Minute))
+	m.selectedID = "101"
+	m.renderJobsViewport()
+	return m
+}
+
+func TestViewGolden(t *testing.T) {
+	cases := []struct {
+		name  string
+		setup func() model
+	}{
+		{"view_basic", func() model { return goldenModel(120, 40) }},
+		{"view_cancel_modal", func() model {
+			m := goldenModel(120, 40)
+			m.pending = &pendingAction{kind: "cancel", jobID: "101"}
+			return m
+		}},
+		{"view_narrow", func() model { return goldenModel(60, 40) }},
+		{"view_merged", func() model {
+			m := goldenModel(120, 40)
+			m.mergedMode = true
+			m.focusArea = 1
+			return m
+		}},
+		{"view_fullscreen_log", func() model {
+			m := goldenModel(120, 40)
+			m.focusArea = 1
+			next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Z")})
+			return next.(model)
+		}},
+		{"view_compact", func() model {
+			next, _ := goldenModel(120, 40).Update(tea.KeyMsg{Type: tea.KeyCtrlB})
+			return next.(model)
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ansi.Strip(tc.setup().View())
+			goldenPath := filepath.Join("testdata", "golden", tc.name+".txt")
+			if *update {
+				if err := os.WriteFile(goldenPath, []byte(got), 0o644); err != nil {
+					t.Fatalf("write golden: %v", err)
+				}
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("read golden: %v (run with -update to create)", err)
+			}
+			if got != string(want) {
+				t.Fatalf("view mismatch for %s\n--- got ---\n%s\n--- want ---\n%s", tc.name, got, want)
+			}
+		})
+	}
+}